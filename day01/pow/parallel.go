@@ -0,0 +1,151 @@
+package pow
+
+import (
+	"crypto/sha256"
+	"runtime"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// autoTuneDuration AutoTuneWorkers 测量每个候选worker数的时长
+const autoTuneDuration = 50 * time.Millisecond
+
+var (
+	tuneOnce     sync.Once
+	tunedWorkers int
+)
+
+// SolveParallel 与Solve相同，但由opts.Workers个goroutine并行搜索，Workers<=0时使用 AutoTuneWorkers 的结果
+// 第i个worker尝试 Start+i*Stride, Start+(i+Workers)*Stride, ...，各worker的搜索空间互不重叠，
+// 因此与 Start/Stride 的多机分布式搜索可以组合使用。返回最先找到的nonce，不一定是最小的。
+// HashFunc可复用内部缓冲区，不能在goroutine之间共享，newHash 为每个worker创建独立的哈希函数；
+// Progress 回调不会被并发调用，attempts 为所有worker的累计尝试次数；
+// 所有worker合计达到 MaxAttempts 或 Context 被取消仍未找到时 found 为false
+func SolveParallel(newHash func() HashFunc, bits int, opts Options) (nonce int64, sum []byte, found bool) {
+	workers := opts.Workers
+	if workers <= 0 {
+		workers = AutoTuneWorkers()
+	}
+	if workers == 1 {
+		return Solve(newHash(), bits, opts)
+	}
+
+	stride := opts.Stride
+	if stride <= 0 {
+		stride = 1
+	}
+
+	type result struct {
+		nonce int64
+		hash  []byte
+	}
+	results := make(chan result, workers)
+	var stop atomic.Bool
+	var attempts atomic.Int64
+	var progressMu sync.Mutex
+	var wg sync.WaitGroup
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func(start int64) {
+			defer wg.Done()
+			hash := newHash()
+			for nonce := start; !stop.Load(); nonce += stride * int64(workers) {
+				if sum := hash(nonce); HasLeadingZeroBits(sum, bits) {
+					results <- result{nonce, append([]byte(nil), sum...)}
+					stop.Store(true)
+					return
+				}
+				n := attempts.Add(1)
+				if opts.MaxAttempts > 0 && n >= opts.MaxAttempts {
+					stop.Store(true)
+					return
+				}
+				if n%ProgressInterval == 0 {
+					if opts.Progress != nil {
+						progressMu.Lock()
+						opts.Progress(int(n))
+						progressMu.Unlock()
+					}
+					if cancelled(opts.Context) {
+						stop.Store(true)
+						return
+					}
+				}
+			}
+		}(opts.Start + int64(i)*stride)
+	}
+
+	wg.Wait()
+	select {
+	case r := <-results:
+		return r.nonce, r.hash, true
+	default:
+		return 0, nil, false
+	}
+}
+
+// AutoTuneWorkers 返回本机并行搜索时算力最高的worker数
+// 依次以 1、NumCPU/2、NumCPU、NumCPU*2 个worker短暂测量SHA-256算力，取最高者；
+// 结果在进程内缓存，只测量一次
+func AutoTuneWorkers() int {
+	tuneOnce.Do(func() {
+		tunedWorkers = tuneWorkers(autoTuneDuration)
+	})
+	return tunedWorkers
+}
+
+// tuneWorkers 测量各候选worker数的算力并返回最佳值
+func tuneWorkers(d time.Duration) int {
+	best, bestRate := 1, 0.0
+	for _, workers := range candidateWorkers(runtime.NumCPU()) {
+		if rate := measureHashRate(workers, d); rate > bestRate {
+			best, bestRate = workers, rate
+		}
+	}
+	return best
+}
+
+// candidateWorkers 返回去重后的候选worker数
+func candidateWorkers(cpus int) []int {
+	var candidates []int
+	seen := make(map[int]bool)
+	for _, n := range []int{1, cpus / 2, cpus, cpus * 2} {
+		if n >= 1 && !seen[n] {
+			seen[n] = true
+			candidates = append(candidates, n)
+		}
+	}
+	return candidates
+}
+
+// measureHashRate 以workers个goroutine计算SHA-256持续d，返回每秒哈希次数
+func measureHashRate(workers int, d time.Duration) float64 {
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	deadline := time.Now().Add(d)
+
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			input := make([]byte, 0, 64)
+			count := int64(0)
+			for nonce := int64(0); ; nonce++ {
+				input = strconv.AppendInt(input[:0], nonce, 10)
+				sha256.Sum256(input)
+				count++
+				// 每ProgressInterval次检查一次时间，避免time.Now的开销影响测量
+				if count%ProgressInterval == 0 && time.Now().After(deadline) {
+					break
+				}
+			}
+			total.Add(count)
+		}()
+	}
+
+	wg.Wait()
+	return float64(total.Load()) / d.Seconds()
+}
@@ -0,0 +1,70 @@
+package pow
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"reflect"
+	"runtime"
+	"strconv"
+	"testing"
+)
+
+// newSHA256Hash 为每个worker创建计算 sha256(nonce的十进制表示) 的哈希函数
+func newSHA256Hash() HashFunc {
+	input := make([]byte, 0, 20)
+	return func(nonce int64) []byte {
+		input = strconv.AppendInt(input[:0], nonce, 10)
+		sum := sha256.Sum256(input)
+		return sum[:]
+	}
+}
+
+func TestSolveParallel(t *testing.T) {
+	for _, workers := range []int{1, 4} {
+		nonce, sum, found := SolveParallel(newSHA256Hash, 8, Options{Workers: workers})
+		if !found {
+			t.Fatalf("%d 个worker未找到解", workers)
+		}
+		want := sha256.Sum256([]byte(strconv.FormatInt(nonce, 10)))
+		if !reflect.DeepEqual(sum, want[:]) || !HasLeadingZeroBits(sum, 8) {
+			t.Fatalf("%d 个worker返回的 nonce %d 与哈希 %x 不符合要求", workers, nonce, sum)
+		}
+	}
+}
+
+func TestSolveParallelMaxAttempts(t *testing.T) {
+	if _, _, found := SolveParallel(newSHA256Hash, 256, Options{Workers: 4, MaxAttempts: 1000}); found {
+		t.Fatal("达到 MaxAttempts 后应停止搜索")
+	}
+}
+
+func TestCandidateWorkers(t *testing.T) {
+	tests := []struct {
+		cpus int
+		want []int
+	}{
+		{1, []int{1, 2}},
+		{2, []int{1, 2, 4}},
+		{8, []int{1, 4, 8, 16}},
+	}
+	for _, tt := range tests {
+		if got := candidateWorkers(tt.cpus); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("candidateWorkers(%d) = %v, want %v", tt.cpus, got, tt.want)
+		}
+	}
+}
+
+// BenchmarkSolveParallel 对比 AutoTuneWorkers 的各候选worker数求解16比特难度的耗时
+func BenchmarkSolveParallel(b *testing.B) {
+	for _, workers := range candidateWorkers(runtime.NumCPU()) {
+		b.Run(fmt.Sprintf("workers=%d", workers), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				// 每次从不同的起点搜索，避免重复求解同一个nonce
+				opts := Options{Workers: workers, Start: int64(i) << 32}
+				if _, _, found := SolveParallel(newSHA256Hash, 16, opts); !found {
+					b.Fatal("未找到解")
+				}
+			}
+		})
+	}
+}
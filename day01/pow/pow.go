@@ -0,0 +1,98 @@
+// Package pow 提供各练习共用的工作量证明求解器
+// 难度统一以哈希的前导0比特数表示，十六进制前导0个数可用 ZerosToBits 换算。
+package pow
+
+import "context"
+
+// BitsPerZero 每个十六进制前导0对应的比特数
+const BitsPerZero = 4
+
+// ProgressInterval 进度回调的间隔（尝试次数）
+const ProgressInterval = 1000
+
+// ZerosToBits 将十六进制前导0个数换算为比特难度
+func ZerosToBits(zeros int) int {
+	return zeros * BitsPerZero
+}
+
+// HashFunc 计算给定nonce对应的哈希，返回的切片只需在下一次调用前有效
+type HashFunc func(nonce int64) []byte
+
+// Options 控制搜索的起始nonce、步长、进度输出与停止条件
+type Options struct {
+	Start    int64              // 起始nonce
+	Stride   int64              // 步长，<=0时视为1
+	Progress func(attempts int) // 每尝试ProgressInterval次回调一次，为nil时不输出任何进度
+	Workers  int                // 并行搜索的goroutine数，仅SolveParallel使用，<=0时自动选择
+
+	// MaxAttempts 最多尝试的次数（并行搜索时为所有worker合计），<=0表示不限制
+	// 难度设置过高时避免搜索看起来永远卡住，也便于用很小的上限测试挖矿
+	MaxAttempts int64
+	// Context 取消后停止搜索，为nil时不会被取消；每ProgressInterval次尝试检查一次
+	Context context.Context
+}
+
+// Solve 从opts.Start开始按步长依次尝试nonce，直到哈希至少有bits个前导0比特
+// 返回命中的nonce及其哈希（副本）；达到 MaxAttempts 或 Context 被取消仍未找到时 found 为false
+func Solve(hash HashFunc, bits int, opts Options) (nonce int64, sum []byte, found bool) {
+	stride := opts.Stride
+	if stride <= 0 {
+		stride = 1
+	}
+
+	attempts := int64(0)
+	for nonce := opts.Start; ; nonce += stride {
+		if sum := hash(nonce); HasLeadingZeroBits(sum, bits) {
+			return nonce, append([]byte(nil), sum...), true
+		}
+
+		attempts++
+		if opts.MaxAttempts > 0 && attempts >= opts.MaxAttempts {
+			return 0, nil, false
+		}
+		if attempts%ProgressInterval == 0 {
+			if opts.Progress != nil {
+				opts.Progress(int(attempts))
+			}
+			if cancelled(opts.Context) {
+				return 0, nil, false
+			}
+		}
+	}
+}
+
+// cancelled 判断ctx是否已被取消，nil表示不会被取消
+func cancelled(ctx context.Context) bool {
+	return ctx != nil && ctx.Err() != nil
+}
+
+// HasLeadingZeroBits 判断哈希是否至少有bits个前导0比特
+// bits为负数或超过哈希的比特数时返回false：难度可能来自不可信的输入（如证书、对端的区块），不能不做任何工作就通过
+func HasLeadingZeroBits(sum []byte, bits int) bool {
+	if bits < 0 || bits > len(sum)*8 {
+		return false
+	}
+	for i := 0; i < bits/8; i++ {
+		if sum[i] != 0 {
+			return false
+		}
+	}
+	remainder := bits % 8
+	return remainder == 0 || sum[bits/8]>>(8-remainder) == 0
+}
+
+// LeadingZeroBits 统计哈希的前导0比特数
+func LeadingZeroBits(sum []byte) int {
+	bits := 0
+	for _, b := range sum {
+		if b != 0 {
+			for b&0x80 == 0 {
+				bits++
+				b <<= 1
+			}
+			return bits
+		}
+		bits += 8
+	}
+	return bits
+}
@@ -0,0 +1,62 @@
+package pow
+
+import "testing"
+
+// digestWithZeroBits 返回恰好有bits个前导0比特的32字节哈希
+func digestWithZeroBits(bits int) []byte {
+	sum := make([]byte, 32)
+	for i := range sum {
+		sum[i] = 0xff
+	}
+	for i := 0; i < bits/8; i++ {
+		sum[i] = 0
+	}
+	if bits/8 < len(sum) {
+		sum[bits/8] = 0xff >> (bits % 8)
+	}
+	return sum
+}
+
+func TestHasLeadingZeroBitsBoundaries(t *testing.T) {
+	tests := []struct {
+		zeros int // 哈希实际的前导0比特数
+		bits  int // 要求的比特数
+		want  bool
+	}{
+		{15, 15, true},
+		{15, 16, false},
+		{16, 15, true},
+		{16, 16, true},
+		{16, 17, false},
+		{17, 16, true},
+		{17, 17, true},
+		{17, 18, false},
+		{0, 0, true},
+		{0, 1, false},
+		{256, 256, true},
+	}
+	for _, tt := range tests {
+		sum := digestWithZeroBits(tt.zeros)
+		if got := HasLeadingZeroBits(sum, tt.bits); got != tt.want {
+			t.Errorf("前导0比特为 %d 的哈希要求 %d 比特: got %v, want %v", tt.zeros, tt.bits, got, tt.want)
+		}
+		if got := LeadingZeroBits(sum); got != tt.zeros {
+			t.Errorf("LeadingZeroBits = %d, want %d", got, tt.zeros)
+		}
+	}
+}
+
+func TestHasLeadingZeroBitsRejectsOutOfRange(t *testing.T) {
+	sum := digestWithZeroBits(256)
+	for _, bits := range []int{-1, -4, -8, -12, -1 << 30, 257} {
+		if HasLeadingZeroBits(sum, bits) {
+			t.Errorf("要求 %d 比特时应返回false", bits)
+		}
+	}
+}
+
+func TestZerosToBits(t *testing.T) {
+	if got := ZerosToBits(4); got != 16 {
+		t.Fatalf("ZerosToBits(4) = %d, want 16", got)
+	}
+}
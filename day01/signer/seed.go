@@ -0,0 +1,161 @@
+package signer
+
+import (
+	"crypto"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ecdh"
+	"crypto/ed25519"
+	"crypto/hkdf"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"fmt"
+	"io"
+	"math/big"
+)
+
+// KeyAlgo 密钥算法
+type KeyAlgo string
+
+const (
+	KeyAlgoRSA     KeyAlgo = "rsa"     // RSA-2048
+	KeyAlgoECDSA   KeyAlgo = "ecdsa"   // ECDSA P-256
+	KeyAlgoEd25519 KeyAlgo = "ed25519" // Ed25519
+)
+
+// seedRSABits 由种子派生的RSA密钥的位数
+const seedRSABits = 2048
+
+// seedSalt HKDF的盐，区分本包派生的密钥与种子的其他用途
+const seedSalt = "openspace/day01/signer seed key v1"
+
+// DeriveKeyFromSeed 由种子确定性地生成密钥对：相同的种子与算法总是得到相同的密钥
+// 返回 *rsa.PrivateKey、*ecdsa.PrivateKey 或 ed25519.PrivateKey。
+//
+// 仅用于演示与测试（如教程中固定钱包身份而不提交密钥文件）：种子通常是可猜测的短语，
+// 密钥的安全性不会超过种子本身；派生方式也不是BIP-32/BIP-39等钱包标准，不能用于保管真实资产。
+func DeriveKeyFromSeed(seed []byte, algo KeyAlgo) (crypto.Signer, error) {
+	if len(seed) == 0 {
+		return nil, fmt.Errorf("种子不能为空")
+	}
+
+	// 每种算法使用独立的派生流，同一种子的不同算法密钥互不相关
+	stream, err := newSeedStream(seed, string(algo))
+	if err != nil {
+		return nil, err
+	}
+
+	switch algo {
+	case KeyAlgoEd25519:
+		keySeed := make([]byte, ed25519.SeedSize)
+		io.ReadFull(stream, keySeed)
+		return ed25519.NewKeyFromSeed(keySeed), nil
+	case KeyAlgoECDSA:
+		return deriveECDSAKey(stream)
+	case KeyAlgoRSA:
+		return deriveRSAKey(stream, seedRSABits)
+	default:
+		return nil, fmt.Errorf("未知的密钥算法 %q（可选 rsa、ecdsa、ed25519）", algo)
+	}
+}
+
+// newSeedStream 以HKDF从种子派生AES-256密钥，返回CTR模式的确定性字节流
+// HKDF单次输出的长度有限，而RSA素数搜索需要的字节数不定，因此用派生的密钥驱动流密码
+func newSeedStream(seed []byte, info string) (io.Reader, error) {
+	key, err := hkdf.Key(sha256.New, seed, []byte(seedSalt), info, 32)
+	if err != nil {
+		return nil, fmt.Errorf("派生密钥失败: %v", err)
+	}
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	iv := make([]byte, aes.BlockSize)
+	return cipher.StreamReader{S: cipher.NewCTR(block, iv), R: zeroReader{}}, nil
+}
+
+// zeroReader 无限输出0字节，经流密码加密后即为密钥流
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
+
+// deriveECDSAKey 从字节流中取32字节作为P-256私钥标量，超出范围时继续读取
+func deriveECDSAKey(stream io.Reader) (crypto.Signer, error) {
+	scalar := make([]byte, 32)
+	for {
+		io.ReadFull(stream, scalar)
+		key, err := ecdh.P256().NewPrivateKey(scalar)
+		if err != nil {
+			continue // 标量为0或不小于群的阶，概率约为2^-32
+		}
+		// 经PKCS#8转换为 *ecdsa.PrivateKey，避免使用已弃用的 crypto/elliptic 接口
+		der, err := x509.MarshalPKCS8PrivateKey(key)
+		if err != nil {
+			return nil, fmt.Errorf("编码ECDSA私钥失败: %v", err)
+		}
+		parsed, err := x509.ParsePKCS8PrivateKey(der)
+		if err != nil {
+			return nil, fmt.Errorf("解析ECDSA私钥失败: %v", err)
+		}
+		return parsed.(crypto.Signer), nil
+	}
+}
+
+// deriveRSAKey 从字节流中确定性地搜索两个素数并构造RSA私钥
+// 标准库的 rsa.GenerateKey 有意不保证对相同的随机源输出相同的密钥，因此自行生成素数
+func deriveRSAKey(stream io.Reader, bits int) (crypto.Signer, error) {
+	e := big.NewInt(65537)
+	one := big.NewInt(1)
+	for {
+		p := derivePrime(stream, bits/2)
+		q := derivePrime(stream, bits-bits/2)
+		if p.Cmp(q) == 0 {
+			continue
+		}
+
+		n := new(big.Int).Mul(p, q)
+		if n.BitLen() != bits {
+			continue
+		}
+		phi := new(big.Int).Mul(new(big.Int).Sub(p, one), new(big.Int).Sub(q, one))
+		d := new(big.Int).ModInverse(e, phi)
+		if d == nil {
+			continue // e与phi不互素
+		}
+
+		key := &rsa.PrivateKey{
+			PublicKey: rsa.PublicKey{N: n, E: int(e.Int64())},
+			D:         d,
+			Primes:    []*big.Int{p, q},
+		}
+		if err := key.Validate(); err != nil {
+			return nil, fmt.Errorf("派生的RSA密钥无效: %v", err)
+		}
+		key.Precompute()
+		return key, nil
+	}
+}
+
+// derivePrime 从字节流中依次读取候选数，返回第一个bits位的素数
+// 最高两位置1保证两个素数之积恰好为2*bits位，最低位置1排除偶数
+func derivePrime(stream io.Reader, bits int) *big.Int {
+	buf := make([]byte, (bits+7)/8)
+	candidate := new(big.Int)
+	for {
+		io.ReadFull(stream, buf)
+		if extra := len(buf)*8 - bits; extra > 0 {
+			buf[0] &= byte(0xFF >> extra)
+		}
+		candidate.SetBytes(buf)
+		candidate.SetBit(candidate, bits-1, 1)
+		candidate.SetBit(candidate, bits-2, 1)
+		candidate.SetBit(candidate, 0, 1)
+		if candidate.ProbablyPrime(20) {
+			return candidate
+		}
+	}
+}
@@ -0,0 +1,85 @@
+// Package signer 将签名逻辑与密钥存放方式解耦
+// 调用方只依赖 Signer 接口，私钥可以保存在进程内、文件中，或由HSM/KMS等外部设备持有。
+package signer
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"os"
+)
+
+// Signer 对SHA-256摘要进行签名的签名器
+type Signer interface {
+	// Sign 对摘要签名，digest 为消息的SHA-256摘要
+	Sign(digest []byte) ([]byte, error)
+	// Public 返回与签名私钥对应的公钥
+	Public() crypto.PublicKey
+}
+
+// KeySigner 使用进程内RSA私钥、以PKCS#1 v1.5签名的默认签名器
+type KeySigner struct {
+	key *rsa.PrivateKey
+}
+
+// NewKeySigner 由RSA私钥创建签名器
+func NewKeySigner(key *rsa.PrivateKey) *KeySigner {
+	return &KeySigner{key: key}
+}
+
+// NewFileSigner 从PEM文件加载RSA私钥并创建签名器
+func NewFileSigner(path string) (*KeySigner, error) {
+	key, err := LoadRSAKey(path)
+	if err != nil {
+		return nil, err
+	}
+	return NewKeySigner(key), nil
+}
+
+// Sign 对SHA-256摘要签名
+func (s *KeySigner) Sign(digest []byte) ([]byte, error) {
+	return rsa.SignPKCS1v15(rand.Reader, s.key, crypto.SHA256, digest)
+}
+
+// Public 返回RSA公钥
+func (s *KeySigner) Public() crypto.PublicKey {
+	return &s.key.PublicKey
+}
+
+// SignMessage 计算消息的SHA-256摘要并交由签名器签名
+func SignMessage(s Signer, message []byte) ([]byte, error) {
+	digest := sha256.Sum256(message)
+	signature, err := s.Sign(digest[:])
+	if err != nil {
+		return nil, fmt.Errorf("签名失败: %v", err)
+	}
+	return signature, nil
+}
+
+// LoadRSAKey 从PEM文件加载RSA私钥，支持PKCS#1与PKCS#8格式
+func LoadRSAKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("读取私钥文件失败: %v", err)
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("解析私钥PEM失败")
+	}
+	if key, err := x509.ParsePKCS1PrivateKey(block.Bytes); err == nil {
+		return key, nil
+	}
+	parsed, err := x509.ParsePKCS8PrivateKey(block.Bytes)
+	if err != nil {
+		return nil, fmt.Errorf("解析私钥失败: %v", err)
+	}
+	key, ok := parsed.(*rsa.PrivateKey)
+	if !ok {
+		return nil, fmt.Errorf("私钥不是RSA密钥")
+	}
+	return key, nil
+}
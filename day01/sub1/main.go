@@ -2,16 +2,17 @@ package main
 
 import (
 	"bufio"
-	"crypto/sha256"
-	"encoding/hex"
+	"flag"
 	"fmt"
 	"os"
 	"strconv"
 	"strings"
-	"time"
 )
 
 func main() {
+	quiet := flag.Bool("quiet", false, "Suppress progress output")
+	flag.Parse()
+
 	// 读取用户输入
 	reader := bufio.NewReader(os.Stdin)
 	fmt.Print("请输入您的昵称（直接回车使用默认值'胡良'）: ")
@@ -36,39 +37,23 @@ func main() {
 		break
 	}
 
-	targetPrefix := strings.Repeat("0", zeroCount)
-	startTime := time.Now()
-	iteration := 0
 	fmt.Printf("开始计算，目标：找到以%d个0开头的哈希值...\n", zeroCount)
 
-	for {
-		// 获取当前时间戳（纳秒级）
-		timestamp := time.Now().UnixNano()
-		fmt.Println(timestamp)
-
-		// 将昵称和时间戳拼接成字符串
-		data := fmt.Sprintf("%s%d", nickname, timestamp)
-
-		// 计算SHA-256哈希
-		hash := sha256.Sum256([]byte(data))
-		hashStr := hex.EncodeToString(hash[:])
-
-		// 检查是否满足条件
-		if len(hashStr) >= zeroCount && hashStr[:zeroCount] == targetPrefix {
-			elapsed := time.Since(startTime)
-			fmt.Printf("\n找到符合条件的哈希值！\n")
-			fmt.Printf("输入字符串: %s\n", data)
-			fmt.Printf("Hash值: %s\n", hashStr)
-			fmt.Printf("计算次数: %d\n", iteration+1)
-			fmt.Printf("耗时: %v\n", elapsed)
-			break
-		}
-
-		iteration++
-
-		// 每1000次输出一次进度
-		if iteration%1000 == 0 {
-			fmt.Printf("\r已尝试 %d 次...", iteration)
+	params := MineParams{Nickname: nickname, ZeroCount: zeroCount}
+	if !*quiet {
+		params.Progress = func(attempts int) {
+			fmt.Printf("\r已尝试 %d 次...", attempts)
 		}
 	}
+	result, err := MineVanity(params)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	fmt.Printf("\n找到符合条件的哈希值！\n")
+	fmt.Printf("输入字符串: %s\n", result.InputString)
+	fmt.Printf("Hash值: %s\n", result.HashHex)
+	fmt.Printf("计算次数: %d\n", result.Iterations)
+	fmt.Printf("耗时: %v\n", result.Elapsed)
 }
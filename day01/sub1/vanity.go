@@ -0,0 +1,60 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// progressInterval 进度回调的间隔（尝试次数）
+const progressInterval = 1000
+
+// MineParams 查找哈希的参数
+type MineParams struct {
+	Nickname  string             // 昵称
+	ZeroCount int                // 哈希需要以多少个十六进制0开头（1-64）
+	Progress  func(attempts int) // 每尝试progressInterval次回调一次，为nil时不输出进度
+}
+
+// MineResult 查找到的哈希及统计信息
+type MineResult struct {
+	InputString string        // 被哈希的字符串（昵称+时间戳）
+	HashHex     string        // 十六进制哈希值
+	Iterations  int           // 计算次数（含命中的一次）
+	Elapsed     time.Duration // 耗时
+	Timestamp   int64         // 命中时使用的时间戳（Unix纳秒）
+}
+
+// MineVanity 不断以当前纳秒时间戳拼接昵称计算SHA-256，直到哈希以ZeroCount个0开头
+func MineVanity(params MineParams) (MineResult, error) {
+	if params.ZeroCount < 1 || params.ZeroCount > sha256.Size*2 {
+		return MineResult{}, fmt.Errorf("0的个数必须在1-%d之间", sha256.Size*2)
+	}
+
+	targetPrefix := strings.Repeat("0", params.ZeroCount)
+	startTime := time.Now()
+	for iteration := 1; ; iteration++ {
+		// 获取当前时间戳（纳秒级），与昵称拼接成字符串
+		timestamp := time.Now().UnixNano()
+		data := params.Nickname + strconv.FormatInt(timestamp, 10)
+
+		hash := sha256.Sum256([]byte(data))
+		hashStr := hex.EncodeToString(hash[:])
+		if strings.HasPrefix(hashStr, targetPrefix) {
+			return MineResult{
+				InputString: data,
+				HashHex:     hashStr,
+				Iterations:  iteration,
+				Elapsed:     time.Since(startTime),
+				Timestamp:   timestamp,
+			}, nil
+		}
+
+		if params.Progress != nil && iteration%progressInterval == 0 {
+			params.Progress(iteration)
+		}
+	}
+}
@@ -0,0 +1,165 @@
+package main
+
+import (
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"time"
+
+	"openspace/day01/pow"
+	"openspace/day01/signer"
+)
+
+// Certificate 工作量证明证书
+// 证明某个身份（公钥）在某个时间找到了满足条件的哈希值
+type Certificate struct {
+	Nickname  string `json:"nickname"`   // 昵称
+	ZeroCount int    `json:"zero_count"` // 要求的前导0个数
+	Data      string `json:"data"`       // 输入数据（昵称+nonce）
+	Nonce     int64  `json:"nonce"`      // 命中的nonce
+	Timestamp int64  `json:"timestamp"`  // 找到哈希的时间（Unix纳秒）
+	Hash      string `json:"hash"`       // 哈希值
+	PublicKey string `json:"public_key"` // PEM编码的签名公钥
+	Signature string `json:"signature"`  // 十六进制编码的签名
+}
+
+// payload 返回证书中被签名的内容
+func (c *Certificate) payload() string {
+	return fmt.Sprintf("%s|%d|%d|%s", c.Data, c.Nonce, c.Timestamp, c.Hash)
+}
+
+// IssueCertificate 查找符合条件的哈希值并用签名器签名，生成工作量证明证书
+// 前导0个数须在1到64之间：不大于0的证书无法通过验证，过大则永远找不到
+func IssueCertificate(s signer.Signer, nickname string, zeroCount int) (*Certificate, error) {
+	if zeroCount < 1 || zeroCount > 64 {
+		return nil, fmt.Errorf("无效的前导0个数: %d", zeroCount)
+	}
+	data, nonce, hashStr := FindValidHash(nickname, zeroCount, SearchOptions{})
+
+	publicKeyBytes, err := x509.MarshalPKIXPublicKey(s.Public())
+	if err != nil {
+		return nil, fmt.Errorf("编码公钥失败: %v", err)
+	}
+
+	cert := &Certificate{
+		Nickname:  nickname,
+		ZeroCount: zeroCount,
+		Data:      data,
+		Nonce:     nonce,
+		Timestamp: time.Now().UnixNano(),
+		Hash:      hashStr,
+		PublicKey: string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: publicKeyBytes})),
+	}
+
+	signature, err := SignMessage(s, cert.payload())
+	if err != nil {
+		return nil, err
+	}
+	cert.Signature = hex.EncodeToString(signature)
+	return cert, nil
+}
+
+// VerifyCertificate 验证证书：输入数据、哈希、难度与签名均需匹配
+// 证书来自不可信的JSON，要求的前导0个数必须为正数，否则无需任何工作即可伪造证书
+func VerifyCertificate(cert *Certificate) error {
+	if cert.ZeroCount <= 0 {
+		return fmt.Errorf("无效的前导0个数 %d，必须大于0", cert.ZeroCount)
+	}
+	if cert.Data != cert.Nickname+strconv.FormatInt(cert.Nonce, 10) {
+		return fmt.Errorf("输入数据与昵称和nonce不匹配")
+	}
+
+	hash := sha256.Sum256([]byte(cert.Data))
+	if hex.EncodeToString(hash[:]) != cert.Hash {
+		return fmt.Errorf("哈希值与输入数据不匹配")
+	}
+	if !pow.HasLeadingZeroBits(hash[:], pow.ZerosToBits(cert.ZeroCount)) {
+		return fmt.Errorf("哈希值不满足%d个前导0的要求", cert.ZeroCount)
+	}
+
+	block, _ := pem.Decode([]byte(cert.PublicKey))
+	if block == nil {
+		return fmt.Errorf("解析公钥PEM失败")
+	}
+	pubInterface, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return fmt.Errorf("解析公钥失败: %v", err)
+	}
+	publicKey, ok := pubInterface.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("公钥不是RSA公钥")
+	}
+
+	signature, err := hex.DecodeString(cert.Signature)
+	if err != nil {
+		return fmt.Errorf("解析签名失败: %v", err)
+	}
+	if err := VerifySignature(publicKey, cert.payload(), signature); err != nil {
+		return fmt.Errorf("签名验证失败: %v", err)
+	}
+	return nil
+}
+
+// runCert 处理 cert 子命令：生成并输出工作量证明证书
+func runCert(args []string) error {
+	fs := flag.NewFlagSet("cert", flag.ExitOnError)
+	keyPath := fs.String("key", "private_key.pem", "PEM file with the RSA private key")
+	nickname := fs.String("nickname", "胡良", "Nickname to hash")
+	zeroCount := fs.Int("zeros", 4, "Number of leading zeros required")
+	out := fs.String("out", "", "Write the certificate to this file instead of stdout")
+	fs.Parse(args)
+
+	keySigner, err := signer.NewFileSigner(*keyPath)
+	if err != nil {
+		return err
+	}
+
+	cert, err := IssueCertificate(keySigner, *nickname, *zeroCount)
+	if err != nil {
+		return err
+	}
+
+	data, err := json.MarshalIndent(cert, "", "  ")
+	if err != nil {
+		return fmt.Errorf("编码证书失败: %v", err)
+	}
+	if *out == "" {
+		fmt.Println(string(data))
+		return nil
+	}
+	if err := os.WriteFile(*out, data, 0644); err != nil {
+		return fmt.Errorf("保存证书失败: %v", err)
+	}
+	fmt.Printf("\n证书已保存到 %s\n", *out)
+	return nil
+}
+
+// runVerify 处理 verify 子命令：验证工作量证明证书
+func runVerify(args []string) error {
+	fs := flag.NewFlagSet("verify", flag.ExitOnError)
+	certPath := fs.String("cert", "cert.json", "Certificate file to verify")
+	fs.Parse(args)
+
+	data, err := os.ReadFile(*certPath)
+	if err != nil {
+		return fmt.Errorf("读取证书失败: %v", err)
+	}
+
+	var cert Certificate
+	if err := json.Unmarshal(data, &cert); err != nil {
+		return fmt.Errorf("解析证书失败: %v", err)
+	}
+	if err := VerifyCertificate(&cert); err != nil {
+		return err
+	}
+
+	fmt.Printf("证书有效：%s 于 %d 找到哈希 %s\n", cert.Nickname, cert.Timestamp, cert.Hash)
+	return nil
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"strings"
+	"testing"
+
+	"openspace/day01/signer"
+)
+
+// issueTestCertificate 用临时生成的密钥签发要求1个前导0的证书
+func issueTestCertificate(t *testing.T) *Certificate {
+	t.Helper()
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cert, err := IssueCertificate(signer.NewKeySigner(key), "alice", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return cert
+}
+
+func TestVerifyCertificate(t *testing.T) {
+	cert := issueTestCertificate(t)
+	if err := VerifyCertificate(cert); err != nil {
+		t.Fatalf("有效的证书验证失败: %v", err)
+	}
+}
+
+func TestIssueCertificateRejectsZeroCountOutOfRange(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, zeros := range []int{0, -1, 65} {
+		if cert, err := IssueCertificate(signer.NewKeySigner(key), "alice", zeros); err == nil {
+			t.Errorf("ZeroCount=%d 时签发了证书 %+v，应拒绝", zeros, cert)
+		}
+	}
+}
+
+func TestVerifyCertificateRejectsNonPositiveZeroCount(t *testing.T) {
+	// -1 对应-4比特，曾被视为满足要求；-3 对应-12比特，曾导致越界访问
+	issued := issueTestCertificate(t)
+	for _, zeros := range []int{0, -1, -2, -3, -1 << 40} {
+		cert := *issued
+		cert.ZeroCount = zeros
+
+		err := VerifyCertificate(&cert)
+		if err == nil || !strings.Contains(err.Error(), "前导0个数") {
+			t.Errorf("ZeroCount=%d 时返回 %v，应拒绝", zeros, err)
+		}
+	}
+}
@@ -0,0 +1,122 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"time"
+
+	"openspace/day01/pow"
+	"openspace/day01/signer"
+)
+
+// E2EStep 端到端流程中一个步骤的结果
+type E2EStep struct {
+	Name     string `json:"name"`
+	OK       bool   `json:"ok"`
+	Detail   string `json:"detail,omitempty"`
+	Duration string `json:"duration"`
+}
+
+// E2ESummary 端到端流程的汇总结果
+type E2ESummary struct {
+	Nickname  string    `json:"nickname"`
+	ZeroCount int       `json:"zero_count"`
+	Nonce     int64     `json:"nonce"`
+	Data      string    `json:"data,omitempty"`
+	Hash      string    `json:"hash,omitempty"`
+	Signature string    `json:"signature,omitempty"`
+	Steps     []E2EStep `json:"steps"`
+	Passed    bool      `json:"passed"`
+}
+
+// runE2E 处理 e2e 子命令：依次执行 生成/加载密钥、查找哈希、签名、验证，
+// 输出JSON格式的汇总，任一步骤失败时返回错误（进程以非0状态退出），可用于CI验收
+func runE2E(args []string) error {
+	fs := flag.NewFlagSet("e2e", flag.ExitOnError)
+	keyPath := fs.String("key", "", "PEM file with the RSA private key (a new key is generated if empty)")
+	keyBits := fs.Int("bits", 2048, "Size of the generated RSA key")
+	nickname := fs.String("nickname", "胡良", "Nickname to hash")
+	zeroCount := fs.Int("zeros", 4, "Number of leading zeros required")
+	fs.Parse(args)
+
+	summary := E2ESummary{Nickname: *nickname, ZeroCount: *zeroCount}
+	step := func(name string, fn func() (string, error)) bool {
+		start := time.Now()
+		detail, err := fn()
+		result := E2EStep{Name: name, OK: err == nil, Detail: detail, Duration: time.Since(start).String()}
+		if err != nil {
+			result.Detail = err.Error()
+		}
+		summary.Steps = append(summary.Steps, result)
+		return err == nil
+	}
+
+	var keySigner *signer.KeySigner
+	var signature []byte
+	ok := step("key", func() (string, error) {
+		if *keyPath != "" {
+			s, err := signer.NewFileSigner(*keyPath)
+			if err != nil {
+				return "", err
+			}
+			keySigner = s
+			return "loaded " + *keyPath, nil
+		}
+		key, err := rsa.GenerateKey(rand.Reader, *keyBits)
+		if err != nil {
+			return "", fmt.Errorf("生成密钥对失败: %v", err)
+		}
+		keySigner = signer.NewKeySigner(key)
+		return fmt.Sprintf("generated %d-bit RSA key", *keyBits), nil
+	}) && step("search", func() (string, error) {
+		if *zeroCount < 1 || *zeroCount > 64 {
+			return "", fmt.Errorf("无效的前导0个数: %d", *zeroCount)
+		}
+		summary.Data, summary.Nonce, summary.Hash = FindValidHash(*nickname, *zeroCount, SearchOptions{})
+		return fmt.Sprintf("nonce %d", summary.Nonce), nil
+	}) && step("check-hash", func() (string, error) {
+		hash := sha256.Sum256([]byte(summary.Data))
+		if hex.EncodeToString(hash[:]) != summary.Hash {
+			return "", fmt.Errorf("哈希值与输入数据不匹配")
+		}
+		if !pow.HasLeadingZeroBits(hash[:], pow.ZerosToBits(*zeroCount)) {
+			return "", fmt.Errorf("哈希值不满足%d个前导0的要求", *zeroCount)
+		}
+		return "", nil
+	}) && step("sign", func() (string, error) {
+		var err error
+		signature, err = SignMessage(keySigner, summary.Data)
+		if err != nil {
+			return "", err
+		}
+		summary.Signature = hex.EncodeToString(signature)
+		return "", nil
+	}) && step("verify", func() (string, error) {
+		publicKey, ok := keySigner.Public().(*rsa.PublicKey)
+		if !ok {
+			return "", fmt.Errorf("公钥不是RSA公钥")
+		}
+		if err := VerifySignature(publicKey, summary.Data, signature); err != nil {
+			return "", fmt.Errorf("签名验证失败: %v", err)
+		}
+		return "", nil
+	})
+	summary.Passed = ok
+
+	out, err := json.MarshalIndent(summary, "", "  ")
+	if err != nil {
+		return fmt.Errorf("编码结果失败: %v", err)
+	}
+	fmt.Println(string(out))
+
+	if !ok {
+		failed := summary.Steps[len(summary.Steps)-1]
+		return fmt.Errorf("步骤 %s 失败: %s", failed.Name, failed.Detail)
+	}
+	return nil
+}
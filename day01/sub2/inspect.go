@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bufio"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/pem"
+	"fmt"
+	"os"
+	"strings"
+)
+
+// KeyInfo PEM密钥文件的基本信息
+type KeyInfo struct {
+	Type        string // 密钥类型：RSA / EC / Ed25519
+	Size        string // RSA为位数，EC为曲线名称
+	Private     bool   // 是否为私钥
+	Fingerprint string // 公钥（PKIX DER）的SHA-256指纹
+}
+
+// InspectPEM 解析PEM数据并返回密钥信息
+// 对于加密的私钥，通过passphrase回调获取口令
+func InspectPEM(data []byte, passphrase func() ([]byte, error)) (*KeyInfo, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, fmt.Errorf("未找到PEM数据块")
+	}
+
+	der := block.Bytes
+	// 传统的加密PEM格式（Proc-Type: 4,ENCRYPTED），需要口令解密
+	if x509.IsEncryptedPEMBlock(block) {
+		pass, err := passphrase()
+		if err != nil {
+			return nil, fmt.Errorf("读取口令失败: %v", err)
+		}
+		if der, err = x509.DecryptPEMBlock(block, pass); err != nil {
+			return nil, fmt.Errorf("解密私钥失败: %v", err)
+		}
+	}
+
+	var key interface{}
+	var private bool
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(der)
+		private = true
+	case "EC PRIVATE KEY":
+		key, err = x509.ParseECPrivateKey(der)
+		private = true
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(der)
+		private = true
+	case "ENCRYPTED PRIVATE KEY":
+		return nil, fmt.Errorf("暂不支持PKCS#8加密私钥，请先用openssl解密")
+	case "RSA PUBLIC KEY":
+		key, err = x509.ParsePKCS1PublicKey(der)
+	case "PUBLIC KEY":
+		key, err = x509.ParsePKIXPublicKey(der)
+	default:
+		return nil, fmt.Errorf("不支持的PEM类型: %s", block.Type)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("解析%s失败: %v", block.Type, err)
+	}
+
+	// 私钥统一转换为对应的公钥再计算指纹
+	if signer, ok := key.(crypto.Signer); ok {
+		key = signer.Public()
+	}
+
+	info := &KeyInfo{Private: private}
+	switch pub := key.(type) {
+	case *rsa.PublicKey:
+		info.Type = "RSA"
+		info.Size = fmt.Sprintf("%d bits", pub.N.BitLen())
+	case *ecdsa.PublicKey:
+		info.Type = "EC"
+		info.Size = pub.Curve.Params().Name
+	case ed25519.PublicKey:
+		info.Type = "Ed25519"
+		info.Size = "256 bits"
+	default:
+		return nil, fmt.Errorf("不支持的密钥类型: %T", key)
+	}
+
+	pkix, err := x509.MarshalPKIXPublicKey(key)
+	if err != nil {
+		return nil, fmt.Errorf("编码公钥失败: %v", err)
+	}
+	info.Fingerprint = fingerprint(pkix)
+	return info, nil
+}
+
+// fingerprint 计算DER数据的SHA-256指纹，以冒号分隔的十六进制表示
+func fingerprint(der []byte) string {
+	sum := sha256.Sum256(der)
+	parts := make([]string, len(sum))
+	for i, b := range sum {
+		parts[i] = hex.EncodeToString([]byte{b})
+	}
+	return strings.Join(parts, ":")
+}
+
+// promptPassphrase 从标准输入读取私钥口令
+func promptPassphrase() ([]byte, error) {
+	fmt.Fprint(os.Stderr, "请输入私钥口令: ")
+	line, err := bufio.NewReader(os.Stdin).ReadString('\n')
+	if err != nil && line == "" {
+		return nil, err
+	}
+	return []byte(strings.TrimRight(line, "\r\n")), nil
+}
+
+// runInspect 处理 inspect 子命令：打印PEM密钥文件的详细信息
+func runInspect(args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("用法: inspect <pem文件>")
+	}
+
+	data, err := os.ReadFile(args[0])
+	if err != nil {
+		return fmt.Errorf("读取文件失败: %v", err)
+	}
+
+	info, err := InspectPEM(data, promptPassphrase)
+	if err != nil {
+		return err
+	}
+
+	kind := "公钥"
+	if info.Private {
+		kind = "私钥"
+	}
+	fmt.Printf("类型: %s %s\n", info.Type, kind)
+	fmt.Printf("长度/曲线: %s\n", info.Size)
+	fmt.Printf("SHA256指纹: %s\n", info.Fingerprint)
+	return nil
+}
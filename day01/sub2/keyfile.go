@@ -0,0 +1,146 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+)
+
+// KeyFileConfig 密钥对写入文件时使用的路径与权限
+// 零值字段使用DefaultKeyFileConfig中的默认值
+type KeyFileConfig struct {
+	PrivatePath string      // 私钥文件路径
+	PublicPath  string      // 公钥文件路径
+	PrivateMode os.FileMode // 私钥文件权限
+	PublicMode  os.FileMode // 公钥文件权限
+	DirMode     os.FileMode // 创建上级目录时使用的权限
+}
+
+// DefaultKeyFileConfig 返回默认配置：当前目录下的 private_key.pem（0600）与 public_key.pem（0644）
+func DefaultKeyFileConfig() KeyFileConfig {
+	return KeyFileConfig{
+		PrivatePath: "private_key.pem",
+		PublicPath:  "public_key.pem",
+		PrivateMode: 0600,
+		PublicMode:  0644,
+		DirMode:     0700,
+	}
+}
+
+// withDefaults 用默认值补全未设置的字段
+func (c KeyFileConfig) withDefaults() KeyFileConfig {
+	def := DefaultKeyFileConfig()
+	if c.PrivatePath == "" {
+		c.PrivatePath = def.PrivatePath
+	}
+	if c.PublicPath == "" {
+		c.PublicPath = def.PublicPath
+	}
+	if c.PrivateMode == 0 {
+		c.PrivateMode = def.PrivateMode
+	}
+	if c.PublicMode == 0 {
+		c.PublicMode = def.PublicMode
+	}
+	if c.DirMode == 0 {
+		c.DirMode = def.DirMode
+	}
+	return c
+}
+
+// Save 将PEM编码的密钥对写入配置的路径，必要时创建上级目录
+// 已存在的文件被替换为按配置权限新建的文件
+func (c KeyFileConfig) Save(publicKeyPEM, privateKeyPEM []byte) error {
+	c = c.withDefaults()
+	if err := writeKeyFile(c.PrivatePath, privateKeyPEM, c.PrivateMode, c.DirMode); err != nil {
+		return fmt.Errorf("保存私钥到文件失败: %v", err)
+	}
+	if err := writeKeyFile(c.PublicPath, publicKeyPEM, c.PublicMode, c.DirMode); err != nil {
+		return fmt.Errorf("保存公钥到文件失败: %v", err)
+	}
+	return nil
+}
+
+// writeKeyFile 创建上级目录并以指定权限写入文件
+// 先以该权限独占创建临时文件（O_EXCL，不会跟随已有的文件或符号链接），写完后再重命名为目标文件：
+// 私钥从不以更宽的权限出现在磁盘上，写入中途失败也不会留下不完整的密钥文件
+func writeKeyFile(path string, data []byte, mode, dirMode os.FileMode) error {
+	if dir := filepath.Dir(path); dir != "." {
+		if err := os.MkdirAll(dir, dirMode); err != nil {
+			return fmt.Errorf("创建目录 %s 失败: %v", dir, err)
+		}
+	}
+
+	tmp := path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_EXCL, mode)
+	if err != nil {
+		return err
+	}
+	if err := writeAndClose(f, data, mode); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, path); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	return nil
+}
+
+// writeAndClose 修正文件权限（umask可能去掉了部分权限位）后写入内容、同步到磁盘并关闭文件
+func writeAndClose(f *os.File, data []byte, mode os.FileMode) error {
+	defer f.Close()
+
+	if err := f.Chmod(mode); err != nil {
+		return err
+	}
+	if _, err := f.Write(data); err != nil {
+		return err
+	}
+	if err := f.Sync(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// parseFileMode 解析八进制的文件权限，如 "0600"
+func parseFileMode(s string) (os.FileMode, error) {
+	mode, err := strconv.ParseUint(s, 8, 32)
+	if err != nil || mode > 0777 {
+		return 0, fmt.Errorf("无效的文件权限 %q", s)
+	}
+	return os.FileMode(mode), nil
+}
+
+// runKeygen 处理 keygen 子命令：生成RSA密钥对并按指定路径与权限保存
+func runKeygen(args []string) error {
+	def := DefaultKeyFileConfig()
+	fs := flag.NewFlagSet("keygen", flag.ExitOnError)
+	bits := fs.Int("bits", 2048, "RSA key size in bits")
+	privatePath := fs.String("private", def.PrivatePath, "Output path for the private key")
+	publicPath := fs.String("public", def.PublicPath, "Output path for the public key")
+	privateMode := fs.String("private-mode", "0600", "File mode (octal) for the private key")
+	publicMode := fs.String("public-mode", "0644", "File mode (octal) for the public key")
+	dirMode := fs.String("dir-mode", "0700", "File mode (octal) for created parent directories")
+	fs.Parse(args)
+
+	cfg := KeyFileConfig{PrivatePath: *privatePath, PublicPath: *publicPath}
+	var err error
+	if cfg.PrivateMode, err = parseFileMode(*privateMode); err != nil {
+		return err
+	}
+	if cfg.PublicMode, err = parseFileMode(*publicMode); err != nil {
+		return err
+	}
+	if cfg.DirMode, err = parseFileMode(*dirMode); err != nil {
+		return err
+	}
+
+	if _, _, err := GenerateRSAKeyPair(*bits, &cfg); err != nil {
+		return err
+	}
+	fmt.Printf("私钥已保存到 %s，公钥已保存到 %s\n", cfg.PrivatePath, cfg.PublicPath)
+	return nil
+}
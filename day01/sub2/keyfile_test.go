@@ -0,0 +1,51 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteKeyFileSetsModeAndReplaces(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "keys", "private_key.pem")
+	if err := os.MkdirAll(filepath.Dir(path), 0700); err != nil {
+		t.Fatal(err)
+	}
+	// 已存在的文件权限更宽，写入后应被替换为按配置权限新建的文件
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeKeyFile(path, []byte("new"), 0600, 0700); err != nil {
+		t.Fatal(err)
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Fatalf("文件权限为 %o，应为 0600", info.Mode().Perm())
+	}
+	if data, _ := os.ReadFile(path); string(data) != "new" {
+		t.Fatalf("文件内容为 %q，应为 new", data)
+	}
+	if _, err := os.Stat(path + ".tmp"); !os.IsNotExist(err) {
+		t.Fatal("写入后不应留下临时文件")
+	}
+}
+
+func TestWriteKeyFileRefusesExistingTempFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "private_key.pem")
+	// 临时文件路径上预先放置的符号链接不应被跟随
+	target := filepath.Join(t.TempDir(), "target")
+	if err := os.Symlink(target, path+".tmp"); err != nil {
+		t.Skip(err)
+	}
+
+	if err := writeKeyFile(path, []byte("secret"), 0600, 0700); err == nil {
+		t.Fatal("临时文件已存在时应返回错误")
+	}
+	if _, err := os.Stat(target); !os.IsNotExist(err) {
+		t.Fatal("不应通过符号链接写入密钥")
+	}
+}
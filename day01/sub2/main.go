@@ -8,17 +8,20 @@ import (
 	"crypto/x509"
 	"encoding/hex"
 	"encoding/pem"
+	"flag"
 	"fmt"
 	"os"
-	"strings"
-	"time"
+	"strconv"
+
+	"openspace/day01/pow"
+	"openspace/day01/signer"
 )
 
 // GenerateRSAKeyPair 生成指定长度的RSA密钥对
 // bits: 密钥长度，建议2048或4096位
-// saveToFile: 是否保存到文件
+// files: 保存到文件时使用的路径与权限，为nil时不保存
 // 返回: 公钥PEM, 私钥PEM, 错误
-func GenerateRSAKeyPair(bits int, saveToFile bool) ([]byte, []byte, error) {
+func GenerateRSAKeyPair(bits int, files *KeyFileConfig) ([]byte, []byte, error) {
 	// 生成RSA密钥对
 	privateKey, err := rsa.GenerateKey(rand.Reader, bits)
 	if err != nil {
@@ -48,33 +51,19 @@ func GenerateRSAKeyPair(bits int, saveToFile bool) ([]byte, []byte, error) {
 	)
 
 	// 如果需要保存到文件
-	if saveToFile {
-		err = os.WriteFile("private_key.pem", privateKeyPEM, 0600)
-		if err != nil {
-			return nil, nil, fmt.Errorf("保存私钥到文件失败: %v", err)
-		}
-
-		err = os.WriteFile("public_key.pem", publicKeyPEM, 0644)
-		if err != nil {
-			return nil, nil, fmt.Errorf("保存公钥到文件失败: %v", err)
+	if files != nil {
+		if err := files.Save(publicKeyPEM, privateKeyPEM); err != nil {
+			return nil, nil, err
 		}
 	}
 
 	return publicKeyPEM, privateKeyPEM, nil
 }
 
-// SignMessage 使用私钥对消息进行签名
-func SignMessage(privateKey *rsa.PrivateKey, message string) ([]byte, error) {
-	hasher := sha256.New()
-	hasher.Write([]byte(message))
-	hash := hasher.Sum(nil)
-
-	signature, err := rsa.SignPKCS1v15(rand.Reader, privateKey, crypto.SHA256, hash)
-	if err != nil {
-		return nil, fmt.Errorf("签名失败: %v", err)
-	}
-
-	return signature, nil
+// SignMessage 使用签名器对消息进行签名
+// 签名器可以是本地私钥（signer.NewKeySigner、signer.NewFileSigner），也可以是HSM/KMS等外部实现
+func SignMessage(s signer.Signer, message string) ([]byte, error) {
+	return signer.SignMessage(s, []byte(message))
 }
 
 // VerifySignature 使用公钥验证签名
@@ -86,41 +75,127 @@ func VerifySignature(publicKey *rsa.PublicKey, message string, signature []byte)
 	return rsa.VerifyPKCS1v15(publicKey, crypto.SHA256, hash, signature)
 }
 
-// FindValidHash 查找符合条件的哈希值
-func FindValidHash(nickname string, zeroCount int) (string, int64, string) {
-	targetPrefix := strings.Repeat("0", zeroCount)
-	iteration := 0
-	var timestamp int64
-	var data string
-	var hashStr string
-
-	for {
-		// 获取当前时间戳（纳秒级）
-		timestamp = time.Now().UnixNano()
-		data = fmt.Sprintf("%s%d", nickname, timestamp)
-
-		// 计算SHA-256哈希
-		hash := sha256.Sum256([]byte(data))
-		hashStr = hex.EncodeToString(hash[:])
-
-		// 检查是否满足条件
-		if len(hashStr) >= zeroCount && hashStr[:zeroCount] == targetPrefix {
-			return data, timestamp, hashStr
+// VerifyAny 依次使用多个候选公钥验证签名，返回第一个匹配的公钥下标
+// 用于密钥轮换期间：签名可能来自旧密钥，也可能来自新密钥
+func VerifyAny(message string, signature []byte, keys []*rsa.PublicKey) (int, error) {
+	for i, key := range keys {
+		if key != nil && VerifySignature(key, message, signature) == nil {
+			return i, nil
 		}
+	}
+	return -1, fmt.Errorf("签名与%d个候选公钥均不匹配", len(keys))
+}
 
-		iteration++
+// SearchOptions 控制哈希搜索的起始nonce、步长与进度输出
+// 在多台机器（或多个进程）上分布式搜索时，第i台机器使用 Start=i、Stride=N，
+// 分别尝试 i, i+N, i+2N, ...，各机器的搜索空间互不重叠。
+// 任意一台机器先找到结果即可停止其余机器；结果中的nonce可由任何人用
+// sha256(昵称+nonce) 独立复现和验证，因此无需合并各机器的中间状态。
+// 每台机器内部再由 Workers 个goroutine并行搜索，未指定时由 pow.AutoTuneWorkers 选择。
+type SearchOptions = pow.Options
 
-		// 每1000次输出一次进度
-		if iteration%1000 == 0 {
-			fmt.Printf("\r已尝试 %d 次...", iteration)
-		}
+// printProgress 在同一行打印已尝试次数，供命令行使用
+func printProgress(attempts int) {
+	fmt.Printf("\r已尝试 %d 次...", attempts)
+}
+
+// FindValidHash 查找使 sha256(昵称+nonce) 以zeroCount个0开头的nonce
+// 返回输入数据、命中的nonce与哈希值；除非提供了Progress回调，否则不产生任何输出
+func FindValidHash(nickname string, zeroCount int, opts SearchOptions) (string, int64, string) {
+	return FindValidHashBits(nickname, pow.ZerosToBits(zeroCount), opts)
+}
+
+// FindValidHashBits 与FindValidHash相同，但难度与区块链一样以前导0比特数表示
+// 由opts.Workers个goroutine并行搜索（未指定时自动选择），每个goroutine使用自己的 nicknameHash
+func FindValidHashBits(nickname string, bits int, opts SearchOptions) (string, int64, string) {
+	nonce, hash, _ := pow.SolveParallel(func() pow.HashFunc {
+		return nicknameHash(nickname)
+	}, bits, opts)
+
+	data := nickname + strconv.FormatInt(nonce, 10)
+	return data, nonce, hex.EncodeToString(hash)
+}
+
+// nicknameHash 返回计算 sha256(昵称+nonce的十进制表示) 的函数
+// 复用同一个哈希器与输入缓冲区，避免每次迭代的内存分配；返回的函数不能并发调用
+func nicknameHash(nickname string) pow.HashFunc {
+	hasher := sha256.New()
+	input := []byte(nickname)
+	prefixLen := len(input)
+	var sum [sha256.Size]byte
+
+	return func(nonce int64) []byte {
+		// 在昵称之后直接追加nonce的十进制表示
+		input = strconv.AppendInt(input[:prefixLen], nonce, 10)
+		hasher.Reset()
+		hasher.Write(input)
+		return hasher.Sum(sum[:0])
 	}
 }
 
 func main() {
+	// 子命令：cert 生成工作量证明证书，verify 验证证书，search 分布式搜索哈希，
+	// inspect 查看PEM密钥文件信息，e2e 端到端运行并验证整个流程，keygen 生成密钥对
+	if len(os.Args) > 1 {
+		var err error
+		switch os.Args[1] {
+		case "cert":
+			err = runCert(os.Args[2:])
+		case "verify":
+			err = runVerify(os.Args[2:])
+		case "search":
+			err = runSearch(os.Args[2:])
+		case "inspect":
+			err = runInspect(os.Args[2:])
+		case "e2e":
+			err = runE2E(os.Args[2:])
+		case "keygen":
+			err = runKeygen(os.Args[2:])
+		default:
+			fmt.Printf("未知的子命令: %s\n", os.Args[1])
+			os.Exit(2)
+		}
+		if err != nil {
+			fmt.Printf("%v\n", err)
+			os.Exit(1)
+		}
+		return
+	}
+
+	runDemo()
+}
+
+// runSearch 处理 search 子命令：按起始nonce与步长搜索哈希，用于多进程分布式搜索
+func runSearch(args []string) error {
+	fs := flag.NewFlagSet("search", flag.ExitOnError)
+	nickname := fs.String("nickname", "胡良", "Nickname to hash")
+	zeroCount := fs.Int("zeros", 4, "Number of leading zeros required")
+	bits := fs.Int("bits", 0, "Difficulty in leading zero bits, as used by the blockchain (overrides -zeros)")
+	start := fs.Int64("start", 0, "First nonce to try (this worker's index)")
+	stride := fs.Int64("stride", 1, "Nonce step (total number of workers)")
+	quiet := fs.Bool("quiet", false, "Suppress progress output")
+	workers := fs.Int("workers", 0, "Number of goroutines searching in parallel (0 picks the fastest by a short benchmark)")
+	fs.Parse(args)
+
+	opts := SearchOptions{Start: *start, Stride: *stride, Workers: *workers}
+	if !*quiet {
+		opts.Progress = printProgress
+	}
+	difficulty := pow.ZerosToBits(*zeroCount)
+	if *bits > 0 {
+		difficulty = *bits
+	}
+	data, nonce, hashStr := FindValidHashBits(*nickname, difficulty, opts)
+	fmt.Printf("\n输入数据: %s\nNonce: %d\n哈希值: %s\n", data, nonce, hashStr)
+	return nil
+}
+
+// runDemo 生成密钥对、查找哈希并签名验证的完整演示
+func runDemo() {
 	// 生成2048位的RSA密钥对
 	fmt.Println("正在生成RSA 2048位密钥对...")
-	publicKeyPEM, privateKeyPEM, err := GenerateRSAKeyPair(2048, true)
+	files := DefaultKeyFileConfig()
+	publicKeyPEM, privateKeyPEM, err := GenerateRSAKeyPair(2048, &files)
 	if err != nil {
 		fmt.Printf("生成密钥对失败: %v\n", err)
 		return
@@ -159,10 +234,10 @@ func main() {
 	zeroCount := 4
 	fmt.Printf("\n正在查找以%d个0开头的哈希值...\n", zeroCount)
 
-	data, timestamp, hashStr := FindValidHash(nickname, zeroCount)
+	data, nonce, hashStr := FindValidHash(nickname, zeroCount, SearchOptions{Progress: printProgress})
 
 	// 使用私钥签名
-	signature, err := SignMessage(privateKey, data)
+	signature, err := SignMessage(signer.NewKeySigner(privateKey), data)
 	if err != nil {
 		fmt.Printf("签名失败: %v\n", err)
 		return
@@ -178,7 +253,7 @@ func main() {
 	// 输出结果
 	fmt.Println("\n\n===== 结果 =====")
 	fmt.Printf("昵称: %s\n", nickname)
-	fmt.Printf("时间戳: %d\n", timestamp)
+	fmt.Printf("Nonce: %d\n", nonce)
 	fmt.Printf("输入数据: %s\n", data)
 	fmt.Printf("哈希值: %s\n", hashStr)
 	fmt.Printf("签名: %x\n", signature)
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"bytes"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"fmt"
+	"strings"
+	"testing"
+
+	"openspace/day01/pow"
+	"openspace/day01/signer"
+)
+
+func TestNicknameHash(t *testing.T) {
+	hash := nicknameHash("alice")
+	for _, nonce := range []int64{0, 9, 10, 123456789} {
+		want := sha256.Sum256([]byte(fmt.Sprintf("alice%d", nonce)))
+		if got := hash(nonce); !bytes.Equal(got, want[:]) {
+			t.Fatalf("nonce %d 的哈希为 %x，应为 %x", nonce, got, want)
+		}
+	}
+}
+
+func TestFindValidHash(t *testing.T) {
+	data, nonce, hash := FindValidHash("alice", 2, SearchOptions{Workers: 2})
+	if data != fmt.Sprintf("alice%d", nonce) {
+		t.Fatalf("数据为 %s，应为昵称加nonce %d", data, nonce)
+	}
+	if sum := sha256.Sum256([]byte(data)); fmt.Sprintf("%x", sum) != hash {
+		t.Fatalf("哈希 %s 与数据 %s 不符", hash, data)
+	}
+	if !strings.HasPrefix(hash, "00") {
+		t.Fatalf("哈希 %s 应以2个0开头", hash)
+	}
+}
+
+func TestVerifyAny(t *testing.T) {
+	keys := make([]*rsa.PrivateKey, 3)
+	candidates := make([]*rsa.PublicKey, len(keys))
+	for i := range keys {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			t.Fatal(err)
+		}
+		keys[i], candidates[i] = key, &key.PublicKey
+	}
+	signature, err := SignMessage(signer.NewKeySigner(keys[1]), "rotate")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if index, err := VerifyAny("rotate", signature, candidates); err != nil || index != 1 {
+		t.Fatalf("VerifyAny = %d, %v，应只匹配第 1 个公钥", index, err)
+	}
+	// 候选列表中的nil会被跳过
+	if index, err := VerifyAny("rotate", signature, []*rsa.PublicKey{nil, candidates[1]}); err != nil || index != 1 {
+		t.Fatalf("跳过nil后 VerifyAny = %d, %v，应为 1", index, err)
+	}
+	if index, err := VerifyAny("rotate", signature, []*rsa.PublicKey{candidates[0], candidates[2]}); err == nil || index != -1 {
+		t.Fatalf("没有匹配的公钥时 VerifyAny = %d, %v，应返回 -1 与错误", index, err)
+	}
+	if _, err := VerifyAny("tampered", signature, candidates); err == nil {
+		t.Fatal("消息被修改后不应匹配任何公钥")
+	}
+	if _, err := VerifyAny("rotate", signature, nil); err == nil {
+		t.Fatal("没有候选公钥时应返回错误")
+	}
+}
+
+// BenchmarkNicknameHash 对比复用哈希器与缓冲区前后单次尝试的耗时与分配
+func BenchmarkNicknameHash(b *testing.B) {
+	b.Run("sprintf", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			sum := sha256.Sum256([]byte(fmt.Sprintf("alice%d", i)))
+			_ = pow.HasLeadingZeroBits(sum[:], 20)
+		}
+	})
+	b.Run("reused", func(b *testing.B) {
+		b.ReportAllocs()
+		hash := nicknameHash("alice")
+		for i := 0; i < b.N; i++ {
+			_ = pow.HasLeadingZeroBits(hash(int64(i)), 20)
+		}
+	})
+}
+
+// BenchmarkFindValidHash 单个worker搜索3个前导0的完整耗时
+func BenchmarkFindValidHash(b *testing.B) {
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		FindValidHash(fmt.Sprintf("alice%d", i), 3, SearchOptions{Workers: 1})
+	}
+}
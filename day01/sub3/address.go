@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// AddressLength 地址负载（公钥哈希）的字节数，与 DeriveAddress 一致
+const AddressLength = 20
+
+var (
+	// ErrInvalidAddress 地址不符合节点配置的地址格式
+	ErrInvalidAddress = errors.New("无效的地址")
+	// ErrInvalidRecipient 严格模式下接收方既未在链上出现过，也不是有效的派生地址
+	ErrInvalidRecipient = errors.New("无效的接收方")
+)
+
+// AddressFormat 地址的编码格式，用于模仿不同区块链的地址约定
+// Blockchain.AddressFormat 为nil时不校验地址格式，任何非空字符串（如演示中的 Alice）都是有效地址
+type AddressFormat interface {
+	// Encode 将AddressLength字节的负载编码为地址
+	Encode(payload []byte) string
+	// Decode 解析地址并返回负载，格式或校验和不正确时返回错误
+	Decode(address string) ([]byte, error)
+}
+
+// ParseAddressFormat 按名称创建地址格式：hex 或 bech32，空名称表示不校验
+// prefix 为十六进制地址的前缀或bech32地址的人类可读部分，为空时使用默认值
+func ParseAddressFormat(name, prefix string) (AddressFormat, error) {
+	switch name {
+	case "":
+		return nil, nil
+	case "hex":
+		if prefix == "" {
+			prefix = "0x"
+		}
+		return HexAddressFormat{Prefix: prefix}, nil
+	case "bech32":
+		if prefix == "" {
+			prefix = "os"
+		}
+		if err := validBech32HRP(prefix); err != nil {
+			return nil, err
+		}
+		return Bech32AddressFormat{HRP: prefix}, nil
+	default:
+		return nil, fmt.Errorf("未知的地址格式 %q（可选 hex、bech32）", name)
+	}
+}
+
+// EncodeAddress 按区块链配置的地址格式编码地址，未配置时使用不带前缀的十六进制
+func (bc *Blockchain) EncodeAddress(payload []byte) string {
+	if bc.AddressFormat == nil {
+		return HexAddressFormat{}.Encode(payload)
+	}
+	return bc.AddressFormat.Encode(payload)
+}
+
+// DecodeAddress 按区块链配置的地址格式解析地址，未配置时按不带前缀的十六进制解析
+func (bc *Blockchain) DecodeAddress(address string) ([]byte, error) {
+	if bc.AddressFormat == nil {
+		return HexAddressFormat{}.Decode(address)
+	}
+	return bc.AddressFormat.Decode(address)
+}
+
+// validateAddresses 校验交易双方的地址格式，未配置地址格式时不校验
+func (bc *Blockchain) validateAddresses(tx Transaction) error {
+	if bc.AddressFormat == nil {
+		return nil
+	}
+	for _, address := range []string{tx.Sender, tx.Recipient} {
+		if _, err := bc.AddressFormat.Decode(address); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// validateRecipient 严格模式下校验接收方：须为链上出现过的地址，或能按地址格式解析的派生地址
+// 地址是自由字符串，拼写错误的接收方会使资金永远无法花费，因此在提交时拒绝明显错误的地址
+func (bc *Blockchain) validateRecipient(tx Transaction) error {
+	if !bc.StrictRecipients {
+		return nil
+	}
+	if _, seen := bc.state.balances[tx.Recipient]; seen {
+		return nil
+	}
+	if _, err := bc.DecodeAddress(tx.Recipient); err != nil {
+		example := bc.EncodeAddress(make([]byte, AddressLength))
+		return fmt.Errorf("%w %q: 地址从未在链上出现过，也不是有效的派生地址（形如 %s），请检查是否拼写错误",
+			ErrInvalidRecipient, tx.Recipient, example)
+	}
+	return nil
+}
+
+// HexAddressFormat 带固定前缀的十六进制地址，如 0x 加40个十六进制字符
+type HexAddressFormat struct {
+	Prefix string
+}
+
+// Encode 将负载编码为带前缀的小写十六进制
+func (f HexAddressFormat) Encode(payload []byte) string {
+	return f.Prefix + hex.EncodeToString(payload)
+}
+
+// Decode 解析带前缀的十六进制地址
+func (f HexAddressFormat) Decode(address string) ([]byte, error) {
+	if !strings.HasPrefix(address, f.Prefix) {
+		return nil, fmt.Errorf("%w %q: 缺少前缀 %q", ErrInvalidAddress, address, f.Prefix)
+	}
+	payload, err := hex.DecodeString(address[len(f.Prefix):])
+	if err != nil || len(payload) != AddressLength {
+		return nil, fmt.Errorf("%w %q: 应为 %d 字节的十六进制", ErrInvalidAddress, address, AddressLength)
+	}
+	return payload, nil
+}
+
+// bech32Charset bech32的字符表
+const bech32Charset = "qpzry9x8gf2tvdw0s3jn54khce6mua7l"
+
+// Bech32AddressFormat bech32风格的地址（BIP-173）：人类可读部分 + "1" + 带6字符校验和的数据部分
+// 校验和可以发现任意不超过4个字符的错误，适合人工抄写
+type Bech32AddressFormat struct {
+	HRP string // 人类可读部分，如 os
+}
+
+// Encode 将负载编码为bech32地址
+func (f Bech32AddressFormat) Encode(payload []byte) string {
+	data := convertBits(payload, 8, 5, true)
+	checksum := bech32Checksum(f.HRP, data)
+
+	var sb strings.Builder
+	sb.WriteString(f.HRP)
+	sb.WriteByte('1')
+	for _, v := range append(data, checksum...) {
+		sb.WriteByte(bech32Charset[v])
+	}
+	return sb.String()
+}
+
+// Decode 解析bech32地址并校验人类可读部分与校验和
+func (f Bech32AddressFormat) Decode(address string) ([]byte, error) {
+	// bech32不允许大小写混用，全大写的地址等价于小写
+	lower := strings.ToLower(address)
+	if lower != address && strings.ToUpper(address) != address {
+		return nil, fmt.Errorf("%w %q: 大小写混用", ErrInvalidAddress, address)
+	}
+
+	sep := strings.LastIndexByte(lower, '1')
+	if sep < 1 || len(lower)-sep-1 < 6 {
+		return nil, fmt.Errorf("%w %q: 格式不正确", ErrInvalidAddress, address)
+	}
+	if hrp := lower[:sep]; hrp != f.HRP {
+		return nil, fmt.Errorf("%w %q: 前缀应为 %q", ErrInvalidAddress, address, f.HRP)
+	}
+
+	data := make([]byte, 0, len(lower)-sep-1)
+	for _, c := range lower[sep+1:] {
+		v := strings.IndexRune(bech32Charset, c)
+		if v < 0 {
+			return nil, fmt.Errorf("%w %q: 包含非法字符 %q", ErrInvalidAddress, address, c)
+		}
+		data = append(data, byte(v))
+	}
+	if bech32Polymod(append(bech32HRPExpand(f.HRP), data...)) != 1 {
+		return nil, fmt.Errorf("%w %q: 校验和不正确", ErrInvalidAddress, address)
+	}
+
+	payload := convertBits(data[:len(data)-6], 5, 8, false)
+	if payload == nil || len(payload) != AddressLength {
+		return nil, fmt.Errorf("%w %q: 应为 %d 字节", ErrInvalidAddress, address, AddressLength)
+	}
+	return payload, nil
+}
+
+// validBech32HRP 校验人类可读部分：1-83个小写ASCII字符（33-126）
+func validBech32HRP(hrp string) error {
+	if len(hrp) == 0 || len(hrp) > 83 || strings.ToLower(hrp) != hrp {
+		return fmt.Errorf("无效的bech32前缀 %q", hrp)
+	}
+	for _, c := range hrp {
+		if c < 33 || c > 126 {
+			return fmt.Errorf("无效的bech32前缀 %q", hrp)
+		}
+	}
+	return nil
+}
+
+// bech32Polymod 计算bech32校验和使用的BCH码
+func bech32Polymod(values []byte) uint32 {
+	generator := [5]uint32{0x3b6a57b2, 0x26508e6d, 0x1ea119fa, 0x3d4233dd, 0x2a1462b3}
+	chk := uint32(1)
+	for _, v := range values {
+		top := chk >> 25
+		chk = (chk&0x1ffffff)<<5 ^ uint32(v)
+		for i := 0; i < 5; i++ {
+			if (top>>i)&1 == 1 {
+				chk ^= generator[i]
+			}
+		}
+	}
+	return chk
+}
+
+// bech32HRPExpand 将人类可读部分展开为参与校验和计算的数值
+func bech32HRPExpand(hrp string) []byte {
+	expanded := make([]byte, 0, len(hrp)*2+1)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]>>5)
+	}
+	expanded = append(expanded, 0)
+	for i := 0; i < len(hrp); i++ {
+		expanded = append(expanded, hrp[i]&31)
+	}
+	return expanded
+}
+
+// bech32Checksum 计算6个5比特值的校验和
+func bech32Checksum(hrp string, data []byte) []byte {
+	values := append(bech32HRPExpand(hrp), data...)
+	polymod := bech32Polymod(append(values, 0, 0, 0, 0, 0, 0)) ^ 1
+	checksum := make([]byte, 6)
+	for i := range checksum {
+		checksum[i] = byte(polymod>>(5*(5-i))) & 31
+	}
+	return checksum
+}
+
+// convertBits 在不同位宽的分组之间转换（如8比特字节与5比特值）
+// pad为false时多余的比特必须为0且不足一组，否则返回nil
+func convertBits(data []byte, from, to uint, pad bool) []byte {
+	var acc uint32
+	var bits uint
+	maxValue := uint32(1)<<to - 1
+	out := make([]byte, 0, len(data)*int(from)/int(to)+1)
+	for _, v := range data {
+		acc = acc<<from | uint32(v)
+		bits += from
+		for bits >= to {
+			bits -= to
+			out = append(out, byte(acc>>bits&maxValue))
+		}
+	}
+	if pad {
+		if bits > 0 {
+			out = append(out, byte(acc<<(to-bits)&maxValue))
+		}
+	} else if bits >= from || acc<<(to-bits)&maxValue != 0 {
+		return nil
+	}
+	return out
+}
@@ -0,0 +1,55 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+)
+
+// newStrictChain 创建启用严格接收方策略的区块链，并为alice挖出一个区块的奖励
+func newStrictChain(t *testing.T) *Blockchain {
+	t.Helper()
+	bc := newTestChain(t)
+	bc.StrictRecipients = true
+	mustMine(t, bc, "alice")
+	return bc
+}
+
+func TestStrictRecipientsAcceptsDerivedAddress(t *testing.T) {
+	bc := newStrictChain(t)
+	derived := bc.EncodeAddress(make([]byte, AddressLength))
+	mustAddTransaction(t, bc, transfer(bc, "alice", derived, 1))
+}
+
+func TestStrictRecipientsAcceptsSeenAddress(t *testing.T) {
+	bc := newStrictChain(t)
+	// 矿工地址不是派生地址，但领取奖励后已在链上出现过
+	mustMine(t, bc, "bob")
+	mustAddTransaction(t, bc, transfer(bc, "alice", "bob", 1))
+}
+
+func TestStrictRecipientsRejectsGarbage(t *testing.T) {
+	bc := newStrictChain(t)
+	_, err := bc.AddTransaction(transfer(bc, "alice", "b0b-typo", 1))
+	if !errors.Is(err, ErrInvalidRecipient) {
+		t.Fatalf("无效的接收方返回 %v，应为 ErrInvalidRecipient", err)
+	}
+	if example := bc.EncodeAddress(make([]byte, AddressLength)); !strings.Contains(err.Error(), example) {
+		t.Fatalf("错误 %q 应提示有效地址的形式 %s", err, example)
+	}
+
+	n := newTestNetwork(t, bc)
+	var body struct {
+		Code string `json:"code"`
+	}
+	if status := postJSON(t, n, "/transactions/new", transfer(bc, "alice", "b0b-typo", 1), &body); status != http.StatusBadRequest || body.Code != "invalid_recipient" {
+		t.Fatalf("/transactions/new 返回 %d %s，应为 400 invalid_recipient", status, body.Code)
+	}
+}
+
+func TestStrictRecipientsOffByDefault(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	mustAddTransaction(t, bc, transfer(bc, "alice", "b0b-typo", 1))
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// Amount 表示以最小单位计的金额，避免浮点数无法精确表示余额的问题
+// 1 个币 = Coin 个最小单位
+type Amount int64
+
+const (
+	// CoinDecimals 最小单位相对于 1 个币的小数位数
+	CoinDecimals = 8
+	// Coin 1 个币对应的最小单位数量
+	Coin Amount = 100000000
+)
+
+// String 将金额格式化为十进制字符串，如 "1.5"
+func (a Amount) String() string {
+	sign := ""
+	u := uint64(a)
+	if a < 0 {
+		sign = "-"
+		u = uint64(-a)
+	}
+
+	whole := u / uint64(Coin)
+	frac := u % uint64(Coin)
+	if frac == 0 {
+		return sign + strconv.FormatUint(whole, 10)
+	}
+
+	fracStr := fmt.Sprintf("%0*d", CoinDecimals, frac)
+	return sign + strconv.FormatUint(whole, 10) + "." + strings.TrimRight(fracStr, "0")
+}
+
+// addCapped 返回非负金额a与b之和，超出 Amount 的上限时返回上限而不是溢出为负数
+func addCapped(a, b Amount) Amount {
+	if b > math.MaxInt64-a {
+		return math.MaxInt64
+	}
+	return a + b
+}
+
+// ParseAmount 将十进制字符串（可带指数，如 "1.5"、"2e-3"）精确解析为金额
+// 精度不能超过 CoinDecimals 位小数
+func ParseAmount(s string) (Amount, error) {
+	r, ok := new(big.Rat).SetString(strings.TrimSpace(s))
+	if !ok {
+		return 0, fmt.Errorf("无效的金额: %q", s)
+	}
+
+	r.Mul(r, new(big.Rat).SetInt64(int64(Coin)))
+	if !r.IsInt() {
+		return 0, fmt.Errorf("金额 %q 的精度超过 %d 位小数", s, CoinDecimals)
+	}
+	if !r.Num().IsInt64() {
+		return 0, fmt.Errorf("金额 %q 超出范围", s)
+	}
+	return Amount(r.Num().Int64()), nil
+}
+
+// MarshalJSON 以十进制数字的形式输出金额，如 1.5
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON 解析十进制数字或字符串形式的金额
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	amount, err := ParseAmount(strings.Trim(string(data), `"`))
+	if err != nil {
+		return err
+	}
+	*a = amount
+	return nil
+}
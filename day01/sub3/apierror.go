@@ -0,0 +1,87 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+)
+
+// 接口层的错误，消息直接返回给客户端
+var (
+	errMethodNotAllowed     = errors.New("Method not allowed")
+	errNotFound             = errors.New("Not found")
+	errUnknownChain         = errors.New("Unknown chain")
+	errMiningPaused         = errors.New("Mining is paused")
+	errTooSoonToMine        = errors.New("Too soon to mine the next block")
+	errInstantMineDisabled  = errors.New("Instant mining is disabled (start the node with -instant-mine)")
+	errObserverMode         = errors.New("Node is a read-only observer")
+	errInvalidRequestBody   = errors.New("Invalid request body")
+	errMissingQueryArgument = errors.New("Missing query parameter")
+)
+
+// errorCodes 错误到稳定错误码的映射，客户端应依据错误码而不是消息判断错误原因
+var errorCodes = []struct {
+	err  error
+	code string
+}{
+	{errMethodNotAllowed, "method_not_allowed"},
+	{errNotFound, "not_found"},
+	{errUnknownChain, "unknown_chain"},
+	{errMiningPaused, "mining_paused"},
+	{errTooSoonToMine, "too_soon_to_mine"},
+	{errInstantMineDisabled, "instant_mine_disabled"},
+	{errObserverMode, "observer_mode"},
+	{errInvalidRequestBody, "invalid_request_body"},
+	{errMissingQueryArgument, "missing_query_parameter"},
+
+	{ErrBlockNotFound, "block_not_found"},
+	{ErrTransactionNotFound, "transaction_not_found"},
+	{ErrInvalidChain, "invalid_chain"},
+	{ErrChainNotLonger, "chain_not_longer"},
+	{ErrProofNotFound, "proof_not_found"},
+	{ErrUnsupportedVersion, "unsupported_version"},
+	{ErrPathTooLong, "path_too_long"},
+	{ErrAddressNotFound, "address_not_found"},
+	{ErrInvalidPeerAddress, "invalid_peer_address"},
+	{ErrDuplicatePeer, "duplicate_peer"},
+	{ErrNodeNotFound, "node_not_found"},
+	{ErrInsufficientPeers, "insufficient_peers"},
+	{ErrEmptyAddress, "empty_address"},
+	{ErrInvalidAddress, "invalid_address"},
+	{ErrInvalidRecipient, "invalid_recipient"},
+	{ErrSelfTransfer, "self_transfer"},
+	{ErrInvalidAmount, "invalid_amount"},
+	{ErrInvalidFee, "invalid_fee"},
+	{ErrDustAmount, "dust_amount"},
+	{ErrMemoTooLong, "memo_too_long"},
+	{ErrDuplicateTransaction, "duplicate_transaction"},
+	{ErrTransactionExpired, "transaction_expired"},
+	{ErrMempoolFull, "mempool_full"},
+	{ErrInsufficientBalance, "insufficient_balance"},
+	{ErrInvalidSignature, "invalid_signature"},
+	{ErrInvalidCoinbase, "invalid_coinbase"},
+	{ErrUnexpectedCoinbase, "unexpected_coinbase"},
+}
+
+// errorCode 返回错误对应的错误码，未登记的错误按HTTP状态得出（如 bad_request）
+func errorCode(err error, status int) string {
+	for _, entry := range errorCodes {
+		if errors.Is(err, entry.err) {
+			return entry.code
+		}
+	}
+	return strings.ToLower(strings.ReplaceAll(http.StatusText(status), " ", "_"))
+}
+
+// writeError 以JSON返回错误：{"error": "...", "code": "..."}
+func writeError(w http.ResponseWriter, status int, err error) {
+	response := struct {
+		Error string `json:"error"`
+		Code  string `json:"code"`
+	}{
+		Error: err.Error(),
+		Code:  errorCode(err, status),
+	}
+
+	sendJSON(w, status, response)
+}
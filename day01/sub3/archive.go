@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// isPruned 判断区块的交易是否已归档（内存中只保留交易列表的哈希）
+func (b *Block) isPruned() bool {
+	return b.TxHash != ""
+}
+
+// archiveOldBlocks 当内存中保留完整交易的区块超过MaxChainBlocks时，
+// 把最旧的区块保存到归档存储，并在内存中只保留其区块头（交易替换为交易列表的哈希）。
+// 区块哈希与链接关系不变，链仍可校验；保存失败时保留区块不变，下次出块时重试。
+func (bc *Blockchain) archiveOldBlocks() error {
+	if bc.MaxChainBlocks <= 0 || bc.Store == nil {
+		return nil
+	}
+
+	first := 0
+	for first < len(bc.Chain) && bc.Chain[first].isPruned() {
+		first++
+	}
+	excess := len(bc.Chain) - first - bc.MaxChainBlocks
+	if excess <= 0 {
+		return nil
+	}
+
+	blocks := bc.Chain[first : first+excess]
+	if err := bc.Store.SaveBlocks(blocks); err != nil {
+		return err
+	}
+
+	// 替换为新的区块对象，已返回给调用方的副本不受影响
+	for i, block := range blocks {
+		pruned := *block
+		pruned.TxHash = block.txHash()
+		pruned.Transactions = nil
+		blocks[i] = &pruned
+	}
+	return nil
+}
+
+// LoadArchive 读取归档文件中的所有区块
+// 读取前用配套的 .sha256 文件校验内容，不一致时返回 ErrChecksumMismatch 而不是部分有效的区块；
+// 旧版本写入的归档没有校验和，此时照常读取并输出警告
+func LoadArchive(path string) ([]*Block, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("打开归档文件失败: %v", err)
+	}
+	defer f.Close()
+
+	verified, err := VerifyChecksum(path)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		fmt.Fprintf(os.Stderr, "警告: 归档文件 %s 没有校验和，未校验完整性\n", path)
+	}
+
+	var blocks []*Block
+	dec := json.NewDecoder(f)
+	for dec.More() {
+		var block Block
+		if err := dec.Decode(&block); err != nil {
+			return nil, fmt.Errorf("解析归档文件失败: %v", err)
+		}
+		blocks = append(blocks, &block)
+	}
+	return blocks, nil
+}
+
+// loadArchive 读取归档存储中的所有区块
+func (bc *Blockchain) loadArchive() ([]*Block, error) {
+	if bc.Store == nil {
+		return nil, fmt.Errorf("未配置归档存储，无法取回已归档的区块")
+	}
+	return bc.Store.LoadBlocks()
+}
+
+// LoadArchivedBlock 从归档存储中取回指定高度的完整区块，并核对其哈希与内存中的区块头一致
+func (bc *Blockchain) LoadArchivedBlock(index int) (*Block, error) {
+	if index < 0 || index >= len(bc.Chain) {
+		return nil, ErrBlockNotFound
+	}
+	if !bc.Chain[index].isPruned() {
+		return bc.Chain[index].Clone(), nil
+	}
+
+	blocks, err := bc.loadArchive()
+	if err != nil {
+		return nil, err
+	}
+	for _, block := range blocks {
+		if block.Index == index && block.Hash == bc.Chain[index].Hash && block.CalculateHash() == block.Hash {
+			return block, nil
+		}
+	}
+	return nil, fmt.Errorf("归档中缺少区块 %d", index)
+}
+
+// fullChain 返回区块序列，已归档的区块从归档存储中取回完整交易
+func (bc *Blockchain) fullChain(chain []*Block) ([]*Block, error) {
+	var archived map[string]*Block
+	full := make([]*Block, len(chain))
+	for i, block := range chain {
+		if !block.isPruned() {
+			full[i] = block
+			continue
+		}
+
+		if archived == nil {
+			blocks, err := bc.loadArchive()
+			if err != nil {
+				return nil, err
+			}
+			archived = make(map[string]*Block, len(blocks))
+			for _, b := range blocks {
+				archived[b.Hash] = b
+			}
+		}
+		restored, ok := archived[block.Hash]
+		if !ok || restored.CalculateHash() != block.Hash {
+			return nil, fmt.Errorf("归档中缺少区块 %d", block.Index)
+		}
+		full[i] = restored
+	}
+	return full, nil
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"errors"
+	"math"
+	"testing"
+)
+
+func TestSpendMinedReward(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	if got := bc.GetBalance("alice"); got != miningReward {
+		t.Fatalf("挖矿后余额为 %s，应为奖励 %s", got, miningReward)
+	}
+
+	if _, _, err := bc.CreateTransaction("alice", "bob", Coin/4); err != nil {
+		t.Fatalf("花费部分奖励失败: %v", err)
+	}
+	mustMine(t, bc, "miner")
+	if got, want := bc.GetBalance("alice"), miningReward-Coin/4; got != want {
+		t.Fatalf("alice 的余额为 %s，应为 %s", got, want)
+	}
+	if got := bc.GetBalance("bob"); got != Coin/4 {
+		t.Fatalf("bob 的余额为 %s，应为 %s", got, Coin/4)
+	}
+
+	if _, _, err := bc.CreateTransaction("alice", "bob", Coin); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("超出余额的转账返回 %v，应为 ErrInsufficientBalance", err)
+	}
+}
+
+func TestOverspendCountsPendingTransfers(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+
+	// 每笔都不超过余额，但合计超过：交易池中待转出的金额须从可用余额中扣除
+	if _, _, err := bc.CreateTransaction("alice", "bob", Coin*3/4); err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := bc.CreateTransaction("alice", "carol", Coin/2); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("与交易池中的转出合计超过余额时返回 %v，应为 ErrInsufficientBalance", err)
+	}
+	if _, _, err := bc.CreateTransaction("alice", "carol", Coin/4); err != nil {
+		t.Fatalf("恰好用完余额的转账被拒绝: %v", err)
+	}
+}
+
+func TestUnfundedSenderRejected(t *testing.T) {
+	bc := newTestChain(t)
+	if _, _, err := bc.CreateTransaction("mallory", "bob", 1); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("没有余额的发送方返回 %v，应为 ErrInsufficientBalance", err)
+	}
+	// 挖矿奖励没有发送方，不受余额限制
+	if block := mustMine(t, bc, "miner"); !block.Transactions[0].Coinbase {
+		t.Fatal("区块应以奖励交易开头")
+	}
+	if got := bc.GetBalance("miner"); got != miningReward {
+		t.Fatalf("矿工余额为 %s，应为 %s", got, miningReward)
+	}
+}
+
+func TestOverspendRejectsOverflowingPending(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+
+	huge := transfer(bc, "alice", "bob", math.MaxInt64)
+	huge.Fee = 1
+	if _, err := bc.AddTransaction(huge); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("金额加手续费溢出的交易返回 %v，应为 ErrInsufficientBalance", err)
+	}
+
+	// 不经过余额检查放入交易池（如合并对端交易池），待转出合计超出 Amount 的上限
+	first, second := transfer(bc, "alice", "bob", math.MaxInt64), transfer(bc, "alice", "carol", math.MaxInt64)
+	bc.Transactions = []Transaction{first, second}
+	if got := bc.pendingOutgoing("alice"); got != math.MaxInt64 {
+		t.Fatalf("待转出合计为 %s，应饱和为上限", got)
+	}
+	if _, err := bc.AddTransaction(transfer(bc, "alice", "dave", 1)); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("待转出超过余额时返回 %v，应为 ErrInsufficientBalance", err)
+	}
+}
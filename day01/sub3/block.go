@@ -1,28 +1,135 @@
 package main
 
 import (
+	"bytes"
+	"context"
 	"crypto/sha256"
 	"encoding/hex"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
 	"strconv"
+	"sync"
 	"time"
+
+	"openspace/day01/pow"
+)
+
+var (
+	// ErrBlockNotFound 区块不存在
+	ErrBlockNotFound = errors.New("区块不存在")
+	// ErrTransactionNotFound 交易不存在
+	ErrTransactionNotFound = errors.New("交易不存在")
+	// ErrInvalidChain 区块链无效
+	ErrInvalidChain = errors.New("区块链无效")
+	// ErrChainNotLonger 新链的累计工作量不比当前链多（分叉按工作量而不是区块数选择）
+	ErrChainNotLonger = errors.New("新链的累计工作量不比当前链多")
+	// ErrChainNotSaved 存储中还没有保存过区块链
+	ErrChainNotSaved = errors.New("存储中没有保存的区块链")
+	// ErrProofNotFound 达到尝试次数上限或被取消时仍未找到工作量证明
+	ErrProofNotFound = errors.New("未找到工作量证明")
 )
 
 // Transaction 表示一个交易
 type Transaction struct {
-	Sender    string  `json:"sender"`    // 发送方
-	Recipient string  `json:"recipient"` // 接收方
-	Amount    float64 `json:"amount"`    // 金额
+	Sender    string `json:"sender"`               // 发送方
+	Recipient string `json:"recipient"`            // 接收方
+	Amount    Amount `json:"amount"`               // 金额（最小单位）
+	Fee       Amount `json:"fee,omitempty"`        // 手续费（最小单位）
+	Timestamp int64  `json:"timestamp,omitempty"`  // 创建时间（Unix纳秒），使内容相同的转账具有不同的ID
+	RefHeight int    `json:"ref_height,omitempty"` // 创建时的链尾高度，启用重放保护窗口时交易过期的依据（见 validateTxHeight）
+	Memo      string `json:"memo,omitempty"`       // 附言（如发票号），最长MaxMemoLength字节
+	PublicKey []byte `json:"public_key,omitempty"` // 签名者的公钥（PKIX DER），未签名的交易为空
+	Signature []byte `json:"signature,omitempty"`  // 对 SigningBytes 的签名，不计入交易ID
+	Coinbase  bool   `json:"coinbase,omitempty"`   // 是否为挖矿奖励交易，奖励没有发送方
+}
+
+// Clone 返回交易的深拷贝，公钥与签名不与原交易共享
+func (tx Transaction) Clone() Transaction {
+	tx.PublicKey = bytes.Clone(tx.PublicKey)
+	tx.Signature = bytes.Clone(tx.Signature)
+	return tx
+}
+
+// cloneTransactions 返回交易列表的深拷贝
+func cloneTransactions(txs []Transaction) []Transaction {
+	clones := make([]Transaction, len(txs))
+	for i, tx := range txs {
+		clones[i] = tx.Clone()
+	}
+	return clones
+}
+
+// ID 计算交易的标识（交易内容的SHA-256哈希）
+func (tx Transaction) ID() string {
+	h := sha256.Sum256(tx.canonicalJSON())
+	return hex.EncodeToString(h[:])
+}
+
+// canonicalJSON 返回用于哈希与签名的交易规范序列化，不包含签名
+// 与API展示用的JSON分离，调整展示格式不会改变交易ID与区块哈希
+func (tx Transaction) canonicalJSON() []byte {
+	data, _ := json.Marshal(struct {
+		Sender    string `json:"sender"`
+		Recipient string `json:"recipient"`
+		Amount    Amount `json:"amount"`
+		Fee       Amount `json:"fee,omitempty"`
+		Timestamp int64  `json:"timestamp,omitempty"`
+		RefHeight int    `json:"ref_height,omitempty"`
+		Memo      string `json:"memo,omitempty"`
+		PublicKey []byte `json:"public_key,omitempty"`
+		Coinbase  bool   `json:"coinbase,omitempty"`
+	}{
+		Sender:    tx.Sender,
+		Recipient: tx.Recipient,
+		Amount:    tx.Amount,
+		Fee:       tx.Fee,
+		Timestamp: tx.Timestamp,
+		RefHeight: tx.RefHeight,
+		Memo:      tx.Memo,
+		PublicKey: tx.PublicKey,
+		Coinbase:  tx.Coinbase,
+	})
+	return data
 }
 
 // Block 表示区块链中的一个区块
 type Block struct {
-	Index        int           `json:"index"`         // 区块高度
-	Timestamp    int64         `json:"timestamp"`     // 时间戳
-	Transactions []Transaction `json:"transactions"`  // 交易列表
-	Proof        int64         `json:"proof"`         // 工作量证明
-	PreviousHash string        `json:"previous_hash"` // 前一个区块的哈希
-	Hash         string        `json:"hash"`          // 当前区块的哈希
+	Version      int           `json:"version,omitempty"`     // 区块格式版本，决定区块哈希的编码方式
+	Index        int           `json:"index"`                 // 区块高度
+	Timestamp    int64         `json:"timestamp"`             // 时间戳
+	Transactions []Transaction `json:"transactions"`          // 交易列表
+	Nonce        int64         `json:"nonce"`                 // 挖矿时不断改变的随机数，使区块哈希满足难度
+	Difficulty   int           `json:"difficulty"`            // 挖出该区块时的难度（前导0比特数）
+	PreviousHash string        `json:"previous_hash"`         // 前一个区块的哈希
+	Hash         string        `json:"hash"`                  // 当前区块的哈希
+	TxHash       string        `json:"tx_hash,omitempty"`     // 交易已归档时保留的交易列表哈希
+	MerkleRoot   string        `json:"merkle_root,omitempty"` // 交易的Merkle根，BlockVersionMerkle 及之后的区块以它参与区块哈希
+}
+
+// UnmarshalJSON 解析区块，兼容 Nonce 改名前以 "proof" 表示的数据（旧的区块链文件、归档与未升级的节点）
+// Nonce 不参与JSON以外的任何编码，改名不影响区块哈希
+func (b *Block) UnmarshalJSON(data []byte) error {
+	type plain Block
+	aux := struct {
+		*plain
+		Proof *int64 `json:"proof"`
+	}{plain: (*plain)(b)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Proof != nil && b.Nonce == 0 {
+		b.Nonce = *aux.Proof
+	}
+	return nil
+}
+
+// Clone 返回区块的深拷贝，交易列表及交易的公钥与签名都不与原区块共享
+func (b *Block) Clone() *Block {
+	clone := *b
+	clone.Transactions = cloneTransactions(b.Transactions)
+	return &clone
 }
 
 // ToJSON 将区块转换为JSON字符串
@@ -41,8 +148,93 @@ func (b *Block) FromJSON(data []byte) error {
 
 // Blockchain 表示区块链
 type Blockchain struct {
-	Chain        []*Block     `json:"chain"`         // 区块链
-	Transactions []Transaction `json:"pending_transactions"` // 待处理交易
+	Version      int           `json:"version"`                 // 序列化格式版本
+	Difficulty   int           `json:"difficulty"`              // 基准挖矿难度（前导0比特数），难度调整从它开始（见 NextDifficulty）
+	PoWAlgorithm string        `json:"pow_algorithm,omitempty"` // 工作量证明算法，空表示SHA-256
+	Chain        []*Block      `json:"chain"`                   // 区块链
+	Transactions []Transaction `json:"pending_transactions"`    // 待处理交易
+
+	// InitialReward 高度1的区块的挖矿奖励，0表示 miningReward；HalvingInterval 奖励减半的间隔（区块数），0表示不减半
+	// 二者决定区块奖励的合计（见 BlockReward），与 GenesisDifficulty 一样随区块链保存，网络中的节点须使用相同的值
+	InitialReward   Amount `json:"initial_reward,omitempty"`
+	HalvingInterval int    `json:"halving_interval,omitempty"`
+
+	// LegacyPoWHeight 高度低于它的区块按版本3之前的规则（ValidProof）验证工作量证明
+	// 由旧版本数据迁移而来，新建的区块链为0
+	LegacyPoWHeight int `json:"legacy_pow_height,omitempty"`
+
+	// DifficultyHeight 从这个高度起区块的难度须符合难度调整规则（见 expectedDifficulty），更早的区块只要求不低于 MinDifficulty
+	// 由版本5之前的数据迁移而来（当时难度由各节点自行设置），新建的区块链为0，即从高度1起
+	DifficultyHeight int `json:"difficulty_height,omitempty"`
+
+	// GenesisDifficulty 创世区块的挖矿难度，与之后区块的难度（Difficulty）相互独立
+	// 0表示创世区块免于工作量证明（证明固定为1）；大于0时创世区块按此难度挖出，
+	// 校验时也要求对端链的创世区块至少达到此难度
+	GenesisDifficulty int `json:"genesis_difficulty,omitempty"`
+
+	// ReplayWindow 重放保护窗口（区块数），0表示不启用：交易ID在整条链上只能出现一次，账户状态记住全部交易ID
+	// 启用后交易只能被打包进其 RefHeight 之后的 ReplayWindow 个区块内，过期后即被拒绝；
+	// 账户状态只需记住窗口内的交易ID，内存有界，窗口之外的旧交易因过期而无法重放。
+	// 它决定区块是否有效，是随区块链保存的共识规则，网络中的节点须使用相同的值；只能在出块前用 SetReplayWindow 设置
+	ReplayWindow int `json:"replay_window,omitempty"`
+
+	// MinBlockInterval 相邻区块之间的最小时间间隔，0表示不限制
+	// 用于低难度的测试网络，避免出块快于节点间的同步
+	MinBlockInterval time.Duration `json:"-"`
+
+	// MinTxAmount 新交易允许的最小金额，低于它的交易视为粉尘被拒绝，0表示不限制
+	// 只是本节点接收交易的策略，不影响对区块的校验，挖矿奖励不受限制
+	MinTxAmount Amount `json:"-"`
+
+	// AddressFormat 新交易双方地址须符合的格式，nil表示不校验
+	// 与MinTxAmount一样只是本节点接收交易的策略，不影响对区块的校验
+	AddressFormat AddressFormat `json:"-"`
+
+	// StrictRecipients 新交易的接收方须为链上出现过的地址，或符合地址格式的派生地址，默认关闭
+	// 同样只是接收交易的策略：防止拼写错误的接收方造成无法花费的资金
+	StrictRecipients bool `json:"-"`
+
+	// MaxChainBlocks 内存中保留完整交易的最大区块数，0表示不限制
+	// 超出后最旧区块保存到Store并从内存中移除交易，只保留区块头；Store为nil时不归档。
+	// 包含已归档区块的链仍可校验，但其他节点无法据此重建账户状态，因此不会采用它
+	MaxChainBlocks int `json:"-"`
+
+	// Store 区块链的持久化后端：Save 把整条链写入其中，已归档的区块也保存在其中，为nil时不持久化
+	Store Store `json:"-"`
+
+	// MaxMiningAttempts 挖一个区块最多尝试的证明数，0表示不限制
+	// 难度设置过高时挖矿以 ErrProofNotFound 结束而不是让节点看起来卡住，交易留在交易池中
+	MaxMiningAttempts int64 `json:"-"`
+
+	// MaxBlockTransactions 每个新区块最多打包的普通交易数，0表示不限制
+	// 交易池中的交易多于此数时按手续费从高到低打包（见 blockCandidates），其余留在交易池中等待下一个区块
+	MaxBlockTransactions int `json:"-"`
+
+	// MaxMempoolSize 交易池最多容纳的交易数，0表示不限制
+	// 交易池已满时，手续费高于池中最低手续费的新交易替换该交易，否则以 ErrMempoolFull 拒绝
+	MaxMempoolSize int `json:"-"`
+
+	// TargetBlockTime 与 RetargetInterval 一起启用难度调整：每 RetargetInterval 个区块比较实际用时与
+	// TargetBlockTime*RetargetInterval，出块过快时难度加1比特、过慢时减1比特（见 expectedDifficulty）。
+	// 难度调整是共识规则，校验区块时同样按它检查每个区块的难度，因此与 Difficulty 一起随区块链保存，
+	// 网络中的节点须使用相同的值；任一为0时所有区块都按 Difficulty 挖出
+	TargetBlockTime  time.Duration `json:"target_block_time,omitempty"`
+	RetargetInterval int           `json:"retarget_interval,omitempty"`
+	// MaxDifficulty 难度调整的上限（比特），0表示只受哈希长度限制
+	MaxDifficulty int `json:"max_difficulty,omitempty"`
+
+	state    *State               // 由链上交易推导出的账户状态
+	publish  func(MiningEvent)    // 发布挖矿事件，由所属的Network设置
+	arrivals map[string]time.Time // 待处理交易进入交易池的时间（按交易ID）
+	byHash   blockHashIndex       // 按哈希查找区块的索引
+}
+
+// blockHashIndex 区块哈希到高度的索引，查询时发现链尾已变化（出块或链重组）则先重建
+// 查询接口只持有区块链的读锁，可能并发执行，因此索引有自己的锁
+type blockHashIndex struct {
+	sync.Mutex
+	tip     string         // 建立索引时的链尾哈希
+	heights map[string]int // 区块哈希 -> 高度
 }
 
 // ToJSON 将区块链转换为JSON字符串
@@ -54,97 +246,303 @@ func (bc *Blockchain) ToJSON() (string, error) {
 	return string(data), nil
 }
 
-// FromJSON 从JSON字符串解析区块链
+// Save 将区块链（含待处理交易）以JSON写入 bc.Store，可用 LoadBlockchain 读回
+func (bc *Blockchain) Save() error {
+	if bc.Store == nil {
+		return fmt.Errorf("未配置存储，无法保存区块链")
+	}
+	data, err := bc.ToJSON()
+	if err != nil {
+		return fmt.Errorf("序列化区块链失败: %v", err)
+	}
+	return bc.Store.SaveChain([]byte(data))
+}
+
+// LoadBlockchain 读取 Save 写入store的区块链：迁移旧版本格式、从store中的归档与区块一起重建账户状态并校验整条链
+// 存储中没有区块链时返回 ErrChainNotSaved；数据损坏或链无效时返回错误，而不是返回部分有效的区块链。
+// 返回的区块链以store为 Store
+func LoadBlockchain(store Store) (*Blockchain, error) {
+	data, err := store.LoadChain()
+	if err != nil {
+		return nil, err
+	}
+	if data == nil {
+		return nil, ErrChainNotSaved
+	}
+
+	bc := &Blockchain{Store: store}
+	if err := bc.FromJSON(data); err != nil {
+		return nil, fmt.Errorf("解析区块链失败: %v", err)
+	}
+	if len(bc.Chain) == 0 {
+		return nil, fmt.Errorf("%w: 没有区块", ErrInvalidChain)
+	}
+	if index, err := bc.validateChain(bc.Chain); err != nil {
+		return nil, fmt.Errorf("%w: 区块 %d: %v", ErrInvalidChain, index, err)
+	}
+	return bc, nil
+}
+
+// SaveToFile 将区块链以JSON写入path，并写入配套的 .sha256 校验和（见 FileStore.SaveChain）
+// 不改变 bc.Store，可用 LoadFromFile 读回
+func (bc *Blockchain) SaveToFile(path string) error {
+	data, err := bc.ToJSON()
+	if err != nil {
+		return fmt.Errorf("序列化区块链失败: %v", err)
+	}
+	return (&FileStore{ChainPath: path}).SaveChain([]byte(data))
+}
+
+// LoadFromFile 读取 SaveToFile 写入的区块链文件，已归档的区块保存在archivePath（为空表示没有归档）
+// 与 LoadBlockchain 相同地校验，文件不存在时返回 ErrChainNotSaved
+func LoadFromFile(path, archivePath string) (*Blockchain, error) {
+	bc, err := LoadBlockchain(&FileStore{Path: archivePath, ChainPath: path})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return bc, nil
+}
+
+// FromJSON 从JSON字符串解析区块链，迁移旧版本格式并重建账户状态
+// 已归档的区块从 bc.Store 取回，须在调用前设置存储（见 rebuildState）
 func (bc *Blockchain) FromJSON(data []byte) error {
-	return json.Unmarshal(data, bc)
+	if err := json.Unmarshal(data, bc); err != nil {
+		return err
+	}
+	if err := checkBlocks(bc.Chain); err != nil {
+		return err
+	}
+	if err := bc.migrate(); err != nil {
+		return err
+	}
+	if err := bc.rebuildState(); err != nil {
+		return err
+	}
+	bc.setPending(bc.Transactions) // 到达时间不随区块链序列化，按加载时间计
+	return nil
+}
+
+// rebuildState 重放整条链重建账户状态，已归档的区块从归档存储恢复完整交易
+// 只重放内存中的区块会丢失归档区块中的余额，因此归档不可用时返回错误而不是构建不完整的状态
+func (bc *Blockchain) rebuildState() error {
+	chain, err := bc.fullChain(bc.Chain)
+	if err != nil {
+		return fmt.Errorf("重建账户状态失败: %v", err)
+	}
+	bc.state = stateFromChain(chain, bc.ReplayWindow)
+	return nil
+}
+
+// SetReplayWindow 设置重放保护窗口并按新窗口重建账户状态
+// 窗口决定已有区块中的交易是否过期，链上已有创世区块之外的区块时返回错误
+func (bc *Blockchain) SetReplayWindow(blocks int) error {
+	if len(bc.Chain) > 1 {
+		return fmt.Errorf("区块链已有 %d 个区块，不能修改重放保护窗口", len(bc.Chain))
+	}
+	if blocks < 0 {
+		return fmt.Errorf("无效的重放保护窗口 %d", blocks)
+	}
+	bc.ReplayWindow = blocks
+	return bc.rebuildState()
 }
 
 // GetChain 获取区块链的副本
+// 返回的区块均为深拷贝，外部修改不会影响链上数据
 func (bc *Blockchain) GetChain() []*Block {
-	return bc.Chain
+	chain := make([]*Block, len(bc.Chain))
+	for i, block := range bc.Chain {
+		chain[i] = block.Clone()
+	}
+	return chain
+}
+
+// GetChainAsOf 获取在给定时刻已存在的区块（时间戳不晚于t）的副本
+// 返回的是链的前缀：遇到第一个晚于t的区块即停止。早于创世区块的时间返回空链。
+func (bc *Blockchain) GetChainAsOf(t time.Time) []*Block {
+	chain := []*Block{}
+	for _, block := range bc.Chain {
+		if block.Timestamp > t.Unix() {
+			break
+		}
+		chain = append(chain, block.Clone())
+	}
+	return chain
 }
 
-// GetPendingTransactions 获取待处理交易
+// GetPendingTransactions 获取待处理交易的副本
 func (bc *Blockchain) GetPendingTransactions() []Transaction {
-	return bc.Transactions
+	return cloneTransactions(bc.Transactions)
 }
 
 // ClearPendingTransactions 清空待处理交易
 func (bc *Blockchain) ClearPendingTransactions() {
-	bc.Transactions = []Transaction{}
+	bc.setPending([]Transaction{})
 }
 
 // NewBlock 创建新区块
-func NewBlock(proof int64, previousHash string) *Block {
+func NewBlock(nonce int64, previousHash string) *Block {
 	block := &Block{
+		Version:      CurrentBlockVersion,
 		Index:        0,
 		Timestamp:    time.Now().Unix(),
 		Transactions: []Transaction{},
-		Proof:        proof,
+		Nonce:        nonce,
 		PreviousHash: previousHash,
 	}
+	block.MerkleRoot = block.txHash()
 	block.Hash = block.CalculateHash()
 	return block
 }
 
 // CalculateHash 计算区块的哈希值
 func (b *Block) CalculateHash() string {
-	hasher := sha256.New()
-	record := strconv.Itoa(b.Index) +
-		strconv.FormatInt(b.Timestamp, 10) +
-		hashTransactions(b.Transactions) +
-		strconv.FormatInt(b.Proof, 10) +
-		b.PreviousHash
-	hasher.Write([]byte(record))
-	return hex.EncodeToString(hasher.Sum(nil))
+	return blockHash(b.Version, b.Index, b.Timestamp, b.txHash(), b.Nonce, b.PreviousHash)
+}
+
+// txHash 返回交易列表的哈希，交易已归档的区块使用保留的哈希
+// BlockVersionMerkle 及之后的区块为交易的Merkle根，由交易重新计算而不是取 MerkleRoot 字段
+func (b *Block) txHash() string {
+	if b.TxHash != "" {
+		return b.TxHash
+	}
+	if b.Version >= BlockVersionMerkle {
+		return txMerkleRoot(b.Transactions)
+	}
+	return hashTransactions(b.Transactions)
+}
+
+// blockHash 由区块头字段计算区块哈希，交易只以交易列表的哈希参与计算
+func blockHash(version, index int, timestamp int64, txHash string, proof int64, previousHash string) string {
+	sum := sha256.Sum256(appendBlockRecord(nil, version, index, timestamp, txHash, proof, previousHash))
+	return hex.EncodeToString(sum[:])
 }
 
 // hashTransactions 计算交易列表的哈希值
 func hashTransactions(transactions []Transaction) string {
 	txHashes := ""
 	for _, tx := range transactions {
-		txData, _ := json.Marshal(tx)
-		h := sha256.Sum256(txData)
-		txHashes += hex.EncodeToString(h[:])
+		txHashes += tx.ID()
 	}
 
 	h := sha256.Sum256([]byte(txHashes))
 	return hex.EncodeToString(h[:])
 }
 
-// ProofOfWork 工作量证明算法
-func ProofOfWork(lastProof int64) int64 {
-	var proof int64 = 0
-	for !ValidProof(lastProof, proof) {
-		proof++
+// SolveBlock 为区块寻找证明，使按algorithm计算的区块哈希至少有block.Difficulty个前导0比特
+// 证明参与区块哈希计算，因此工作量与区块的全部内容（交易、前一个区块哈希等）绑定。
+// 找到后设置区块的Nonce与Hash；与 day01/sub2 的哈希搜索共用 pow.SolveParallel，opts.Workers 为0时自动选择并行数。
+// 达到 opts.MaxAttempts 或 opts.Context 被取消仍未找到时返回 ErrProofNotFound，区块保持不变
+func SolveBlock(block *Block, algorithm string, opts pow.Options) error {
+	powFunc, err := lookupPoW(algorithm)
+	if err != nil {
+		return err
+	}
+
+	txHash := block.txHash()
+	proof, _, found := pow.SolveParallel(func() pow.HashFunc {
+		var record []byte
+		var sum [32]byte
+		return func(proof int64) []byte {
+			record = appendBlockRecord(record[:0], block.Version, block.Index, block.Timestamp, txHash, proof, block.PreviousHash)
+			sum = powFunc(record)
+			return sum[:]
+		}
+	}, block.Difficulty, opts)
+	if !found {
+		if opts.Context != nil && opts.Context.Err() != nil {
+			return fmt.Errorf("%w: %v", ErrProofNotFound, opts.Context.Err())
+		}
+		return fmt.Errorf("%w: 尝试 %d 次仍未达到难度 %d 比特", ErrProofNotFound, opts.MaxAttempts, block.Difficulty)
+	}
+
+	block.Nonce = proof
+	block.Hash = block.CalculateHash()
+	return nil
+}
+
+// meetsDifficulty 判断按algorithm计算的区块哈希是否至少有Difficulty个前导0比特
+func (h BlockHeader) meetsDifficulty(algorithm string) bool {
+	sum, err := h.powSum(algorithm)
+	if err != nil {
+		return false
+	}
+	return pow.HasLeadingZeroBits(sum[:], h.Difficulty)
+}
+
+// powSum 按algorithm计算区块头的工作量证明哈希，SHA-256时即为区块哈希本身
+func (h BlockHeader) powSum(algorithm string) ([32]byte, error) {
+	powFunc, err := lookupPoW(algorithm)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return powFunc(appendBlockRecord(nil, h.Version, h.Index, h.Timestamp, h.TxHash, h.Nonce, h.PreviousHash)), nil
+}
+
+// ValidProof 按版本3之前的规则验证工作量证明：只对前一个证明与当前证明的拼接求哈希，
+// 与区块内容无关。仅用于验证LegacyPoWHeight之前的历史区块
+func ValidProof(lastProof, proof int64, difficulty int, algorithm string) bool {
+	sum, err := proofSum(lastProof, proof, algorithm)
+	if err != nil {
+		return false
 	}
-	return proof
+	return pow.HasLeadingZeroBits(sum[:], difficulty) // 要求哈希值至少有difficulty个前导0比特
 }
 
-// ValidProof 验证工作量证明
-func ValidProof(lastProof, proof int64) bool {
-	hasher := sha256.New()
-	hasher.Write([]byte(strconv.FormatInt(lastProof, 10) + strconv.FormatInt(proof, 10)))
-	hash := hex.EncodeToString(hasher.Sum(nil))
-	return hash[:4] == "0000" // 要求哈希值以4个0开头
+// proofInput 返回工作量证明的哈希输入
+func proofInput(lastProof, proof int64) []byte {
+	return []byte(strconv.FormatInt(lastProof, 10) + strconv.FormatInt(proof, 10))
+}
+
+// proofSum 计算工作量证明的哈希值
+func proofSum(lastProof, proof int64, algorithm string) ([32]byte, error) {
+	powFunc, err := lookupPoW(algorithm)
+	if err != nil {
+		return [32]byte{}, err
+	}
+	return powFunc(proofInput(lastProof, proof)), nil
 }
 
 // NewBlockchain 创建新的区块链
 func NewBlockchain() *Blockchain {
 	bc := &Blockchain{
+		Version:      ChainVersion,
+		Difficulty:   DefaultDifficulty,
 		Chain:        []*Block{},
 		Transactions: []Transaction{},
+		state:        NewState(),
 	}
 
-	// 创建创世区块
-	bc.CreateGenesisBlock()
+	// 创建创世区块，难度为0时不需要挖矿，不会失败
+	_ = bc.CreateGenesisBlock()
 	return bc
 }
 
-// CreateGenesisBlock 创建创世区块
-func (bc *Blockchain) CreateGenesisBlock() {
+// NewBlockchainWithDifficulty 创建按给定难度挖矿的区块链，难度以前导0比特数表示
+// 旧版的n个十六进制0对应 pow.ZerosToBits(n) 比特；低于 MinDifficulty 时按 MinDifficulty。
+// 每个区块记录挖出时的难度，之后修改 Difficulty 不影响对已有区块的校验
+func NewBlockchainWithDifficulty(difficulty int) *Blockchain {
+	bc := NewBlockchain()
+	bc.Difficulty = max(difficulty, MinDifficulty)
+	return bc
+}
+
+// CreateGenesisBlock 按GenesisDifficulty创建创世区块，作为链上唯一的区块
+// 修改GenesisDifficulty或PoWAlgorithm后可再次调用以重新创建，链上已有其他区块时返回错误
+func (bc *Blockchain) CreateGenesisBlock() error {
+	if len(bc.Chain) > 1 {
+		return fmt.Errorf("区块链已有 %d 个区块，不能重新创建创世区块", len(bc.Chain))
+	}
+
 	genesisBlock := NewBlock(1, "0")
-	bc.Chain = append(bc.Chain, genesisBlock)
+	if bc.GenesisDifficulty > 0 {
+		genesisBlock.Difficulty = bc.GenesisDifficulty
+		if err := SolveBlock(genesisBlock, bc.PoWAlgorithm, bc.miningOptions()); err != nil {
+			return err
+		}
+	}
+	bc.Chain = []*Block{genesisBlock}
+	bc.state = stateFromChain(bc.Chain, bc.ReplayWindow)
+	return nil
 }
 
 // GetLastBlock 获取最后一个区块
@@ -152,71 +550,487 @@ func (bc *Blockchain) GetLastBlock() *Block {
 	return bc.Chain[len(bc.Chain)-1]
 }
 
-// CreateTransaction 创建新交易
-func (bc *Blockchain) CreateTransaction(sender, recipient string, amount float64) int {
+// GetBlockByIndex 按高度查找区块，返回区块的副本
+func (bc *Blockchain) GetBlockByIndex(index int) (*Block, error) {
+	if index < 0 || index >= len(bc.Chain) {
+		return nil, ErrBlockNotFound
+	}
+	return bc.Chain[index].Clone(), nil
+}
+
+// GetBlockByHash 按哈希查找区块，返回区块的副本
+func (bc *Blockchain) GetBlockByHash(hash string) (*Block, error) {
+	height, ok := bc.heightOf(hash)
+	if !ok {
+		return nil, ErrBlockNotFound
+	}
+	return bc.Chain[height].Clone(), nil
+}
+
+// heightOf 通过哈希索引查找区块的高度
+func (bc *Blockchain) heightOf(hash string) (int, bool) {
+	if len(bc.Chain) == 0 {
+		return 0, false
+	}
+	index := &bc.byHash
+	index.Lock()
+	defer index.Unlock()
+
+	if tip := bc.GetLastBlock().Hash; index.heights == nil || index.tip != tip {
+		index.heights = make(map[string]int, len(bc.Chain))
+		for i, block := range bc.Chain {
+			index.heights[block.Hash] = i
+		}
+		index.tip = tip
+	}
+	height, ok := index.heights[hash]
+	// 链尾之前的区块被直接修改（如演示中的篡改）时索引可能过期，以链上的实际哈希为准
+	if !ok || height >= len(bc.Chain) || bc.Chain[height].Hash != hash {
+		return 0, false
+	}
+	return height, true
+}
+
+// FindTransaction 按交易ID查找已确认的交易，返回交易的副本及其所在区块的高度
+func (bc *Blockchain) FindTransaction(txID string) (*Transaction, int, error) {
+	for _, block := range bc.Chain {
+		for i := range block.Transactions {
+			if block.Transactions[i].ID() == txID {
+				tx := block.Transactions[i].Clone()
+				return &tx, block.Index, nil
+			}
+		}
+	}
+	return nil, -1, ErrTransactionNotFound
+}
+
+// GetAddressTransactions 获取与某地址相关的所有已确认交易
+func (bc *Blockchain) GetAddressTransactions(address string) []Transaction {
+	txs := []Transaction{}
+	for _, block := range bc.Chain {
+		for _, tx := range block.Transactions {
+			if tx.Sender == address || tx.Recipient == address {
+				txs = append(txs, tx.Clone())
+			}
+		}
+	}
+	return txs
+}
+
+// GetBalance 返回地址当前的余额
+func (bc *Blockchain) GetBalance(address string) Amount {
+	return bc.state.Balance(address)
+}
+
+// AllAddresses 返回链上所有曾参与交易的地址（去重并排序）
+func (bc *Blockchain) AllAddresses() []string {
+	return bc.state.Addresses()
+}
+
+// MinedBlocks 返回奖励了该地址的区块，从新到旧排序；从未获得奖励的地址返回空列表
+// 由维护的账户状态得出，已归档的区块同样包含在内
+func (bc *Blockchain) MinedBlocks(address string) []BlockReward {
+	rewards := bc.state.Rewards(address)
+	blocks := make([]BlockReward, len(rewards))
+	for i, reward := range rewards {
+		blocks[len(rewards)-1-i] = reward
+	}
+	return blocks
+}
+
+// GetBalanceAtHeight 重放区块链至指定高度（含），返回地址在该高度时的余额
+func (bc *Blockchain) GetBalanceAtHeight(address string, height int) (Amount, error) {
+	if height < 0 || height >= len(bc.Chain) {
+		return 0, fmt.Errorf("高度 %d 超出区块链范围 [0, %d]", height, len(bc.Chain)-1)
+	}
+	chain, err := bc.fullChain(bc.Chain[:height+1])
+	if err != nil {
+		return 0, err
+	}
+	return stateFromChain(chain, bc.ReplayWindow).Balance(address), nil
+}
+
+// CreateTransaction 校验并创建新交易（不含手续费），返回交易ID与将包含此交易的区块索引
+func (bc *Blockchain) CreateTransaction(sender, recipient string, amount Amount) (string, int, error) {
+	return bc.CreateTransactionWithFee(sender, recipient, amount, 0)
+}
+
+// CreateTransactionWithFee 校验并创建附带手续费的新交易，返回交易ID与将包含此交易的区块索引
+// 创建时间精确到纳秒，内容相同的转账也有不同的交易ID；与已确认或交易池中的交易ID相同时由 AddTransaction 拒绝。
+// 交易以当前链尾为 RefHeight，启用重放保护窗口时在之后的 ReplayWindow 个区块内有效
+func (bc *Blockchain) CreateTransactionWithFee(sender, recipient string, amount, fee Amount) (string, int, error) {
 	tx := Transaction{
 		Sender:    sender,
 		Recipient: recipient,
 		Amount:    amount,
+		Fee:       fee,
+		Timestamp: time.Now().UnixNano(),
+		RefHeight: bc.GetLastBlock().Index,
+	}
+	index, err := bc.AddTransaction(tx)
+	if err != nil {
+		return "", 0, err
+	}
+	return tx.ID(), index, nil
+}
+
+// AddTransaction 校验交易并加入交易池，返回将包含此交易的区块索引
+// 已确认或已在交易池中的交易（交易ID相同）会被拒绝；发送方的余额须足以支付交易池中已有的转出与本交易
+func (bc *Blockchain) AddTransaction(tx Transaction) (int, error) {
+	// 转给自己只会消耗手续费与区块空间，按接收交易的策略拒绝；已上链的此类交易仍然有效
+	if tx.Sender != "" && tx.Sender == tx.Recipient {
+		return 0, fmt.Errorf("%w: %s", ErrSelfTransfer, tx.Sender)
+	}
+	if err := ValidateTransaction(tx, bc.state); err != nil {
+		return 0, err
+	}
+	if tx.Amount < bc.MinTxAmount {
+		return 0, fmt.Errorf("%w: %s 低于最小金额 %s", ErrDustAmount, tx.Amount, bc.MinTxAmount)
+	}
+	if err := bc.validateAddresses(tx); err != nil {
+		return 0, err
+	}
+	if err := bc.validateRecipient(tx); err != nil {
+		return 0, err
+	}
+	id := tx.ID()
+	for _, pending := range bc.Transactions {
+		if pending.ID() == id {
+			return 0, fmt.Errorf("%w: %s 已在交易池中", ErrDuplicateTransaction, id)
+		}
+	}
+	// 交易池中同一发送方的交易尚未确认，但打包时会先于本交易扣款，可用余额须扣除它们
+	if balance, pending := bc.state.Balance(tx.Sender), bc.pendingOutgoing(tx.Sender); pending > balance || !canAfford(balance-pending, tx.Amount, tx.Fee) {
+		return 0, fmt.Errorf("%w: %s 的余额为 %s，交易池中待转出 %s，需要 %s 加手续费 %s",
+			ErrInsufficientBalance, tx.Sender, balance, pending, tx.Amount, tx.Fee)
+	}
+	// 交易池已满时只接受手续费更高的交易，加入后由 setPending 移除手续费最低的交易
+	if bc.MaxMempoolSize > 0 && len(bc.Transactions) >= bc.MaxMempoolSize {
+		if lowest := bc.minPendingFee(); tx.Fee <= lowest {
+			return 0, fmt.Errorf("%w: 已有 %d 笔交易，手续费须高于 %s", ErrMempoolFull, len(bc.Transactions), lowest)
+		}
 	}
 
-	bc.Transactions = append(bc.Transactions, tx)
-	return len(bc.Chain) // 返回将包含此交易的区块索引
+	bc.setPending(append(bc.Transactions, tx))
+	return len(bc.Chain), nil
 }
 
-// Mine 挖矿，创建新区块
-func (bc *Blockchain) Mine(minerAddress string) *Block {
-	// 获取最后一个区块
-	lastBlock := bc.GetLastBlock()
-	lastProof := lastBlock.Proof
+// Mine 挖矿，创建新区块，奖励全部发放给矿工
+// 未找到工作量证明（见 MaxMiningAttempts）时返回 ErrProofNotFound，矿工地址无效等同样返回错误，此时链与交易池保持不变
+func (bc *Blockchain) Mine(minerAddress string) (*Block, error) {
+	return bc.MineWithRewardSplit([]RewardShare{{Address: minerAddress, Fraction: 1}})
+}
+
+// MineWithRewardSplit 挖矿，并按比例将奖励分配给多个地址（矿池分账）
+// 每个接收方生成一笔奖励交易，各比例之和必须为1，返回新区块的副本
+func (bc *Blockchain) MineWithRewardSplit(shares []RewardShare) (*Block, error) {
+	return bc.MineContext(context.Background(), shares)
+}
+
+// MineContext 与 MineWithRewardSplit 相同，但ctx被取消时停止求解并返回 ErrProofNotFound
+func (bc *Blockchain) MineContext(ctx context.Context, shares []RewardShare) (*Block, error) {
+	block, err := bc.newBlockTemplate(shares)
+	if err != nil {
+		return nil, err
+	}
 
 	// 计算工作量证明
-	proof := ProofOfWork(lastProof)
+	opts := bc.miningOptions()
+	opts.Context = ctx
+	if err := SolveBlock(block, bc.PoWAlgorithm, opts); err != nil {
+		return nil, err
+	}
 
-	// 给矿工奖励
-	bc.CreateTransaction("network", minerAddress, 1.0)
+	return bc.appendBlock(block), nil
+}
+
+// newBlockTemplate 用待处理交易和奖励交易构造尚未求解工作量证明的新区块
+// 新区块按难度调整规则得出的难度挖出（见 NextDifficulty）
+func (bc *Blockchain) newBlockTemplate(shares []RewardShare) (*Block, error) {
+	if err := validateRewardShares(shares, bc.AddressFormat); err != nil {
+		return nil, err
+	}
+	lastBlock := bc.GetLastBlock()
+
+	// 等待直到满足最小出块间隔
+	time.Sleep(bc.NextBlockDelay())
+
+	// 按顺序重新校验待处理交易，跳过在当前状态下已无效的交易（如合计超出余额）
+	state := bc.state.Clone()
+	var included []Transaction
+	var fees Amount
+	for _, tx := range bc.blockCandidates() {
+		if bc.MaxBlockTransactions > 0 && len(included) == bc.MaxBlockTransactions {
+			break
+		}
+		if err := ValidateTransaction(tx, state); err != nil {
+			continue
+		}
+		state.applyTransaction(tx)
+		included = append(included, tx)
+		fees += tx.Fee
+	}
+
+	// 给矿工奖励（区块奖励加上打包交易的手续费），奖励交易位于区块开头
+	amounts, err := splitReward(bc.BlockReward(lastBlock.Index+1)+fees, shares)
+	if err != nil {
+		return nil, err
+	}
+	transactions := []Transaction{}
+	for i, share := range shares {
+		if amounts[i] == 0 {
+			continue // 奖励减半到不足一个最小单位的份额没有奖励交易
+		}
+		transactions = append(transactions, newCoinbase(share.Address, amounts[i]))
+	}
+	transactions = append(transactions, included...)
 
-	// 创建新区块
 	block := &Block{
+		Version:      CurrentBlockVersion,
 		Index:        lastBlock.Index + 1,
 		Timestamp:    time.Now().Unix(),
-		Transactions: bc.Transactions,
-		Proof:        proof,
+		Transactions: transactions,
+		Difficulty:   bc.NextDifficulty(),
 		PreviousHash: lastBlock.Hash,
 	}
+	block.MerkleRoot = block.txHash()
+	// 求解之前按校验时的规则检查奖励交易，避免挖出自己的链都无法通过校验的区块
+	if err := validateCoinbase(block, bc.BlockReward(block.Index)); err != nil {
+		return nil, err
+	}
+	return block, nil
+}
 
-	// 计算新区块的哈希
-	block.Hash = block.CalculateHash()
-
-	// 将新区块添加到链上
+// appendBlock 将已求解的区块加入链，更新账户状态并返回区块的副本
+func (bc *Blockchain) appendBlock(block *Block) *Block {
 	bc.Chain = append(bc.Chain, block)
+	bc.state.ApplyBlock(block)
 
-	// 清空待处理交易
-	bc.Transactions = []Transaction{}
+	// 从交易池中移除已打包的交易，以及在新状态下已无效的交易
+	state := bc.state.Clone()
+	pending := []Transaction{}
+	for _, tx := range bc.Transactions {
+		if err := ValidateTransaction(tx, state); err != nil {
+			continue
+		}
+		state.applyTransaction(tx)
+		pending = append(pending, tx)
+	}
+	bc.setPending(pending)
 
-	return block
+	// 归档失败不影响出块，区块留在内存中，下次出块时重试
+	_ = bc.archiveOldBlocks()
+
+	bc.emit(MiningEvent{Type: MiningEventBlock, Block: block.Clone()})
+	return block.Clone()
 }
 
 // IsChainValid 验证区块链是否有效
 func (bc *Blockchain) IsChainValid() bool {
-	for i := 1; i < len(bc.Chain); i++ {
-		currentBlock := bc.Chain[i]
-		previousBlock := bc.Chain[i-1]
+	return bc.IsValidChain(bc.Chain)
+}
+
+// minBlockSpacing 返回相邻区块时间戳之间要求的最小秒数
+// 区块时间戳精确到秒，因此向上取整
+func (bc *Blockchain) minBlockSpacing() int64 {
+	return int64(math.Ceil(bc.MinBlockInterval.Seconds()))
+}
+
+// NextBlockDelay 返回距离允许产生下一个区块还需等待的时间
+func (bc *Blockchain) NextBlockDelay() time.Duration {
+	earliest := time.Unix(bc.GetLastBlock().Timestamp+bc.minBlockSpacing(), 0)
+	if delay := time.Until(earliest); delay > 0 {
+		return delay
+	}
+	return 0
+}
+
+// validateGenesis 验证创世区块：高度为0、前一个区块哈希为"0"且哈希正确
+// 创世区块记录的难度为0时免于工作量证明，这是唯一不需要证明的区块；
+// 难度大于0时按algorithm验证证明，且难度不能低于minDifficulty（即 Blockchain.GenesisDifficulty）
+func validateGenesis(genesis *Block, algorithm string, minDifficulty int) error {
+	if genesis.Index != 0 || genesis.PreviousHash != "0" {
+		return fmt.Errorf("创世区块的高度或前一个区块哈希不正确")
+	}
+	if genesis.Hash != genesis.CalculateHash() {
+		return fmt.Errorf("创世区块哈希不正确")
+	}
+	if err := validateMerkleRoot(genesis); err != nil {
+		return err
+	}
+	if genesis.Difficulty < minDifficulty {
+		return fmt.Errorf("创世区块难度 %d 低于要求的 %d", genesis.Difficulty, minDifficulty)
+	}
+	if genesis.Difficulty > 0 && !genesis.Header().meetsDifficulty(algorithm) {
+		return fmt.Errorf("创世区块工作量证明无效")
+	}
+	return nil
+}
+
+// IsValidChain 按本链的规则（难度、工作量证明算法、奖励等）验证给定的区块序列是否构成有效的区块链
+// 用于在采用对端的链之前校验它，本链自身的校验见 IsChainValid
+func (bc *Blockchain) IsValidChain(chain []*Block) bool {
+	_, err := bc.validateChain(chain)
+	return err == nil
+}
+
+// validateChain 验证给定的区块序列，返回第一个无效区块的位置及原因
+func (bc *Blockchain) validateChain(chain []*Block) (int, error) {
+	for i, block := range chain {
+		if block == nil {
+			return i, fmt.Errorf("区块为空")
+		}
+	}
+	if len(chain) > 0 {
+		if err := validateGenesis(chain[0], bc.PoWAlgorithm, bc.GenesisDifficulty); err != nil {
+			return 0, err
+		}
+	}
+
+	// 从创世区块开始重放交易，每个区块中的普通交易须在前一个区块之后的状态下有效（签名、余额等）；
+	// 交易已归档的区块无法重放，遇到后其余区块只校验哈希、链接与工作量证明
+	var state *State
+	if len(chain) > 0 && !chain[0].isPruned() {
+		state = newStateWithWindow(bc.ReplayWindow)
+		state.ApplyBlock(chain[0])
+	}
+
+	seen := make(map[string]int) // 交易ID -> 所在区块高度
+	for i := 1; i < len(chain); i++ {
+		currentBlock := chain[i]
+		previousBlock := chain[i-1]
 
 		// 验证当前区块的哈希值是否正确
+		if currentBlock.isPruned() && len(currentBlock.Transactions) > 0 {
+			return i, fmt.Errorf("区块同时包含交易与交易列表哈希")
+		}
+		if currentBlock.Index != i {
+			return i, fmt.Errorf("区块高度 %d 不正确", currentBlock.Index)
+		}
+		if !validBlockVersion(currentBlock.Version, previousBlock.Version) {
+			return i, fmt.Errorf("区块格式版本 %d 无效", currentBlock.Version)
+		}
 		if currentBlock.Hash != currentBlock.CalculateHash() {
-			return false
+			return i, fmt.Errorf("区块哈希不正确")
+		}
+		if err := validateMerkleRoot(currentBlock); err != nil {
+			return i, err
+		}
+		if err := validateCoinbase(currentBlock, bc.BlockReward(currentBlock.Index)); err != nil {
+			return i, err
 		}
 
 		// 验证区块的PreviousHash是否等于前一个区块的哈希
 		if currentBlock.PreviousHash != previousBlock.Hash {
-			return false
+			return i, fmt.Errorf("前一个区块的哈希不匹配")
+		}
+
+		// 验证难度：区块记录的难度须等于按难度调整规则由之前的区块得出的难度，
+		// 否则对端可以用极低的难度快速挖出更长的链；迁移而来的旧区块只要求不低于最小难度
+		if currentBlock.Difficulty < MinDifficulty {
+			return i, fmt.Errorf("难度 %d 低于最小难度 %d", currentBlock.Difficulty, MinDifficulty)
+		}
+		if i >= bc.DifficultyHeight {
+			if expected := bc.expectedDifficulty(chain, i); currentBlock.Difficulty != expected {
+				return i, fmt.Errorf("难度 %d 不符合难度调整规则，应为 %d", currentBlock.Difficulty, expected)
+			}
+		}
+
+		// 验证工作量证明（使用区块自身记录的难度，以便难度调整后仍能验证历史区块）
+		if !validBlockProof(currentBlock.Header(), previousBlock.Nonce, bc.PoWAlgorithm, bc.LegacyPoWHeight) {
+			return i, fmt.Errorf("工作量证明无效")
+		}
+
+		// 验证出块间隔
+		if currentBlock.Timestamp-previousBlock.Timestamp < bc.minBlockSpacing() {
+			return i, fmt.Errorf("与前一个区块的间隔小于 %d 秒", bc.minBlockSpacing())
+		}
+
+		// 同一笔普通交易（交易ID相同）在重放保护窗口内只能出现一次，未设置窗口时在整条链上只能出现一次；
+		// 设置窗口时交易还须在有效期内，窗口之外重放的旧交易已过期。交易已归档的区块无法检查
+		for _, tx := range currentBlock.Transactions {
+			if currentBlock.isReward(tx) {
+				continue
+			}
+			if err := validateTxHeight(tx, i, bc.ReplayWindow); err != nil {
+				return i, err
+			}
+			id := tx.ID()
+			if height, ok := seen[id]; ok && (bc.ReplayWindow <= 0 || i-height < bc.ReplayWindow) {
+				return i, fmt.Errorf("%w: %s", ErrDuplicateTransaction, id)
+			}
+			seen[id] = i
+		}
+
+		if currentBlock.isPruned() {
+			state = nil
+		}
+		if state != nil {
+			if err := state.ApplyValidatedBlock(currentBlock); err != nil {
+				return i, err
+			}
+		}
+	}
+	return -1, nil
+}
+
+// ReplaceChain 用累计工作量更大的有效链替换当前链（链重组）
+// 按工作量（见 ChainWork）而不是区块数比较：低难度的长链不能取代工作量更大的短链。
+// 链、账户状态与待处理交易作为一个整体替换：被替换掉的区块中未出现在新链上的交易
+// 会重新放回待处理交易，新链上已确认的交易会从交易池中移除。
+// 校验失败时不修改任何内容，因此可以安全地重试。
+func (bc *Blockchain) ReplaceChain(chain []*Block) error {
+	if err := checkBlocks(chain); err != nil {
+		return err
+	}
+	if chainWork(chain).Cmp(bc.ChainWork()) <= 0 {
+		return ErrChainNotLonger
+	}
+	if !bc.IsValidChain(chain) {
+		return ErrInvalidChain
+	}
+	// 交易已归档的区块无法重建账户状态，只接受包含完整交易的链
+	for _, block := range chain {
+		if block.isPruned() {
+			return fmt.Errorf("%w: 区块 %d 的交易已归档", ErrInvalidChain, block.Index)
 		}
+	}
 
-		// 验证工作量证明
-		if !ValidProof(previousBlock.Proof, currentBlock.Proof) {
-			return false
+	// 收集新链上已确认的交易
+	confirmed := make(map[string]bool)
+	for _, block := range chain {
+		for _, tx := range block.Transactions {
+			confirmed[tx.ID()] = true
 		}
 	}
-	return true
+
+	// 找出孤立区块中的交易，与原有待处理交易一起重新放入交易池
+	pending := []Transaction{}
+	seen := make(map[string]bool)
+	requeue := func(tx Transaction) {
+		id := tx.ID()
+		if confirmed[id] || seen[id] {
+			return
+		}
+		seen[id] = true
+		pending = append(pending, tx)
+	}
+	for _, block := range bc.Chain {
+		for _, tx := range block.Transactions {
+			if !block.isReward(tx) {
+				requeue(tx)
+			}
+		}
+	}
+	for _, tx := range bc.Transactions {
+		requeue(tx)
+	}
+
+	state := stateFromChain(chain, bc.ReplayWindow)
+
+	bc.Chain = chain
+	bc.setPending(pending)
+	bc.state = state
+	bc.emit(MiningEvent{Type: MiningEventChainReplaced})
+	return nil
 }
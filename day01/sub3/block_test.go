@@ -0,0 +1,250 @@
+package main
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestReplaceChainRequiresMoreWork(t *testing.T) {
+	local := newConsensusChain(t)
+	genesis := local.GetLastBlock().Timestamp
+	mineAt(t, local, "alice", genesis)
+	mineAt(t, local, "alice", genesis)
+
+	remote := newConsensusChain(t)
+	for i := 0; i < 6; i++ {
+		mineAt(t, remote, "mallory", remote.GetLastBlock().Timestamp+100)
+	}
+
+	if err := local.ReplaceChain(remote.GetChain()); !errors.Is(err, ErrChainNotLonger) {
+		t.Fatalf("工作量更小的更长的链返回 %v，应为 ErrChainNotLonger", err)
+	}
+	if err := remote.ReplaceChain(local.GetChain()); err != nil {
+		t.Fatalf("工作量更大的更短的链应被采用: %v", err)
+	}
+	if remote.GetLastBlock().Hash != local.GetLastBlock().Hash {
+		t.Fatal("替换后的链尾应与采用的链一致")
+	}
+}
+
+func TestProofOfWorkCommitsToBlockContents(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(b *Block)
+	}{
+		{"transaction", func(b *Block) { b.Transactions[1].Amount++ }},
+		{"previous hash", func(b *Block) { b.PreviousHash = b.Hash }},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// 16比特难度下篡改后的哈希恰好满足难度的概率可以忽略
+			bc := NewBlockchainWithDifficulty(16)
+			mustMine(t, bc, "alice")
+			mustAddTransaction(t, bc, transfer(bc, "alice", "bob", 1))
+			mustMine(t, bc, "miner")
+
+			block := bc.Chain[2]
+			tt.tamper(block)
+			if bc.IsChainValid() {
+				t.Fatal("篡改后哈希不再匹配，链应无效")
+			}
+
+			// 重新计算Merkle根与哈希也无济于事：原有的证明不再满足难度
+			block.MerkleRoot = block.txHash()
+			block.Hash = block.CalculateHash()
+			if block.Header().meetsDifficulty(bc.PoWAlgorithm) {
+				t.Fatal("篡改后的区块不应仍满足难度")
+			}
+			if index, err := bc.validateChain(bc.Chain); err == nil || index != 2 {
+				t.Fatalf("校验结果为 区块 %d: %v，应拒绝区块 2", index, err)
+			}
+		})
+	}
+}
+
+func TestMaxBlockTransactionsSplitsMempool(t *testing.T) {
+	bc := newTestChain(t)
+	bc.MaxBlockTransactions = 2
+	mustMine(t, bc, "alice")
+	for _, recipient := range []string{"bob", "carol", "dave"} {
+		mustAddTransaction(t, bc, transfer(bc, "alice", recipient, 1))
+	}
+
+	first := mustMine(t, bc, "miner")
+	if got := len(first.Transactions); got != 3 {
+		t.Fatalf("第一个区块有 %d 笔交易，应为奖励加上 2 笔", got)
+	}
+	if !first.Transactions[0].Coinbase {
+		t.Fatal("奖励交易应始终位于区块开头")
+	}
+	if got := len(bc.Transactions); got != 1 {
+		t.Fatalf("交易池剩余 %d 笔交易，应为 1", got)
+	}
+
+	second := mustMine(t, bc, "miner")
+	if got := len(second.Transactions); got != 2 {
+		t.Fatalf("第二个区块有 %d 笔交易，应为奖励加上剩余的 1 笔", got)
+	}
+	if len(bc.Transactions) != 0 {
+		t.Fatalf("交易池应已清空，剩余 %d 笔", len(bc.Transactions))
+	}
+	if !bc.IsChainValid() {
+		t.Fatal("分两次打包的链应有效")
+	}
+}
+
+func TestBlockCloneCopiesSignatures(t *testing.T) {
+	block := &Block{Transactions: []Transaction{{
+		Sender:    "alice",
+		Recipient: "bob",
+		Amount:    1,
+		PublicKey: []byte{1, 2, 3},
+		Signature: []byte{4, 5, 6},
+	}}}
+
+	clone := block.Clone()
+	clone.Transactions[0].PublicKey[0] = 9
+	clone.Transactions[0].Signature[0] = 9
+	if tx := block.Transactions[0]; tx.PublicKey[0] != 1 || tx.Signature[0] != 4 {
+		t.Fatal("修改副本的公钥或签名不应影响原区块")
+	}
+}
+
+func TestReturnedBlocksDoNotAliasChain(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	tx := transfer(bc, "alice", "bob", 1)
+	tx.PublicKey, tx.Signature = []byte{1}, []byte{2} // 未验证的字段，只用于检查是否共享底层数组
+	bc.Chain[1].Transactions = append(bc.Chain[1].Transactions, tx)
+	want, err := bc.Chain[1].ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	mutate := func(b *Block) {
+		b.Hash = "tampered"
+		b.Transactions[0].Amount = 100 * Coin
+		b.Transactions[1].Signature[0] = 9
+		b.Transactions = append(b.Transactions[:0], b.Transactions[1:]...)
+	}
+	mutate(bc.GetChain()[1])
+	mutate(bc.GetChainAsOf(time.Now())[1])
+	block, err := bc.GetBlockByIndex(1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	mutate(block)
+	if block, err = bc.GetBlockByHash(bc.Chain[1].Hash); err != nil {
+		t.Fatal(err)
+	}
+	mutate(block)
+
+	found, _, err := bc.FindTransaction(tx.ID())
+	if err != nil {
+		t.Fatal(err)
+	}
+	found.Amount = 100 * Coin
+	found.PublicKey[0] = 9
+	for _, tx := range bc.GetAddressTransactions("bob") {
+		tx.Signature[0] = 9
+	}
+
+	if got, _ := bc.Chain[1].ToJSON(); got != want {
+		t.Fatalf("修改返回的区块或交易改变了链上的区块:\n%s\n应为:\n%s", got, want)
+	}
+}
+
+func TestReturnedPendingTransactionsDoNotAliasMempool(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	mustAddTransaction(t, bc, transfer(bc, "alice", "bob", 1))
+
+	pending := bc.GetPendingTransactions()
+	pending[0].Amount = 100 * Coin
+	if bc.Transactions[0].Amount != 1 {
+		t.Fatal("修改返回的待处理交易不应影响交易池")
+	}
+}
+
+func TestGenesisExemptFromProofOfWork(t *testing.T) {
+	bc := newTestChain(t)
+	if genesis := bc.Chain[0]; genesis.Difficulty != 0 {
+		t.Fatalf("未设置 GenesisDifficulty 时创世区块难度为 %d，应为0（免于工作量证明）", genesis.Difficulty)
+	}
+	mustMine(t, bc, "alice")
+	if !bc.IsChainValid() {
+		t.Fatal("创世区块免于工作量证明的链应有效")
+	}
+}
+
+func TestGenesisMinedAtGenesisDifficulty(t *testing.T) {
+	bc := newTestChain(t)
+	bc.GenesisDifficulty = 8
+	if err := bc.CreateGenesisBlock(); err != nil {
+		t.Fatal(err)
+	}
+	genesis := bc.Chain[0]
+	if genesis.Difficulty != 8 || !genesis.Header().meetsDifficulty(bc.PoWAlgorithm) {
+		t.Fatalf("创世区块难度为 %d，应按8比特难度挖出", genesis.Difficulty)
+	}
+	mustMine(t, bc, "alice")
+	if !bc.IsChainValid() {
+		t.Fatal("按 GenesisDifficulty 挖出创世区块的链应有效")
+	}
+
+	// 要求创世区块工作量的链不接受免于工作量证明的创世区块
+	if exempt := newTestChain(t); bc.IsValidChain(exempt.Chain) {
+		t.Fatal("创世区块难度低于 GenesisDifficulty 的链应无效")
+	}
+	// 工作量证明无效的创世区块
+	tampered := copyChain(t, bc).Chain[0]
+	for tampered.Header().meetsDifficulty(bc.PoWAlgorithm) {
+		tampered.Nonce++
+		tampered.Hash = tampered.CalculateHash()
+	}
+	if index, err := bc.validateChain([]*Block{tampered}); err == nil || index != 0 {
+		t.Fatalf("校验结果为 区块 %d: %v，应拒绝工作量证明无效的创世区块", index, err)
+	}
+}
+
+func TestCreateGenesisBlockRequiresEmptyChain(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	bc.GenesisDifficulty = 8
+	if err := bc.CreateGenesisBlock(); err == nil {
+		t.Fatal("链上已有区块时不应重新创建创世区块")
+	}
+	if bc.Chain[0].Difficulty != 0 || len(bc.Chain) != 2 {
+		t.Fatal("重新创建失败时链应保持不变")
+	}
+}
+
+func TestValidateChainRequiresBlockHashToMeetDifficulty(t *testing.T) {
+	bc := NewBlockchainWithDifficulty(8)
+	block, err := bc.newBlockTemplate([]RewardShare{{Address: "mallory", Fraction: 1}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 找一个按旧规则（只对前后两个证明求哈希）有效、但区块哈希本身不满足难度的证明
+	previous := bc.GetLastBlock().Nonce
+	for block.Nonce = 0; ; block.Nonce++ {
+		if ValidProof(previous, block.Nonce, block.Difficulty, bc.PoWAlgorithm) && !block.Header().meetsDifficulty(bc.PoWAlgorithm) {
+			break
+		}
+	}
+	block.Hash = block.CalculateHash()
+	bc.appendBlock(block)
+
+	index, err := bc.validateChain(bc.Chain)
+	if err == nil || index != 1 || !strings.Contains(err.Error(), "工作量证明无效") {
+		t.Fatalf("校验结果为 区块 %d: %v，应拒绝哈希不满足难度的区块 1", index, err)
+	}
+
+	// 迁移而来的旧区块仍按旧规则验证
+	bc.LegacyPoWHeight = 2
+	if !bc.IsChainValid() {
+		t.Fatal("LegacyPoWHeight 之前的区块应按旧规则通过验证")
+	}
+}
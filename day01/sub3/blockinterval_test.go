@@ -0,0 +1,86 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newSpacedChain 创建相邻区块至少间隔interval的区块链，创世时间提前一小时，可以用过去的时间戳挖矿
+func newSpacedChain(t *testing.T, interval time.Duration) *Blockchain {
+	t.Helper()
+	bc := newTestChain(t)
+	bc.MinBlockInterval = interval
+	backdateGenesis(t, bc, time.Hour)
+	return bc
+}
+
+func TestMineWaitsForMinBlockInterval(t *testing.T) {
+	bc := newTestChain(t)
+	bc.MinBlockInterval = time.Second
+	first := mustMine(t, bc, "miner")
+
+	if delay := bc.NextBlockDelay(); delay <= 0 || delay > time.Second {
+		t.Fatalf("刚出块后 NextBlockDelay 为 %s，应在 (0, 1s] 之内", delay)
+	}
+	second := mustMine(t, bc, "miner")
+	if spacing := second.Timestamp - first.Timestamp; spacing < 1 {
+		t.Fatalf("相邻区块间隔 %d 秒，应至少 1 秒", spacing)
+	}
+	if !bc.IsChainValid() {
+		t.Fatal("按最小间隔挖出的链应有效")
+	}
+}
+
+func TestValidateChainRejectsBlocksTooClose(t *testing.T) {
+	tests := []struct {
+		name     string
+		interval time.Duration
+		spacing  int64
+		valid    bool
+	}{
+		{"exactly the interval", 10 * time.Second, 10, true},
+		{"one second short", 10 * time.Second, 9, false},
+		// 不足一秒的部分向上取整
+		{"fractional interval", 1500 * time.Millisecond, 1, false},
+		{"fractional interval rounded up", 1500 * time.Millisecond, 2, true},
+		{"no limit", 0, 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := newSpacedChain(t, tt.interval)
+			mineSpaced(t, bc, 1, tt.spacing)
+
+			index, err := bc.validateChain(bc.Chain)
+			if tt.valid {
+				if err != nil {
+					t.Fatalf("间隔 %d 秒的链被拒绝: 区块 %d: %v", tt.spacing, index, err)
+				}
+				return
+			}
+			if index != 1 || err == nil || !strings.Contains(err.Error(), "间隔") {
+				t.Fatalf("校验结果为 区块 %d: %v，应以间隔过小拒绝区块 1", index, err)
+			}
+		})
+	}
+}
+
+func TestMineEndpointRejectsTooSoon(t *testing.T) {
+	bc := newSpacedChain(t, time.Minute)
+	mustMine(t, bc, "miner")
+	n := newTestNetwork(t, bc)
+
+	rec := httptest.NewRecorder()
+	n.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/mine", nil))
+	if rec.Code != http.StatusTooManyRequests || !strings.Contains(rec.Body.String(), "too_soon_to_mine") {
+		t.Fatalf("/mine 返回 %d %s，应为 429 too_soon_to_mine", rec.Code, rec.Body)
+	}
+	if retry := rec.Header().Get("Retry-After"); retry == "" || retry == "0" {
+		t.Fatalf("Retry-After 为 %q，应给出需等待的秒数", retry)
+	}
+	if length := len(bc.Chain); length != 2 {
+		t.Fatalf("链长为 %d，过早的 /mine 不应出块", length)
+	}
+}
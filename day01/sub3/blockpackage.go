@@ -0,0 +1,95 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+)
+
+// BlockPackageVersion 区块包的格式版本
+const BlockPackageVersion = 1
+
+// BlockPackage 可独立验证的单个区块：完整区块、交易的Merkle根，以及从创世区块到它的区块头序列
+// 持有者无需整条区块链即可证明"高度N、包含这些交易的区块按此难度挖出"；
+// 验证者只需信任创世区块哈希（GenesisHash），高度由区块头序列的连续性保证
+type BlockPackage struct {
+	Version         int           `json:"version"`
+	PoWAlgorithm    string        `json:"pow_algorithm,omitempty"`
+	LegacyPoWHeight int           `json:"legacy_pow_height,omitempty"`
+	GenesisHash     string        `json:"genesis_hash"`
+	Block           *Block        `json:"block"`   // 完整区块（含交易）
+	TxRoot          string        `json:"tx_root"` // 以交易ID为叶子的Merkle根，可用于单笔交易的包含证明
+	Headers         []BlockHeader `json:"headers"` // 创世区块到前一个区块的区块头，按高度排列
+}
+
+// ExportBlockPackage 导出指定高度的区块包（JSON），已归档的区块从归档存储取回完整交易
+func (bc *Blockchain) ExportBlockPackage(index int) ([]byte, error) {
+	block, err := bc.LoadArchivedBlock(index)
+	if err != nil {
+		return nil, err
+	}
+
+	headers := make([]BlockHeader, 0, index)
+	for _, b := range bc.Chain[:index] {
+		headers = append(headers, b.Header())
+	}
+
+	pkg := BlockPackage{
+		Version:         BlockPackageVersion,
+		PoWAlgorithm:    bc.PoWAlgorithm,
+		LegacyPoWHeight: bc.LegacyPoWHeight,
+		GenesisHash:     bc.Chain[0].Hash,
+		Block:           block,
+		TxRoot:          txMerkleRoot(block.Transactions),
+		Headers:         headers,
+	}
+	return json.MarshalIndent(pkg, "", "  ")
+}
+
+// VerifyBlockPackage 解析并验证区块包，返回其内容
+// 校验区块哈希覆盖包中的交易、Merkle根正确、区块头序列从创世区块开始依次相连且工作量证明有效，
+// 区块本身满足其记录的难度。调用方还应将 GenesisHash 与自己信任的创世区块哈希比较
+func VerifyBlockPackage(data []byte) (*BlockPackage, error) {
+	var pkg BlockPackage
+	if err := json.Unmarshal(data, &pkg); err != nil {
+		return nil, fmt.Errorf("解析区块包失败: %v", err)
+	}
+	if pkg.Version != BlockPackageVersion {
+		return nil, fmt.Errorf("%w: 区块包版本 %d", ErrUnsupportedVersion, pkg.Version)
+	}
+	if pkg.Block == nil {
+		return nil, fmt.Errorf("区块包缺少区块")
+	}
+
+	block := pkg.Block
+	if block.isPruned() {
+		return nil, fmt.Errorf("区块包中的区块缺少交易")
+	}
+	if block.Hash != block.CalculateHash() {
+		return nil, fmt.Errorf("区块哈希与交易不匹配")
+	}
+	if pkg.TxRoot != txMerkleRoot(block.Transactions) {
+		return nil, fmt.Errorf("交易的Merkle根不正确")
+	}
+	if len(pkg.Headers) != block.Index {
+		return nil, fmt.Errorf("区块头数 %d 与区块高度 %d 不符", len(pkg.Headers), block.Index)
+	}
+
+	// 区块自身作为路径的最后一个区块头，与之前的区块头一起校验
+	path := append(append([]BlockHeader{}, pkg.Headers...), block.Header())
+	genesis := path[0]
+	if genesis.Index != 0 || genesis.PreviousHash != "0" || genesis.Hash != pkg.GenesisHash {
+		return nil, fmt.Errorf("区块头序列不是从创世区块 %s 开始", pkg.GenesisHash)
+	}
+	if err := VerifyBlockPath(path, pkg.PoWAlgorithm, pkg.LegacyPoWHeight); err != nil {
+		return nil, err
+	}
+	if genesis.Difficulty > 0 && !genesis.meetsDifficulty(pkg.PoWAlgorithm) {
+		return nil, fmt.Errorf("创世区块工作量证明无效")
+	}
+	return &pkg, nil
+}
+
+// txMerkleRoot 以交易ID为叶子计算交易列表的Merkle根
+func txMerkleRoot(txs []Transaction) string {
+	return merkleRoot(txIDs(txs))
+}
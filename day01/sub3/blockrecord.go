@@ -0,0 +1,55 @@
+package main
+
+import (
+	"encoding/binary"
+	"strconv"
+)
+
+const (
+	// BlockVersionLegacy 区块哈希的字段以十进制字符串直接拼接
+	// 这种编码有歧义（如高度"12"+时间戳"3..."与高度"1"+时间戳"23..."相同），仅用于验证历史区块
+	BlockVersionLegacy = 0
+	// BlockVersionBinary 区块哈希的字段以定长整数（大端序）与带长度前缀的字符串编码，不存在歧义
+	BlockVersionBinary = 1
+	// BlockVersionCoinbase 编码与 BlockVersionBinary 相同，挖矿奖励改为区块开头带 Coinbase 标记的交易（见 validateCoinbase）
+	BlockVersionCoinbase = 2
+	// BlockVersionMerkle 区块哈希中的交易列表哈希改为以交易ID为叶子的Merkle根（见 BuildMerkleTree），
+	// 可以为单笔交易提供包含证明；更早的区块沿用交易ID直接拼接后的哈希
+	BlockVersionMerkle = 3
+	// BlockVersionFees 挖矿奖励交易的合计为区块奖励加上区块内普通交易的手续费，更早的区块中手续费被销毁
+	BlockVersionFees = 4
+
+	// CurrentBlockVersion 新区块使用的格式版本
+	CurrentBlockVersion = BlockVersionFees
+)
+
+// appendBlockRecord 将参与区块哈希计算的字段按区块格式版本编码后追加到dst
+// 证明位于记录中间，挖矿时只需替换证明部分即可重新计算
+func appendBlockRecord(dst []byte, version, index int, timestamp int64, txHash string, proof int64, previousHash string) []byte {
+	if version == BlockVersionLegacy {
+		dst = strconv.AppendInt(dst, int64(index), 10)
+		dst = strconv.AppendInt(dst, timestamp, 10)
+		dst = append(dst, txHash...)
+		dst = strconv.AppendInt(dst, proof, 10)
+		return append(dst, previousHash...)
+	}
+
+	dst = binary.BigEndian.AppendUint32(dst, uint32(version))
+	dst = binary.BigEndian.AppendUint64(dst, uint64(index))
+	dst = binary.BigEndian.AppendUint64(dst, uint64(timestamp))
+	dst = appendLengthPrefixed(dst, txHash)
+	dst = binary.BigEndian.AppendUint64(dst, uint64(proof))
+	return appendLengthPrefixed(dst, previousHash)
+}
+
+// validBlockVersion 判断区块格式版本是否有效：必须是已知版本，且不低于前一个区块的版本，
+// 防止在已使用二进制编码的链上再追加有歧义编码的区块
+func validBlockVersion(version, previousVersion int) bool {
+	return version >= previousVersion && version <= CurrentBlockVersion
+}
+
+// appendLengthPrefixed 追加4字节长度（大端序）与字符串内容
+func appendLengthPrefixed(dst []byte, s string) []byte {
+	dst = binary.BigEndian.AppendUint32(dst, uint32(len(s)))
+	return append(dst, s...)
+}
@@ -0,0 +1,60 @@
+package main
+
+import (
+	"bytes"
+	"testing"
+)
+
+func TestBinaryBlockRecordAvoidsCollisions(t *testing.T) {
+	type fields struct {
+		index        int
+		timestamp    int64
+		txHash       string
+		proof        int64
+		previousHash string
+	}
+	tests := []struct {
+		name string
+		a, b fields
+	}{
+		// 高度 "12"+时间戳 "3456" 与高度 "1"+时间戳 "23456" 拼接后相同
+		{"index and timestamp", fields{12, 3456, "tx", 7, "prev"}, fields{1, 23456, "tx", 7, "prev"}},
+		// 交易哈希 "ab1"+证明 "23" 与交易哈希 "ab"+证明 "123" 拼接后相同
+		{"tx hash and proof", fields{1, 2, "ab1", 23, "prev"}, fields{1, 2, "ab", 123, "prev"}},
+		// 证明 "12"+前一个区块哈希 "3prev" 与证明 "123"+前一个区块哈希 "prev" 拼接后相同
+		{"proof and previous hash", fields{1, 2, "tx", 12, "3prev"}, fields{1, 2, "tx", 123, "prev"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			record := func(version int, f fields) []byte {
+				return appendBlockRecord(nil, version, f.index, f.timestamp, f.txHash, f.proof, f.previousHash)
+			}
+			if !bytes.Equal(record(BlockVersionLegacy, tt.a), record(BlockVersionLegacy, tt.b)) {
+				t.Fatal("旧编码下两组字段应拼接成相同的记录")
+			}
+			hash := func(version int, f fields) string {
+				return blockHash(version, f.index, f.timestamp, f.txHash, f.proof, f.previousHash)
+			}
+			if hash(BlockVersionLegacy, tt.a) != hash(BlockVersionLegacy, tt.b) {
+				t.Fatal("旧编码下两组字段的哈希应相同")
+			}
+			for _, version := range []int{BlockVersionBinary, CurrentBlockVersion} {
+				if hash(version, tt.a) == hash(version, tt.b) {
+					t.Fatalf("版本 %d 的二进制编码下两组字段的哈希应不同", version)
+				}
+			}
+		})
+	}
+}
+
+func TestValidBlockVersionForbidsDowngrade(t *testing.T) {
+	if validBlockVersion(BlockVersionLegacy, BlockVersionBinary) {
+		t.Fatal("二进制编码的区块之后不能再出现有歧义编码的区块")
+	}
+	if !validBlockVersion(CurrentBlockVersion, BlockVersionLegacy) {
+		t.Fatal("应允许从旧版本升级到当前版本")
+	}
+	if validBlockVersion(CurrentBlockVersion+1, CurrentBlockVersion) {
+		t.Fatal("未知的区块版本应无效")
+	}
+}
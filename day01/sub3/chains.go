@@ -0,0 +1,121 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"sort"
+	"sync"
+)
+
+// DefaultChainName 默认区块链的名称，不带 /chains/{name} 前缀的接口都作用于它
+const DefaultChainName = "main"
+
+// namedChain 网络中的一条命名区块链
+// 每条链有独立的锁、交易池与分步挖矿进度，不同链上的请求互不阻塞
+type namedChain struct {
+	name       string
+	blockchain *Blockchain
+	sync.RWMutex
+
+	stepSession *mineStepSession // 分步挖矿的进度
+}
+
+// AddChain 以给定名称加入一条区块链
+func (n *Network) AddChain(name string, bc *Blockchain) error {
+	if name == "" {
+		return fmt.Errorf("区块链名称不能为空")
+	}
+
+	n.Lock()
+	defer n.Unlock()
+
+	if _, exists := n.chains[name]; exists {
+		return fmt.Errorf("区块链 %s 已存在", name)
+	}
+	bc.publish = func(event MiningEvent) {
+		event.Chain = name
+		n.miningEvents.publish(event)
+	}
+	n.chains[name] = &namedChain{name: name, blockchain: bc}
+	return nil
+}
+
+// SetBlockchain 用给定的区块链替换已有的同名区块链（如启动时从文件加载的默认链），名称不存在时返回错误
+// 替换后分步挖矿的进度重新开始，应在启动HTTP服务之前调用
+func (n *Network) SetBlockchain(name string, bc *Blockchain) error {
+	n.Lock()
+	defer n.Unlock()
+
+	c, exists := n.chains[name]
+	if !exists {
+		return fmt.Errorf("区块链 %s 不存在", name)
+	}
+	bc.publish = c.blockchain.publish
+	n.chains[name] = &namedChain{name: name, blockchain: bc}
+	return nil
+}
+
+// Blockchain 返回指定名称的区块链，不存在时返回nil
+func (n *Network) Blockchain(name string) *Blockchain {
+	if c := n.chain(name); c != nil {
+		return c.blockchain
+	}
+	return nil
+}
+
+// SaveChain 在持有该链的读锁时把指定名称的区块链保存到其 Store（见 Blockchain.Save），返回保存的区块数
+func (n *Network) SaveChain(name string) (int, error) {
+	c := n.chain(name)
+	if c == nil {
+		return 0, fmt.Errorf("区块链 %s 不存在", name)
+	}
+	c.RLock()
+	defer c.RUnlock()
+
+	if err := c.blockchain.Save(); err != nil {
+		return 0, err
+	}
+	return len(c.blockchain.Chain), nil
+}
+
+// ChainNames 返回所有区块链的名称（已排序）
+func (n *Network) ChainNames() []string {
+	n.RLock()
+	defer n.RUnlock()
+
+	names := make([]string, 0, len(n.chains))
+	for name := range n.chains {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// chain 查找指定名称的区块链
+func (n *Network) chain(name string) *namedChain {
+	n.RLock()
+	defer n.RUnlock()
+
+	return n.chains[name]
+}
+
+// handleChain 注册作用于单条区块链的接口
+// 同时注册默认链的原有路径（如 /chain）与带链名的路径（如 /chains/{chain}/chain）
+func (n *Network) handleChain(pattern string, handler func(http.ResponseWriter, *http.Request, *namedChain)) {
+	serve := func(w http.ResponseWriter, r *http.Request) {
+		name := r.PathValue("chain")
+		if name == "" {
+			name = DefaultChainName
+		}
+
+		c := n.chain(name)
+		if c == nil {
+			writeError(w, http.StatusNotFound, fmt.Errorf("%w: %s", errUnknownChain, name))
+			return
+		}
+		handler(w, r, c)
+	}
+
+	n.mux.HandleFunc(pattern, serve)
+	n.mux.HandleFunc("/chains/{chain}"+pattern, serve)
+}
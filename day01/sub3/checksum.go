@@ -0,0 +1,88 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// ErrChecksumMismatch 文件内容与配套的 .sha256 校验和不一致（磁盘损坏或被篡改）
+var ErrChecksumMismatch = errors.New("校验和不匹配")
+
+// checksumPath 返回文件配套的校验和文件路径
+func checksumPath(path string) string {
+	return path + ".sha256"
+}
+
+// FileChecksum 计算文件内容的SHA-256（十六进制）
+func FileChecksum(path string) (string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	h := sha256.New()
+	if _, err := io.Copy(h, f); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+// WriteChecksum 计算文件的SHA-256并写入配套的 .sha256 文件
+// 格式与 sha256sum 相同（"校验和  文件名"），可以用 sha256sum -c 检查；
+// 先写临时文件再重命名，中途失败不会留下不完整的校验和
+func WriteChecksum(path string) error {
+	sum, err := FileChecksum(path)
+	if err != nil {
+		return fmt.Errorf("计算校验和失败: %v", err)
+	}
+	return writeChecksumFile(path, sum)
+}
+
+// writeChecksumFile 把已计算的校验和写入path配套的 .sha256 文件，先写临时文件再重命名
+// 调用方可以在数据文件就位之前写入校验和（见 SaveToFile）
+func writeChecksumFile(path, sum string) error {
+	target := checksumPath(path)
+	tmp := target + ".tmp"
+	line := fmt.Sprintf("%s  %s\n", sum, filepath.Base(path))
+	if err := os.WriteFile(tmp, []byte(line), 0644); err != nil {
+		return fmt.Errorf("写入校验和失败: %v", err)
+	}
+	if err := os.Rename(tmp, target); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入校验和失败: %v", err)
+	}
+	return nil
+}
+
+// VerifyChecksum 用配套的 .sha256 文件校验文件内容
+// 校验和文件不存在时（旧版本写入的文件）返回 false 与 nil，表示未经校验；
+// 内容不一致时返回 ErrChecksumMismatch
+func VerifyChecksum(path string) (bool, error) {
+	data, err := os.ReadFile(checksumPath(path))
+	if errors.Is(err, os.ErrNotExist) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("读取校验和失败: %v", err)
+	}
+
+	fields := strings.Fields(string(data))
+	if len(fields) == 0 {
+		return false, fmt.Errorf("%w: %s 为空", ErrChecksumMismatch, checksumPath(path))
+	}
+	sum, err := FileChecksum(path)
+	if err != nil {
+		return false, fmt.Errorf("计算校验和失败: %v", err)
+	}
+	if !strings.EqualFold(fields[0], sum) {
+		return false, fmt.Errorf("%w: %s 应为 %s，实际为 %s", ErrChecksumMismatch, path, fields[0], sum)
+	}
+	return true, nil
+}
@@ -0,0 +1,152 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+)
+
+// clientTimeout 客户端请求的默认超时时间
+const clientTimeout = 10 * time.Second
+
+// Client 是访问节点HTTP接口的客户端
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+}
+
+// NewClient 创建访问指定节点的客户端，baseURL 如 "http://localhost:5000"
+// 省略协议时默认使用 http
+func NewClient(baseURL string) *Client {
+	if !strings.Contains(baseURL, "://") {
+		baseURL = "http://" + baseURL
+	}
+	return &Client{
+		baseURL:    strings.TrimRight(baseURL, "/"),
+		httpClient: &http.Client{Timeout: clientTimeout},
+	}
+}
+
+// SubmitTransaction 向节点提交交易，返回交易ID
+func (c *Client) SubmitTransaction(tx Transaction) (string, error) {
+	var resp struct {
+		TxID string `json:"txid"`
+	}
+	if err := c.do(http.MethodPost, "/transactions/new", tx, &resp); err != nil {
+		return "", err
+	}
+	return resp.TxID, nil
+}
+
+// EstimateFees 获取节点根据交易池给出的建议手续费
+func (c *Client) EstimateFees() (FeeEstimate, error) {
+	var estimate FeeEstimate
+	err := c.do(http.MethodGet, "/fees/estimate", nil, &estimate)
+	return estimate, err
+}
+
+// GetChain 获取节点的完整区块链
+func (c *Client) GetChain() ([]*Block, error) {
+	var resp struct {
+		Chain []*Block `json:"chain"`
+	}
+	if err := c.do(http.MethodGet, "/chain", nil, &resp); err != nil {
+		return nil, err
+	}
+	if err := checkBlocks(resp.Chain); err != nil {
+		return nil, err
+	}
+	return resp.Chain, nil
+}
+
+// GetBalance 查询地址的当前余额
+func (c *Client) GetBalance(address string) (Amount, error) {
+	var resp struct {
+		Balance Amount `json:"balance"`
+	}
+	path := "/address/" + url.PathEscape(address) + "/balance"
+	if err := c.do(http.MethodGet, path, nil, &resp); err != nil {
+		return 0, err
+	}
+	return resp.Balance, nil
+}
+
+// Mine 请求节点挖出一个新区块
+func (c *Client) Mine() (*Block, error) {
+	var resp struct {
+		Block *Block `json:"block"`
+	}
+	if err := c.do(http.MethodGet, "/mine", nil, &resp); err != nil {
+		return nil, err
+	}
+	return resp.Block, nil
+}
+
+// RegisterNode 向节点注册另一个节点
+func (c *Client) RegisterNode(nodeID, address string) error {
+	body := struct {
+		NodeID  string `json:"node_id"`
+		Address string `json:"address"`
+	}{
+		NodeID:  nodeID,
+		Address: address,
+	}
+	return c.do(http.MethodPost, "/nodes/register", body, nil)
+}
+
+// do 发送请求并解析JSON响应，非2xx响应会解析为错误
+func (c *Client) do(method, path string, body, out interface{}) error {
+	var reader io.Reader
+	if body != nil {
+		data, err := json.Marshal(body)
+		if err != nil {
+			return fmt.Errorf("编码请求失败: %v", err)
+		}
+		reader = bytes.NewReader(data)
+	}
+
+	req, err := http.NewRequest(method, c.baseURL+path, reader)
+	if err != nil {
+		return fmt.Errorf("创建请求失败: %v", err)
+	}
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("请求 %s 失败: %v", path, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("请求 %s 失败 (%s): %s", path, resp.Status, readErrorMessage(resp.Body))
+	}
+
+	if out == nil {
+		return nil
+	}
+	if err := json.NewDecoder(resp.Body).Decode(out); err != nil {
+		return fmt.Errorf("解析响应失败: %v", err)
+	}
+	return nil
+}
+
+// readErrorMessage 从错误响应中提取错误信息
+// 兼容 {"error": "..."} 形式的JSON与纯文本
+func readErrorMessage(r io.Reader) string {
+	data, _ := io.ReadAll(io.LimitReader(r, 4096))
+
+	var errResp struct {
+		Error string `json:"error"`
+	}
+	if json.Unmarshal(data, &errResp) == nil && errResp.Error != "" {
+		return errResp.Error
+	}
+	return strings.TrimSpace(string(data))
+}
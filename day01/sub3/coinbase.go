@@ -0,0 +1,80 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// legacyRewardSender BlockVersionCoinbase 之前的区块中挖矿奖励交易的发送方
+// 它只是普通字符串，可能与真实地址冲突，新区块改用 Transaction.Coinbase 标记奖励
+const legacyRewardSender = "network"
+
+var (
+	// ErrInvalidCoinbase 区块的挖矿奖励交易缺失、位置不对或金额不正确
+	ErrInvalidCoinbase = errors.New("挖矿奖励交易无效")
+	// ErrUnexpectedCoinbase 挖矿奖励交易只能由矿工放入区块，不能作为普通交易提交
+	ErrUnexpectedCoinbase = errors.New("不能提交挖矿奖励交易")
+)
+
+// newCoinbase 创建发放给recipient的挖矿奖励交易，奖励没有发送方
+func newCoinbase(recipient string, amount Amount) Transaction {
+	return Transaction{Recipient: recipient, Amount: amount, Coinbase: true}
+}
+
+// isReward 判断交易是否为该区块的挖矿奖励
+// BlockVersionCoinbase 及之后的区块只认 Coinbase 标记；更早的区块沿用发送方为 legacyRewardSender 的约定，仅用于验证历史区块
+func (b *Block) isReward(tx Transaction) bool {
+	if b.Version < BlockVersionCoinbase {
+		return tx.Sender == legacyRewardSender
+	}
+	return tx.Coinbase
+}
+
+// validateCoinbase 校验区块的挖矿奖励：BlockVersionCoinbase 及之后的区块必须以奖励交易开头，
+// 通常只有一笔；按比例分配奖励（矿池分账）时每个接收方一笔，同一接收方不能出现两次。
+// 交易只有一个接收方，矿池分账只能表示为多笔奖励交易，因此不要求恰好一笔：不分账的区块（见 Mine）
+// 只有一笔，多出的奖励交易只能分走同一份奖励，合计不变。
+// 奖励交易之后不能再出现奖励交易，奖励没有发送方与手续费，合计必须等于reward（见 BlockReward），
+// BlockVersionFees 及之后的区块还要加上区块内普通交易的手续费；奖励交易的金额须为正，应得的奖励为0时不能包含奖励交易
+func validateCoinbase(block *Block, reward Amount) error {
+	if block.Version < BlockVersionCoinbase || block.isPruned() {
+		return nil
+	}
+	if block.Version >= BlockVersionFees {
+		for _, tx := range block.Transactions {
+			if !tx.Coinbase {
+				reward += tx.Fee
+			}
+		}
+	}
+
+	count := 0
+	for count < len(block.Transactions) && block.Transactions[count].Coinbase {
+		count++
+	}
+	if count == 0 && reward > 0 {
+		return fmt.Errorf("%w: 区块的第一笔交易不是挖矿奖励", ErrInvalidCoinbase)
+	}
+	for _, tx := range block.Transactions[count:] {
+		if tx.Coinbase {
+			return fmt.Errorf("%w: 挖矿奖励交易 %s 不在区块开头", ErrInvalidCoinbase, tx.ID())
+		}
+	}
+
+	var total Amount
+	recipients := make(map[string]bool, count)
+	for _, tx := range block.Transactions[:count] {
+		if tx.Sender != "" || tx.Fee != 0 || tx.Recipient == "" || tx.Amount <= 0 {
+			return fmt.Errorf("%w: 交易 %s 的字段不正确", ErrInvalidCoinbase, tx.ID())
+		}
+		if recipients[tx.Recipient] {
+			return fmt.Errorf("%w: %s 获得了多笔奖励", ErrInvalidCoinbase, tx.Recipient)
+		}
+		recipients[tx.Recipient] = true
+		total += tx.Amount
+	}
+	if total != reward {
+		return fmt.Errorf("%w: 奖励合计 %s，应为 %s", ErrInvalidCoinbase, total, reward)
+	}
+	return nil
+}
@@ -0,0 +1,62 @@
+package main
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestValidateCoinbase(t *testing.T) {
+	tests := []struct {
+		name  string
+		edit  func(b *Block)
+		valid bool
+	}{
+		{"one", nil, true},
+		{"zero", func(b *Block) { b.Transactions = b.Transactions[1:] }, false},
+		{"split between recipients", func(b *Block) {
+			reward := b.Transactions[0].Amount
+			b.Transactions[0].Amount = reward / 2
+			b.Transactions = append([]Transaction{newCoinbase("pool", reward-reward/2)}, b.Transactions...)
+		}, true},
+		{"two to the same recipient", func(b *Block) {
+			reward := b.Transactions[0].Amount
+			b.Transactions[0].Amount = reward / 2
+			b.Transactions = append([]Transaction{newCoinbase("miner", reward-reward/2)}, b.Transactions...)
+		}, false},
+		{"extra coinbase", func(b *Block) {
+			b.Transactions = append([]Transaction{newCoinbase("mallory", 1)}, b.Transactions...)
+		}, false},
+		{"not first", func(b *Block) {
+			b.Transactions[0], b.Transactions[1] = b.Transactions[1], b.Transactions[0]
+		}, false},
+		{"zero amount", func(b *Block) {
+			b.Transactions = append([]Transaction{newCoinbase("mallory", 0)}, b.Transactions...)
+		}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := newTestChain(t)
+			mustMine(t, bc, "alice")
+			mustAddTransaction(t, bc, transfer(bc, "alice", "bob", 1))
+			block := mineWith(t, bc, "miner", tt.edit)
+
+			err := validateCoinbase(block, bc.BlockReward(block.Index))
+			if tt.valid && err != nil {
+				t.Fatalf("奖励交易应有效: %v", err)
+			}
+			if !tt.valid && !errors.Is(err, ErrInvalidCoinbase) {
+				t.Fatalf("校验结果为 %v，应为 ErrInvalidCoinbase", err)
+			}
+			if bc.IsChainValid() != tt.valid {
+				t.Fatalf("链的有效性应为 %v", tt.valid)
+			}
+		})
+	}
+}
+
+func TestAddTransactionRejectsCoinbase(t *testing.T) {
+	bc := newTestChain(t)
+	if _, err := bc.AddTransaction(newCoinbase("mallory", Coin)); !errors.Is(err, ErrUnexpectedCoinbase) {
+		t.Fatalf("提交奖励交易返回 %v，应为 ErrUnexpectedCoinbase", err)
+	}
+}
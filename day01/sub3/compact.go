@@ -0,0 +1,78 @@
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// maxCompactLength 紧凑编码允许的最大长度
+// 一个QR码（版本40、L级纠错、字节模式）最多容纳2953个字节
+const maxCompactLength = 2953
+
+// maxCompactJSONSize 导入时解压后JSON的最大字节数，防止压缩炸弹
+const maxCompactJSONSize = 1 << 20
+
+// ExportCompact 将区块链压缩编码为单个字符串（gzip + base64url），便于通过二维码或剪贴板分享
+// 编码结果超过maxCompactLength时返回错误
+func (bc *Blockchain) ExportCompact() (string, error) {
+	data, err := json.Marshal(bc)
+	if err != nil {
+		return "", fmt.Errorf("编码区块链失败: %v", err)
+	}
+
+	var buf bytes.Buffer
+	zw, err := gzip.NewWriterLevel(&buf, gzip.BestCompression)
+	if err != nil {
+		return "", fmt.Errorf("压缩区块链失败: %v", err)
+	}
+	if _, err := zw.Write(data); err != nil {
+		return "", fmt.Errorf("压缩区块链失败: %v", err)
+	}
+	if err := zw.Close(); err != nil {
+		return "", fmt.Errorf("压缩区块链失败: %v", err)
+	}
+
+	encoded := base64.RawURLEncoding.EncodeToString(buf.Bytes())
+	if len(encoded) > maxCompactLength {
+		return "", fmt.Errorf("编码后长度 %d 超过上限 %d，区块链过大不便分享", len(encoded), maxCompactLength)
+	}
+	return encoded, nil
+}
+
+// ImportCompact 解码ExportCompact生成的字符串，并校验得到的区块链
+func ImportCompact(s string) (*Blockchain, error) {
+	if len(s) > maxCompactLength {
+		return nil, fmt.Errorf("编码长度 %d 超过上限 %d", len(s), maxCompactLength)
+	}
+
+	compressed, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, fmt.Errorf("解码失败: %v", err)
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(compressed))
+	if err != nil {
+		return nil, fmt.Errorf("解压失败: %v", err)
+	}
+	defer zr.Close()
+
+	data, err := io.ReadAll(io.LimitReader(zr, maxCompactJSONSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("解压失败: %v", err)
+	}
+	if len(data) > maxCompactJSONSize {
+		return nil, fmt.Errorf("解压后超过 %d 字节", maxCompactJSONSize)
+	}
+
+	bc := &Blockchain{}
+	if err := bc.FromJSON(data); err != nil {
+		return nil, fmt.Errorf("解析区块链失败: %v", err)
+	}
+	if len(bc.Chain) == 0 || !bc.IsChainValid() {
+		return nil, ErrInvalidChain
+	}
+	return bc, nil
+}
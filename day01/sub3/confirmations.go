@@ -0,0 +1,122 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	// DefaultConfirmationDepth 未指定深度时等待的确认数
+	DefaultConfirmationDepth = 6
+	// MaxConfirmationDepth 允许订阅的最大确认深度
+	MaxConfirmationDepth = 100
+)
+
+// ConfirmationUpdate 推送给订阅者的交易确认进度
+type ConfirmationUpdate struct {
+	TxID          string `json:"txid"`
+	Confirmations int    `json:"confirmations"`         // 包含交易的区块及其后的区块数，未上链时为0
+	Depth         int    `json:"depth"`                 // 订阅时请求的确认深度
+	BlockIndex    int    `json:"block_index,omitempty"` // 包含交易的区块高度，未上链时省略
+}
+
+// Confirmations 返回交易当前的确认数与所在区块高度，交易未上链时返回 0 与 -1
+func (bc *Blockchain) Confirmations(txID string) (int, int) {
+	_, index, err := bc.FindTransaction(txID)
+	if err != nil {
+		return 0, -1
+	}
+	return len(bc.Chain) - index, index
+}
+
+// parseConfirmationDepth 解析 ?depth= 参数，未指定时使用 DefaultConfirmationDepth
+func parseConfirmationDepth(r *http.Request) (int, error) {
+	s := r.URL.Query().Get("depth")
+	if s == "" {
+		return DefaultConfirmationDepth, nil
+	}
+	depth, err := strconv.Atoi(s)
+	if err != nil || depth < 1 || depth > MaxConfirmationDepth {
+		return 0, fmt.Errorf("depth must be between 1 and %d", MaxConfirmationDepth)
+	}
+	return depth, nil
+}
+
+// watchConfirmations 通过WebSocket推送交易的确认进度
+// 订阅后立即推送一次当前确认数，之后每当本链出块或发生链重组且确认数变化时推送；
+// 达到请求的深度或客户端断开时取消订阅并关闭连接
+func (n *Network) watchConfirmations(w http.ResponseWriter, r *http.Request, c *namedChain) {
+	txID := r.URL.Query().Get("txid")
+	if txID == "" {
+		writeError(w, http.StatusBadRequest, fmt.Errorf("%w txid", errMissingQueryArgument))
+		return
+	}
+	depth, err := parseConfirmationDepth(r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+
+	// 在握手前订阅，避免错过握手期间产生的区块
+	events := n.Subscribe()
+	defer n.Unsubscribe(events)
+
+	ws, err := upgradeWebSocket(w, r)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	defer ws.Close()
+
+	disconnected := make(chan error, 1)
+	go func() {
+		disconnected <- ws.readLoop()
+	}()
+
+	last := -1
+	notify := func() (bool, error) {
+		c.RLock()
+		confirmations, index := c.blockchain.Confirmations(txID)
+		c.RUnlock()
+
+		if confirmations == last {
+			return false, nil
+		}
+		last = confirmations
+
+		update := ConfirmationUpdate{TxID: txID, Confirmations: min(confirmations, depth), Depth: depth}
+		if index >= 0 {
+			update.BlockIndex = index
+		}
+		data, err := json.Marshal(update)
+		if err != nil {
+			return false, err
+		}
+		if err := ws.WriteText(data); err != nil {
+			return false, err
+		}
+		return confirmations >= depth, nil
+	}
+
+	for {
+		done, err := notify()
+		if err != nil || done {
+			return
+		}
+
+		// 等待本链的新区块或链重组，算力采样与其他链的事件不影响确认数
+		for relevant := false; !relevant; {
+			select {
+			case <-disconnected:
+				return
+			case event, ok := <-events:
+				if !ok {
+					return
+				}
+				relevant = event.Chain == c.name && event.Type != MiningEventHashRate
+			}
+		}
+	}
+}
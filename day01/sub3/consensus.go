@@ -0,0 +1,63 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/binary"
+	"fmt"
+)
+
+const (
+	// PoWSHA256 使用单次SHA-256的工作量证明（默认）
+	PoWSHA256 = "sha256"
+	// PoWMemoryHard 使用内存困难函数的工作量证明
+	PoWMemoryHard = "memhard"
+
+	// memoryHardBlocks 内存困难函数的暂存区大小（32字节块的个数，即32KiB）
+	memoryHardBlocks = 1024
+)
+
+// PoWFunc 工作量证明使用的哈希函数
+type PoWFunc func(data []byte) [32]byte
+
+// powFuncs 可选的工作量证明哈希函数
+var powFuncs = map[string]PoWFunc{
+	PoWSHA256:     sha256.Sum256,
+	PoWMemoryHard: memoryHardHash,
+}
+
+// lookupPoW 按名称查找工作量证明哈希函数，空名称表示默认的SHA-256
+func lookupPoW(algorithm string) (PoWFunc, error) {
+	if algorithm == "" {
+		algorithm = PoWSHA256
+	}
+	fn, ok := powFuncs[algorithm]
+	if !ok {
+		return nil, fmt.Errorf("未知的工作量证明算法: %s", algorithm)
+	}
+	return fn, nil
+}
+
+// memoryHardHash 简化版的scrypt ROMix，以SHA-256为基础
+// 先顺序填充暂存区，再按与数据相关的顺序随机读取，计算时必须把整个暂存区保留在内存中。
+//
+// 性能权衡：每次哈希约需 2*memoryHardBlocks 次SHA-256运算和32KiB内存，
+// 比单次SHA-256慢三个数量级左右，使用时应相应调低难度。
+// 它能削弱专用硬件的优势，但验证区块同样变慢。
+func memoryHardHash(data []byte) [32]byte {
+	scratch := make([][32]byte, memoryHardBlocks)
+
+	x := sha256.Sum256(data)
+	for i := range scratch {
+		scratch[i] = x
+		x = sha256.Sum256(x[:])
+	}
+
+	for i := 0; i < memoryHardBlocks; i++ {
+		j := binary.LittleEndian.Uint64(x[:8]) % memoryHardBlocks
+		for k := range x {
+			x[k] ^= scratch[j][k]
+		}
+		x = sha256.Sum256(x[:])
+	}
+	return x
+}
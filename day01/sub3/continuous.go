@@ -0,0 +1,58 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"time"
+)
+
+// blockIntervalForRate 返回达到每分钟blocksPerMinute个区块所需的出块间隔
+func blockIntervalForRate(blocksPerMinute float64) time.Duration {
+	return time.Duration(float64(time.Minute) / blocksPerMinute)
+}
+
+// MineContinuously 在默认链上持续挖矿，直到ctx被取消（如收到Ctrl+C）
+// 出块速度由链的 MinBlockInterval 限制：等待下一个区块时不持有链的锁，HTTP接口照常响应；
+// 暂停出块或对端节点不足时等待后重试。每出一个区块输出高度、哈希、交易数与交易池大小。
+// ctx被取消时正在求解的区块被放弃，交易留在交易池中，返回nil
+func (n *Network) MineContinuously(ctx context.Context, minerAddress string) error {
+	c := n.chain(DefaultChainName)
+	shares := []RewardShare{{Address: minerAddress, Fraction: 1}}
+
+	for {
+		c.RLock()
+		wait := c.blockchain.NextBlockDelay()
+		c.RUnlock()
+		if err := n.checkCanMine(); err != nil {
+			fmt.Printf("暂不出块: %v\n", err)
+			wait = max(wait, time.Second)
+		}
+		if wait > 0 {
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(wait):
+			}
+			continue
+		}
+
+		c.Lock()
+		block, err := c.blockchain.MineContext(ctx, shares)
+		pending := len(c.blockchain.Transactions)
+		c.Unlock()
+
+		if ctx.Err() != nil {
+			return nil
+		}
+		if errors.Is(err, ErrProofNotFound) {
+			fmt.Fprintf(os.Stderr, "挖矿未完成: %v\n", err)
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		fmt.Printf("区块 #%d %s 交易 %d 笔，交易池 %d 笔\n", block.Index, block.Hash, len(block.Transactions), pending)
+	}
+}
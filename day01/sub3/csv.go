@@ -0,0 +1,34 @@
+package main
+
+import (
+	"encoding/csv"
+	"io"
+	"strconv"
+)
+
+// csvHeader CSV导出的表头
+var csvHeader = []string{"block_index", "timestamp", "txid", "sender", "recipient", "amount", "fee", "memo"}
+
+// WriteCSV 以CSV格式逐行写出链上的所有交易，每笔交易一行，首行为表头
+// 已归档区块的交易不在内存中，不会被导出
+// 边遍历边写出，不在内存中构造完整的结果
+func (bc *Blockchain) WriteCSV(w io.Writer) error {
+	cw := csv.NewWriter(w)
+	if err := cw.Write(csvHeader); err != nil {
+		return err
+	}
+
+	for _, block := range bc.Chain {
+		index := strconv.Itoa(block.Index)
+		timestamp := strconv.FormatInt(block.Timestamp, 10)
+		for _, tx := range block.Transactions {
+			record := []string{index, timestamp, tx.ID(), tx.Sender, tx.Recipient, tx.Amount.String(), tx.Fee.String(), tx.Memo}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+	}
+
+	cw.Flush()
+	return cw.Error()
+}
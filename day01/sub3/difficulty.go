@@ -0,0 +1,181 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"openspace/day01/pow"
+)
+
+const (
+	// ChainVersion 当前程序能识别的区块链序列化格式版本
+	//   1（或缺省）: 难度固定为哈希以4个十六进制0开头
+	//   2: 难度以前导0比特数表示，并记录在每个区块上
+	//   3: 区块哈希本身须满足难度，证明作为nonce参与区块哈希，工作量与区块内容绑定
+	//   4: 区块记录格式版本，新区块的哈希改用无歧义的二进制编码（见 BlockVersionBinary）
+	//   5: 区块的难度须符合难度调整规则（见 expectedDifficulty），不再由挖出它的节点自行决定
+	ChainVersion = 5
+
+	// BitsPerZero 每个十六进制前导0对应的比特数
+	BitsPerZero = pow.BitsPerZero
+
+	// DefaultDifficulty 默认难度（比特），与旧版的4个十六进制0等价
+	DefaultDifficulty = 4 * BitsPerZero
+
+	// MinDifficulty 非创世区块允许的最小难度（比特）
+	MinDifficulty = 1
+
+	// legacyZeroCount 版本1中固定要求的十六进制前导0个数
+	legacyZeroCount = 4
+)
+
+// ErrUnsupportedVersion 区块链格式版本高于当前程序支持的版本
+var ErrUnsupportedVersion = errors.New("不支持的区块链格式版本")
+
+// ZerosToBits 将十六进制前导0个数换算为比特难度
+func ZerosToBits(zeros int) int {
+	return pow.ZerosToBits(zeros)
+}
+
+// SuggestDifficulty 返回期望出块时间最接近targetBlockTime的难度（比特）
+// 难度为bits时平均需要 2^bits 次哈希，期望出块时间为 2^bits / hashRate 秒
+func SuggestDifficulty(hashRate float64, targetBlockTime time.Duration) int {
+	if hashRate <= 0 || targetBlockTime <= 0 {
+		return MinDifficulty
+	}
+
+	target := targetBlockTime.Seconds()
+	best, bestDiff := MinDifficulty, math.Inf(1)
+	for bits := MinDifficulty; bits <= 256; bits++ {
+		diff := math.Abs(math.Exp2(float64(bits))/hashRate - target)
+		if diff >= bestDiff {
+			break // 期望时间随难度单调增长，误差开始变大后不会再变小
+		}
+		best, bestDiff = bits, diff
+	}
+	return best
+}
+
+// maxDifficultyBits 难度的理论上限：哈希的全部比特都为0
+const maxDifficultyBits = 256
+
+// NextDifficulty 返回下一个区块按难度调整规则应有的难度，挖矿时新区块按它挖出
+func (bc *Blockchain) NextDifficulty() int {
+	return bc.expectedDifficulty(bc.Chain, len(bc.Chain))
+}
+
+// expectedDifficulty 返回高度为height的区块按难度调整规则应有的难度，chain[:height] 为它之前的区块
+// 第一个受规则约束的区块（高度 DifficultyHeight，新建的链为1）的难度是链的起点：校验时取该区块自身记录的难度
+// （不低于 MinDifficulty），挖出它时取 Difficulty。各节点按自己的哈希率选择 Difficulty，
+// 若要求对端的链与之相同，硬件不同的节点会互相拒绝对方的链。之后的区块沿用前一个区块的难度；
+// 启用难度调整时，前一个区块的高度为 RetargetInterval 的整数倍则按最近 RetargetInterval 个区块的实际用时调整：
+// 用时少于 TargetBlockTime*RetargetInterval 时加1比特（期望哈希次数加倍），多于时减1比特，
+// 结果限制在 [MinDifficulty, MaxDifficulty] 内。难度只由链参数与之前的区块决定，挖矿与校验得出相同的结果；
+// 区块时间戳精确到秒，间隔应足够大以免取整误差主导调整
+func (bc *Blockchain) expectedDifficulty(chain []*Block, height int) int {
+	if height <= max(bc.DifficultyHeight, 1) {
+		if height < len(chain) {
+			return max(chain[height].Difficulty, MinDifficulty)
+		}
+		return max(bc.Difficulty, MinDifficulty)
+	}
+	previous := chain[height-1]
+	n := bc.RetargetInterval
+	if n <= 0 || bc.TargetBlockTime <= 0 || previous.Index < n || previous.Index%n != 0 {
+		return previous.Difficulty
+	}
+
+	difficulty := previous.Difficulty
+	elapsed := time.Duration(previous.Timestamp-chain[height-1-n].Timestamp) * time.Second
+	expected := bc.TargetBlockTime * time.Duration(n)
+	switch {
+	case elapsed < expected:
+		difficulty++
+	case elapsed > expected:
+		difficulty--
+	}
+
+	upper := maxDifficultyBits
+	if bc.MaxDifficulty > 0 {
+		upper = min(bc.MaxDifficulty, maxDifficultyBits)
+	}
+	return max(MinDifficulty, min(difficulty, upper))
+}
+
+// hashRateCheckInterval MeasureHashRate 检查是否超时的间隔（哈希次数），memhard等慢速算法也不会明显超时
+const hashRateCheckInterval = 64
+
+// MeasureHashRate 以挖矿时相同的并行度计算区块哈希，持续d后返回每秒哈希次数
+// 用于按目标出块时间选择初始难度（见 SuggestDifficulty）
+func MeasureHashRate(algorithm string, d time.Duration) (float64, error) {
+	powFunc, err := lookupPoW(algorithm)
+	if err != nil {
+		return 0, err
+	}
+
+	var total atomic.Int64
+	var wg sync.WaitGroup
+	start := time.Now()
+	deadline := start.Add(d)
+	txHash := hashTransactions(nil)
+	for i := 0; i < pow.AutoTuneWorkers(); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			var record []byte
+			count := int64(0)
+			for proof := int64(0); ; proof++ {
+				record = appendBlockRecord(record[:0], CurrentBlockVersion, 1, 0, txHash, proof, "0")
+				powFunc(record)
+				count++
+				// 每hashRateCheckInterval次检查一次时间，避免time.Now的开销影响测量
+				if count%hashRateCheckInterval == 0 && time.Now().After(deadline) {
+					break
+				}
+			}
+			total.Add(count)
+		}()
+	}
+
+	wg.Wait()
+	return float64(total.Load()) / time.Since(start).Seconds(), nil
+}
+
+// migrate 将旧版本的区块链数据迁移到当前格式
+// 版本1的区块均按4个十六进制0挖出，迁移后记录为等价的16比特难度；
+// 版本3之前的区块继续按旧的工作量证明规则验证；
+// 版本4之前的区块没有version字段，即 BlockVersionLegacy，按原有的字符串拼接计算哈希，无需迁移；
+// 版本5之前的区块只要求不低于最小难度，之后的区块按难度调整规则校验，以 Difficulty 为起点
+func (bc *Blockchain) migrate() error {
+	if bc.Version > ChainVersion {
+		return fmt.Errorf("%w: %d（当前支持 %d）", ErrUnsupportedVersion, bc.Version, ChainVersion)
+	}
+
+	if bc.Version < 2 {
+		for _, block := range bc.Chain {
+			if block.Index > 0 && block.Difficulty == 0 {
+				block.Difficulty = ZerosToBits(legacyZeroCount)
+			}
+		}
+		if bc.Difficulty == 0 {
+			bc.Difficulty = ZerosToBits(legacyZeroCount)
+		}
+	}
+
+	// 版本3之前的区块无法改用新的工作量证明规则，记录其高度以便按旧规则验证
+	if bc.Version < 3 {
+		bc.LegacyPoWHeight = len(bc.Chain)
+	}
+
+	// 版本5之前各节点自行设置难度，已有区块的难度不一定符合难度调整规则，规则从下一个区块开始生效
+	if bc.Version < 5 {
+		bc.DifficultyHeight = len(bc.Chain)
+	}
+
+	bc.Version = ChainVersion
+	return nil
+}
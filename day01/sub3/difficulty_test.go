@@ -0,0 +1,171 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// newRetargetChain 创建每interval个区块按10秒出块时间调整难度的区块链
+func newRetargetChain(t *testing.T, difficulty, interval int) *Blockchain {
+	t.Helper()
+	bc := NewBlockchainWithDifficulty(difficulty)
+	bc.TargetBlockTime = 10 * time.Second
+	bc.RetargetInterval = interval
+	backdateGenesis(t, bc, time.Hour)
+	return bc
+}
+
+// mineSpaced 以相隔spacing秒的时间戳连续挖出count个区块
+func mineSpaced(t *testing.T, bc *Blockchain, count int, spacing int64) {
+	t.Helper()
+	for i := 0; i < count; i++ {
+		mineAt(t, bc, "miner", bc.GetLastBlock().Timestamp+spacing)
+	}
+}
+
+func TestRetargetRaisesDifficultyForFastBlocks(t *testing.T) {
+	bc := newRetargetChain(t, 2, 2)
+	mineSpaced(t, bc, 2, 0)
+
+	if got := bc.NextDifficulty(); got != 3 {
+		t.Fatalf("出块过快后难度为 %d，应为 3", got)
+	}
+	if block := mineAt(t, bc, "miner", bc.GetLastBlock().Timestamp); block.Difficulty != 3 {
+		t.Fatalf("新区块的难度为 %d，应为 3", block.Difficulty)
+	}
+	if !bc.IsChainValid() {
+		t.Fatal("按调整后的难度挖出的链应有效")
+	}
+}
+
+func TestRetargetLowersDifficultyForSlowBlocks(t *testing.T) {
+	bc := newRetargetChain(t, 3, 2)
+	mineSpaced(t, bc, 2, 100)
+
+	if got := bc.NextDifficulty(); got != 2 {
+		t.Fatalf("出块过慢后难度为 %d，应为 2", got)
+	}
+	mineSpaced(t, bc, 1, 100)
+	if !bc.IsChainValid() {
+		t.Fatal("按调整后的难度挖出的链应有效")
+	}
+}
+
+func TestRetargetKeepsDifficultyBetweenIntervals(t *testing.T) {
+	bc := newRetargetChain(t, 2, 3)
+	mineSpaced(t, bc, 2, 0)
+
+	if got := bc.NextDifficulty(); got != 2 {
+		t.Fatalf("未到调整间隔时难度为 %d，应保持 2", got)
+	}
+}
+
+func TestRetargetClampsDifficulty(t *testing.T) {
+	t.Run("max", func(t *testing.T) {
+		bc := newRetargetChain(t, 2, 1)
+		bc.MaxDifficulty = 2
+		mineSpaced(t, bc, 3, 0)
+
+		if got := bc.NextDifficulty(); got != 2 {
+			t.Fatalf("难度为 %d，不应超过上限 2", got)
+		}
+		if !bc.IsChainValid() {
+			t.Fatal("受上限约束的链应有效")
+		}
+	})
+
+	t.Run("min", func(t *testing.T) {
+		bc := newRetargetChain(t, MinDifficulty, 1)
+		mineSpaced(t, bc, 3, 100)
+
+		if got := bc.NextDifficulty(); got != MinDifficulty {
+			t.Fatalf("难度为 %d，不应低于最小难度 %d", got, MinDifficulty)
+		}
+		if !bc.IsChainValid() {
+			t.Fatal("受下限约束的链应有效")
+		}
+	})
+}
+
+func TestValidateChainRejectsDifficultyOffSchedule(t *testing.T) {
+	tests := []struct {
+		name  string
+		delta int
+	}{
+		{"too low", -1},
+		{"too high", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := newRetargetChain(t, 2, 2)
+			mineSpaced(t, bc, 2, 0)
+			expected := bc.NextDifficulty()
+			mineWith(t, bc, "miner", func(b *Block) {
+				b.Timestamp = bc.GetLastBlock().Timestamp
+				b.Difficulty = expected + tt.delta
+			})
+
+			index, err := bc.validateChain(bc.Chain)
+			if err == nil {
+				t.Fatal("难度不符合调整规则的区块应被拒绝")
+			}
+			if index != 3 || !strings.Contains(err.Error(), "难度调整规则") {
+				t.Fatalf("错误为 区块 %d: %v，应指出区块 3 的难度", index, err)
+			}
+		})
+	}
+}
+
+func TestValidateChainRejectsDifficultyChangeWithoutRetarget(t *testing.T) {
+	bc := NewBlockchainWithDifficulty(3)
+	mustMine(t, bc, "miner")
+	mineWith(t, bc, "miner", func(b *Block) { b.Difficulty = MinDifficulty })
+
+	if bc.IsChainValid() {
+		t.Fatal("未启用难度调整时，以低于链难度挖出的区块应被拒绝")
+	}
+}
+
+func TestValidateChainTakesStartingDifficultyFromChain(t *testing.T) {
+	// 两个节点按各自测得的哈希率选择了不同的难度
+	local := NewBlockchainWithDifficulty(2)
+	peer := NewBlockchainWithDifficulty(3)
+	peer.Chain[0] = local.Chain[0]
+	mustMine(t, peer, "miner")
+	mustMine(t, peer, "miner")
+
+	if index, err := local.validateChain(peer.Chain); err != nil {
+		t.Fatalf("对端的链在区块 %d 被拒绝: %v，起点难度应取自对端的链", index, err)
+	}
+	if err := local.ReplaceChain(peer.Chain); err != nil {
+		t.Fatalf("替换为难度不同的对端链失败: %v", err)
+	}
+	if got := local.NextDifficulty(); got != 3 {
+		t.Fatalf("替换后下一个区块的难度为 %d，应沿用对端链的 3", got)
+	}
+}
+
+func TestMigrateExemptsExistingBlocksFromSchedule(t *testing.T) {
+	bc := NewBlockchainWithDifficulty(3)
+	mustMine(t, bc, "miner")
+	mineWith(t, bc, "miner", func(b *Block) { b.Difficulty = 2 })
+
+	// 版本5之前各节点自行设置难度，迁移后已有区块只要求不低于最小难度
+	bc.Version = 4
+	if err := bc.migrate(); err != nil {
+		t.Fatal(err)
+	}
+	if bc.DifficultyHeight != len(bc.Chain) {
+		t.Fatalf("DifficultyHeight 为 %d，应为 %d", bc.DifficultyHeight, len(bc.Chain))
+	}
+	if !bc.IsChainValid() {
+		t.Fatal("迁移后的旧区块应有效")
+	}
+	if block := mustMine(t, bc, "miner"); block.Difficulty != 3 {
+		t.Fatalf("迁移后的新区块难度为 %d，应为链的难度 3", block.Difficulty)
+	}
+	if !bc.IsChainValid() {
+		t.Fatal("迁移后挖出的链应有效")
+	}
+}
@@ -0,0 +1,41 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+)
+
+// dotHashLength DOT图中节点标签显示的哈希前缀长度
+const dotHashLength = 8
+
+// ExportDOT 以Graphviz DOT格式输出区块链，可用 dot -Tpng 生成图片
+// 每个区块一个节点，标签为高度、哈希前缀与交易数；边由区块指向其 PreviousHash 对应的区块。
+// 节点不保留分叉的区块，因此图中只有当前的主链
+func (bc *Blockchain) ExportDOT() string {
+	var sb strings.Builder
+	sb.WriteString("digraph blockchain {\n")
+	sb.WriteString("  rankdir=LR;\n")
+	sb.WriteString("  node [shape=box, fontname=monospace];\n")
+
+	for _, block := range bc.Chain {
+		fmt.Fprintf(&sb, "  %q [label=\"#%d\\n%s\\n%d tx\"];\n",
+			block.Hash, block.Index, shortHash(block.Hash), len(block.Transactions))
+	}
+	for _, block := range bc.Chain {
+		if block.Index == 0 {
+			continue
+		}
+		fmt.Fprintf(&sb, "  %q -> %q;\n", block.Hash, block.PreviousHash)
+	}
+
+	sb.WriteString("}\n")
+	return sb.String()
+}
+
+// shortHash 返回哈希的前dotHashLength个字符
+func shortHash(hash string) string {
+	if len(hash) <= dotHashLength {
+		return hash
+	}
+	return hash[:dotHashLength]
+}
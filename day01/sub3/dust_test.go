@@ -0,0 +1,77 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestMinTxAmountBoundary(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	bc.MinTxAmount = 100
+
+	if _, _, err := bc.CreateTransaction("alice", "bob", 99); !errors.Is(err, ErrDustAmount) {
+		t.Fatalf("低于最小金额的错误为 %v，应为 ErrDustAmount", err)
+	}
+	if _, _, err := bc.CreateTransaction("alice", "bob", 100); err != nil {
+		t.Fatalf("等于最小金额的交易应被接受: %v", err)
+	}
+	if len(bc.Transactions) != 1 {
+		t.Fatalf("交易池有 %d 笔交易，应只有等于最小金额的 1 笔", len(bc.Transactions))
+	}
+}
+
+func TestMinTxAmountDefaultsToNoRestriction(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	if bc.MinTxAmount != 0 {
+		t.Fatalf("MinTxAmount 默认为 %s，应为 0", bc.MinTxAmount)
+	}
+	if _, _, err := bc.CreateTransaction("alice", "bob", 1); err != nil {
+		t.Fatalf("未设置最小金额时最小单位的交易应被接受: %v", err)
+	}
+}
+
+func TestMinTxAmountExemptsRewardAndBlocks(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	dust := transfer(bc, "alice", "bob", 1)
+
+	// 最小金额高于出块奖励：奖励交易不受限制，已打包的小额交易也仍然有效
+	bc.MinTxAmount = 2 * miningReward
+	mineWith(t, bc, "miner", func(b *Block) { b.Transactions = append(b.Transactions, dust) })
+	mustMine(t, bc, "miner")
+	if !bc.IsChainValid() {
+		t.Fatal("最小金额只是接收交易的策略，不应影响区块校验")
+	}
+}
+
+func TestNewTransactionEndpointRejectsDust(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	bc.MinTxAmount = 100
+	n := newTestNetwork(t, bc)
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if status := postJSON(t, n, "/transactions/new", transfer(bc, "alice", "bob", 99), &body); status != http.StatusBadRequest || body.Code != "dust_amount" {
+		t.Fatalf("提交小额交易返回 %d %s，应为 400 dust_amount", status, body.Code)
+	}
+}
+
+func TestMergePendingDropsDust(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	bc.MinTxAmount = 100
+
+	dust := transfer(bc, "alice", "bob", 99)
+	enough := transfer(bc, "alice", "carol", 100)
+	if added := bc.MergePending([]Transaction{dust, enough}); added != 1 {
+		t.Fatalf("合并了 %d 笔对端交易，应只合并不低于最小金额的 1 笔", added)
+	}
+	if bc.Transactions[0].ID() != enough.ID() {
+		t.Fatalf("交易池中为 %s -> %s，应为 alice -> carol", bc.Transactions[0].Sender, bc.Transactions[0].Recipient)
+	}
+}
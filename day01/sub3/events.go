@@ -0,0 +1,126 @@
+package main
+
+import (
+	"sync"
+	"time"
+
+	"openspace/day01/pow"
+)
+
+const (
+	// MiningEventBlock 挖出了新区块
+	MiningEventBlock = "block"
+	// MiningEventHashRate 挖矿过程中的算力采样
+	MiningEventHashRate = "hashrate"
+	// MiningEventChainReplaced 链被对端更长的链替换（链重组）
+	MiningEventChainReplaced = "chain_replaced"
+
+	// miningEventBuffer 每个订阅者的事件缓冲区大小，缓冲区满时丢弃新事件而不阻塞挖矿
+	miningEventBuffer = 64
+	// hashRateSampleInterval 挖矿过程中发布算力采样的最小间隔
+	hashRateSampleInterval = time.Second
+)
+
+// MiningEvent 挖矿事件，供界面、WebSocket与监控等订阅
+type MiningEvent struct {
+	Type     string    `json:"type"`                // MiningEventBlock、MiningEventHashRate 或 MiningEventChainReplaced
+	Chain    string    `json:"chain"`               // 区块链名称
+	Time     time.Time `json:"time"`                // 事件发生的时间
+	Block    *Block    `json:"block,omitempty"`     // 挖出的区块（仅区块事件）
+	HashRate float64   `json:"hash_rate,omitempty"` // 自上次采样以来的每秒哈希次数（仅算力采样事件）
+}
+
+// miningEvents 挖矿事件的订阅者列表
+// 发布时不等待订阅者：缓冲区已满的订阅者会错过该事件
+type miningEvents struct {
+	sync.Mutex
+	subscribers map[chan MiningEvent]struct{}
+}
+
+// subscribe 新增一个订阅者
+func (e *miningEvents) subscribe() chan MiningEvent {
+	e.Lock()
+	defer e.Unlock()
+
+	if e.subscribers == nil {
+		e.subscribers = make(map[chan MiningEvent]struct{})
+	}
+	ch := make(chan MiningEvent, miningEventBuffer)
+	e.subscribers[ch] = struct{}{}
+	return ch
+}
+
+// unsubscribe 移除订阅者并关闭其通道
+func (e *miningEvents) unsubscribe(ch <-chan MiningEvent) {
+	e.Lock()
+	defer e.Unlock()
+
+	for sub := range e.subscribers {
+		if sub == ch {
+			delete(e.subscribers, sub)
+			close(sub)
+			return
+		}
+	}
+}
+
+// publish 向所有订阅者发送事件，跳过缓冲区已满的订阅者
+func (e *miningEvents) publish(event MiningEvent) {
+	e.Lock()
+	defer e.Unlock()
+
+	for sub := range e.subscribers {
+		select {
+		case sub <- event:
+		default:
+		}
+	}
+}
+
+// Subscribe 订阅本节点所有区块链的挖矿事件（新区块与算力采样）
+// 处理过慢的订阅者会丢失事件，挖矿不会因此阻塞；不再需要时调用 Unsubscribe
+func (n *Network) Subscribe() <-chan MiningEvent {
+	return n.miningEvents.subscribe()
+}
+
+// Unsubscribe 取消订阅并关闭通道
+func (n *Network) Unsubscribe(ch <-chan MiningEvent) {
+	n.miningEvents.unsubscribe(ch)
+}
+
+// emit 发布挖矿事件，区块链未加入网络时不做任何事
+func (bc *Blockchain) emit(event MiningEvent) {
+	if bc.publish == nil {
+		return
+	}
+	if event.Time.IsZero() {
+		event.Time = time.Now()
+	}
+	bc.publish(event)
+}
+
+// hashRateSampler 返回挖矿进度回调，最多每hashRateSampleInterval发布一次算力采样
+func (bc *Blockchain) hashRateSampler() func(attempts int) {
+	if bc.publish == nil {
+		return nil
+	}
+	lastTime, lastAttempts := time.Now(), 0
+	return func(attempts int) {
+		now := time.Now()
+		elapsed := now.Sub(lastTime)
+		if elapsed < hashRateSampleInterval {
+			return
+		}
+		bc.emit(MiningEvent{
+			Type:     MiningEventHashRate,
+			Time:     now,
+			HashRate: float64(attempts-lastAttempts) / elapsed.Seconds(),
+		})
+		lastTime, lastAttempts = now, attempts
+	}
+}
+
+// miningOptions 返回区块链挖矿时使用的求解选项
+func (bc *Blockchain) miningOptions() pow.Options {
+	return pow.Options{Progress: bc.hashRateSampler(), MaxAttempts: bc.MaxMiningAttempts}
+}
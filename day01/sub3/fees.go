@@ -0,0 +1,54 @@
+package main
+
+import (
+	"math"
+	"slices"
+)
+
+// defaultFeeEstimate 交易池为空时建议的手续费
+const defaultFeeEstimate = Coin / 10000
+
+// FeeEstimate 建议的手续费档位
+type FeeEstimate struct {
+	Low         Amount `json:"low"`          // 交易池不拥堵时可被较快打包
+	Medium      Amount `json:"medium"`       // 交易池手续费的中位数
+	High        Amount `json:"high"`         // 优先进入下一个区块
+	MempoolSize int    `json:"mempool_size"` // 参与估算的待处理交易数
+}
+
+// EstimateFees 根据交易池的手续费分布给出低、中、高三档建议手续费
+// 三档分别取第25、50、90百分位；设置了 MaxBlockTransactions 且交易池超过一个区块的容量时，
+// 高档至少要高于能进入下一个区块的最低手续费
+func (bc *Blockchain) EstimateFees() FeeEstimate {
+	if len(bc.Transactions) == 0 {
+		return FeeEstimate{
+			Low:    defaultFeeEstimate,
+			Medium: defaultFeeEstimate,
+			High:   defaultFeeEstimate,
+		}
+	}
+
+	fees := make([]Amount, len(bc.Transactions))
+	for i, tx := range bc.Transactions {
+		fees[i] = tx.Fee
+	}
+	slices.Sort(fees)
+
+	estimate := FeeEstimate{
+		Low:         feePercentile(fees, 25),
+		Medium:      feePercentile(fees, 50),
+		High:        feePercentile(fees, 90),
+		MempoolSize: len(fees),
+	}
+	if capacity := bc.MaxBlockTransactions; capacity > 0 && len(fees) >= capacity {
+		cutoff := fees[len(fees)-capacity] + 1
+		estimate.High = max(estimate.High, cutoff)
+	}
+	return estimate
+}
+
+// feePercentile 按最近秩法取已排序手续费的第p百分位
+func feePercentile(sorted []Amount, p float64) Amount {
+	rank := int(math.Ceil(p / 100 * float64(len(sorted))))
+	return sorted[max(rank-1, 0)]
+}
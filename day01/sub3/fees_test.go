@@ -0,0 +1,26 @@
+package main
+
+import "testing"
+
+func TestEstimateFeesUsesBlockCapacity(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	// 10笔手续费为1的交易，以及手续费为2与3的各一笔：第90百分位为2
+	fees := []Amount{1, 1, 1, 1, 1, 1, 1, 1, 1, 1, 2, 3}
+	for i, fee := range fees {
+		tx := transfer(bc, "alice", "bob", 1)
+		tx.Timestamp = int64(i + 1)
+		tx.Fee = fee
+		mustAddTransaction(t, bc, tx)
+	}
+
+	if got := bc.EstimateFees().High; got != 2 {
+		t.Fatalf("没有区块交易数上限时高档为 %s，应为第90百分位 2", got)
+	}
+
+	// 每个区块只能打包2笔时，进入下一个区块须高于第二高的手续费2
+	bc.MaxBlockTransactions = 2
+	if got := bc.EstimateFees().High; got != 3 {
+		t.Fatalf("区块容量为2时高档为 %s，应为 3", got)
+	}
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+)
+
+// maxPathLength 单次查询区块路径允许返回的最大区块头数
+const maxPathLength = 1000
+
+// ErrPathTooLong 请求的区块路径超过maxPathLength
+var ErrPathTooLong = errors.New("区块路径过长")
+
+// BlockHeader 区块头：不含交易内容，只保留交易列表的哈希，足以重新计算区块哈希
+type BlockHeader struct {
+	Version      int    `json:"version,omitempty"`
+	Index        int    `json:"index"`
+	Timestamp    int64  `json:"timestamp"`
+	TxHash       string `json:"tx_hash"` // 交易列表的哈希
+	Nonce        int64  `json:"nonce"`
+	Difficulty   int    `json:"difficulty"`
+	PreviousHash string `json:"previous_hash"`
+	Hash         string `json:"hash"`
+}
+
+// Header 返回区块的区块头
+func (b *Block) Header() BlockHeader {
+	return BlockHeader{
+		Version:      b.Version,
+		Index:        b.Index,
+		Timestamp:    b.Timestamp,
+		TxHash:       b.txHash(),
+		Nonce:        b.Nonce,
+		Difficulty:   b.Difficulty,
+		PreviousHash: b.PreviousHash,
+		Hash:         b.Hash,
+	}
+}
+
+// UnmarshalJSON 解析区块头，兼容 Nonce 改名前以 "proof" 表示的数据
+func (h *BlockHeader) UnmarshalJSON(data []byte) error {
+	type plain BlockHeader
+	aux := struct {
+		*plain
+		Proof *int64 `json:"proof"`
+	}{plain: (*plain)(h)}
+	if err := json.Unmarshal(data, &aux); err != nil {
+		return err
+	}
+	if aux.Proof != nil && h.Nonce == 0 {
+		h.Nonce = *aux.Proof
+	}
+	return nil
+}
+
+// CalculateHash 由区块头计算区块哈希，与 Block.CalculateHash 结果一致
+func (h BlockHeader) CalculateHash() string {
+	return blockHash(h.Version, h.Index, h.Timestamp, h.TxHash, h.Nonce, h.PreviousHash)
+}
+
+// GetBlockPath 返回从高度from（已知的检查点或创世区块）到高度to的区块头序列
+// 客户端用 VerifyBlockPath 即可确认区块to在链上的位置，而无需下载完整的区块链
+func (bc *Blockchain) GetBlockPath(from, to int) ([]BlockHeader, error) {
+	if to < 0 || to >= len(bc.Chain) || from < 0 || from > to {
+		return nil, ErrBlockNotFound
+	}
+	if to-from+1 > maxPathLength {
+		return nil, fmt.Errorf("%w: 最多 %d 个区块，请指定更近的检查点", ErrPathTooLong, maxPathLength)
+	}
+
+	path := make([]BlockHeader, 0, to-from+1)
+	for _, block := range bc.Chain[from : to+1] {
+		path = append(path, block.Header())
+	}
+	return path, nil
+}
+
+// VerifyBlockPath 校验区块头序列：每个区块头的哈希正确、依次相连且工作量证明有效
+// 第一个区块头视为可信的检查点，只校验其哈希；legacyHeight 为链的 LegacyPoWHeight
+func VerifyBlockPath(path []BlockHeader, algorithm string, legacyHeight int) error {
+	for i, header := range path {
+		if header.Hash != header.CalculateHash() {
+			return fmt.Errorf("区块 %d 的哈希不正确", header.Index)
+		}
+		if i == 0 {
+			continue
+		}
+
+		previous := path[i-1]
+		if header.Index != previous.Index+1 || header.PreviousHash != previous.Hash {
+			return fmt.Errorf("区块 %d 与前一个区块不相连", header.Index)
+		}
+		if !validBlockVersion(header.Version, previous.Version) {
+			return fmt.Errorf("区块 %d 的格式版本无效", header.Index)
+		}
+		if header.Difficulty < MinDifficulty ||
+			!validBlockProof(header, previous.Nonce, algorithm, legacyHeight) {
+			return fmt.Errorf("区块 %d 的工作量证明无效", header.Index)
+		}
+	}
+	return nil
+}
+
+// validBlockProof 验证区块的工作量证明
+// 高度低于legacyHeight的区块按旧规则验证（旧规则中区块的Nonce即其证明），其余区块的哈希本身须满足难度
+func validBlockProof(header BlockHeader, previousNonce int64, algorithm string, legacyHeight int) bool {
+	if header.Index < legacyHeight {
+		return ValidProof(previousNonce, header.Nonce, header.Difficulty, algorithm)
+	}
+	return header.meetsDifficulty(algorithm)
+}
@@ -0,0 +1,163 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// newTestChain 创建按最小难度挖矿的区块链，测试中出块几乎不耗时
+func newTestChain(t *testing.T) *Blockchain {
+	t.Helper()
+	return NewBlockchainWithDifficulty(MinDifficulty)
+}
+
+// backdateGenesis 把只有创世区块的链的创世时间提前d，之后可以用过去的时间戳挖出间隔较长的区块
+// 而不必等待（挖矿会等到上一个区块的时间戳之后才出块）
+func backdateGenesis(t *testing.T, bc *Blockchain, d time.Duration) {
+	t.Helper()
+	if len(bc.Chain) != 1 {
+		t.Fatalf("链上已有 %d 个区块，只能调整创世区块", len(bc.Chain))
+	}
+	genesis := bc.Chain[0]
+	genesis.Timestamp -= int64(d / time.Second)
+	genesis.Hash = genesis.CalculateHash()
+}
+
+// mustMine 挖出下一个区块，奖励全部发给miner
+func mustMine(t *testing.T, bc *Blockchain, miner string) *Block {
+	t.Helper()
+	block, err := bc.MineWithRewardSplit([]RewardShare{{Address: miner, Fraction: 1}})
+	if err != nil {
+		t.Fatalf("挖矿失败: %v", err)
+	}
+	return block
+}
+
+// mineWith 构造下一个区块，求解前用edit修改区块（如时间戳或难度），再加入链而不经过校验
+// 用于构造时间可控或故意违反规则的区块
+func mineWith(t *testing.T, bc *Blockchain, miner string, edit func(*Block)) *Block {
+	t.Helper()
+	block, err := bc.newBlockTemplate([]RewardShare{{Address: miner, Fraction: 1}})
+	if err != nil {
+		t.Fatalf("构造区块失败: %v", err)
+	}
+	if edit != nil {
+		edit(block)
+		block.MerkleRoot = block.txHash()
+	}
+	if err := SolveBlock(block, bc.PoWAlgorithm, bc.miningOptions()); err != nil {
+		t.Fatalf("求解区块失败: %v", err)
+	}
+	return bc.appendBlock(block)
+}
+
+// mineAt 以给定的时间戳挖出下一个区块
+func mineAt(t *testing.T, bc *Blockchain, miner string, timestamp int64) *Block {
+	t.Helper()
+	return mineWith(t, bc, miner, func(b *Block) { b.Timestamp = timestamp })
+}
+
+// mustAddTransaction 把交易加入交易池，失败时终止测试
+func mustAddTransaction(t *testing.T, bc *Blockchain, tx Transaction) {
+	t.Helper()
+	if _, err := bc.AddTransaction(tx); err != nil {
+		t.Fatalf("加入交易 %s -> %s 失败: %v", tx.Sender, tx.Recipient, err)
+	}
+}
+
+// copyChain 经JSON序列化复制区块链，得到区块与账户状态相同、互不影响的另一条链
+func copyChain(t *testing.T, bc *Blockchain) *Blockchain {
+	t.Helper()
+	data, err := bc.ToJSON()
+	if err != nil {
+		t.Fatal(err)
+	}
+	copied := &Blockchain{}
+	if err := copied.FromJSON([]byte(data)); err != nil {
+		t.Fatal(err)
+	}
+	return copied
+}
+
+// pendingTransactions 在持有链的读锁时返回网络中默认链的待处理交易数
+func pendingTransactions(n *Network) int {
+	c := n.chain(DefaultChainName)
+	c.RLock()
+	defer c.RUnlock()
+	return len(c.blockchain.Transactions)
+}
+
+// newTestNetwork 创建以bc为默认链的网络
+func newTestNetwork(t *testing.T, bc *Blockchain) *Network {
+	t.Helper()
+	n := NewNetwork()
+	if err := n.SetBlockchain(DefaultChainName, bc); err != nil {
+		t.Fatal(err)
+	}
+	return n
+}
+
+// startPeer 用 httptest 启动以bc为默认链的节点，返回节点及其 host:port 地址，测试结束时关闭
+func startPeer(t *testing.T, bc *Blockchain) (*Network, string) {
+	t.Helper()
+	n := newTestNetwork(t, bc)
+	server := httptest.NewServer(n.Handler())
+	t.Cleanup(server.Close)
+	return n, server.Listener.Addr().String()
+}
+
+// mustRegister 把address注册为网络中的节点nodeID
+func mustRegister(t *testing.T, n *Network, nodeID, address string) {
+	t.Helper()
+	if _, err := n.RegisterNode(nodeID, address); err != nil {
+		t.Fatalf("注册节点 %s 失败: %v", nodeID, err)
+	}
+}
+
+// postTransaction 把交易提交到address节点的 /transactions/new，返回状态码
+func postTransaction(t *testing.T, address string, tx Transaction) int {
+	t.Helper()
+	body, err := json.Marshal(tx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Post("http://"+address+"/transactions/new", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	return resp.StatusCode
+}
+
+// postJSON 把body以JSON提交到网络的接口，返回状态码并把响应解析到v
+func postJSON(t *testing.T, n *Network, target string, body, v any) int {
+	t.Helper()
+	data, err := json.Marshal(body)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rec := httptest.NewRecorder()
+	n.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, target, bytes.NewReader(data)))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("解析 %s 的响应失败: %v", target, err)
+		}
+	}
+	return rec.Code
+}
+
+// waitFor 轮询直到cond成立，超时时终止测试；用于等待后台任务完成
+func waitFor(t *testing.T, what string, cond func() bool) {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for !cond() {
+		if time.Now().After(deadline) {
+			t.Fatalf("等待%s超时", what)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
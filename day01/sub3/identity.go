@@ -0,0 +1,146 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"openspace/day01/signer"
+)
+
+// ProtocolVersion 节点协议版本，区块等数据的JSON格式变化时递增（2: 区块的 proof 改名为 nonce）
+const ProtocolVersion = "2"
+
+// identityTimeout 获取对端节点身份的超时时间
+const identityTimeout = 2 * time.Second
+
+// maxIdentitySize 对端身份响应的最大字节数，公钥PEM与其他字段远小于此
+const maxIdentitySize = 64 << 10
+
+// Identity 表示节点对外公布的身份，不包含私钥
+type Identity struct {
+	NodeID          string `json:"node_id"`          // 节点ID
+	PublicKey       string `json:"public_key"`       // PEM编码的公钥
+	Address         string `json:"address"`          // 由公钥派生的地址
+	ProtocolVersion string `json:"protocol_version"` // 协议版本
+}
+
+// DeriveAddress 由公钥派生地址：PKIX编码公钥的SHA-256哈希前20字节的十六进制
+func DeriveAddress(pub *rsa.PublicKey) (string, error) {
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return "", fmt.Errorf("编码公钥失败: %v", err)
+	}
+	h := sha256.Sum256(der)
+	return hex.EncodeToString(h[:20]), nil
+}
+
+// LoadOrGenerateKey 从PEM文件加载节点私钥；path为空时若给出seed则由种子确定性地派生（仅用于演示），
+// 否则生成临时的2048位密钥
+func LoadOrGenerateKey(path, seed string) (*rsa.PrivateKey, error) {
+	if path != "" && seed != "" {
+		return nil, fmt.Errorf("不能同时指定私钥文件与种子")
+	}
+	if seed != "" {
+		key, err := signer.DeriveKeyFromSeed([]byte(seed), signer.KeyAlgoRSA)
+		if err != nil {
+			return nil, err
+		}
+		return key.(*rsa.PrivateKey), nil
+	}
+	if path == "" {
+		key, err := rsa.GenerateKey(rand.Reader, 2048)
+		if err != nil {
+			return nil, fmt.Errorf("生成节点密钥失败: %v", err)
+		}
+		return key, nil
+	}
+
+	return signer.LoadRSAKey(path)
+}
+
+// SetIdentity 设置节点ID与身份签名器，签名器的公钥须为RSA公钥
+func (n *Network) SetIdentity(nodeID string, s signer.Signer) {
+	n.Lock()
+	defer n.Unlock()
+
+	n.nodeID = nodeID
+	n.identitySigner = s
+}
+
+// Identity 返回节点的公开身份
+func (n *Network) Identity() (Identity, error) {
+	n.RLock()
+	defer n.RUnlock()
+
+	if n.identitySigner == nil {
+		return Identity{}, fmt.Errorf("节点未设置身份密钥")
+	}
+
+	pub, ok := n.identitySigner.Public().(*rsa.PublicKey)
+	if !ok {
+		return Identity{}, fmt.Errorf("节点身份公钥不是RSA公钥")
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return Identity{}, fmt.Errorf("编码公钥失败: %v", err)
+	}
+	address, err := DeriveAddress(pub)
+	if err != nil {
+		return Identity{}, err
+	}
+
+	return Identity{
+		NodeID:          n.nodeID,
+		PublicKey:       string(pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: der})),
+		Address:         address,
+		ProtocolVersion: ProtocolVersion,
+	}, nil
+}
+
+// fetchIdentity 获取对端节点公布的身份，最多读取 maxIdentitySize 字节
+func fetchIdentity(address string) (*Identity, error) {
+	resp, err := peerClient(identityTimeout).Get(fmt.Sprintf("http://%s/identity", address))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取节点身份失败: %s", resp.Status)
+	}
+
+	var identity Identity
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxIdentitySize)).Decode(&identity); err != nil {
+		return nil, fmt.Errorf("解析节点身份失败: %v", err)
+	}
+	return &identity, nil
+}
+
+// lookupIdentity 在后台获取刚注册的节点的身份并记录，获取失败时不记录
+// 注册请求不等待对端响应，无响应的地址不会拖住注册接口
+func (n *Network) lookupIdentity(nodeID, address string) {
+	go func() {
+		if identity, err := fetchIdentity(address); err == nil {
+			n.recordIdentity(nodeID, identity)
+		}
+	}()
+}
+
+// recordIdentity 记录已注册节点的身份
+func (n *Network) recordIdentity(nodeID string, identity *Identity) {
+	n.Lock()
+	defer n.Unlock()
+
+	if node, exists := n.nodes[nodeID]; exists {
+		node.Identity = identity
+	}
+}
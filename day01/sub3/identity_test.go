@@ -0,0 +1,56 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestRegisterDoesNotWaitForIdentity(t *testing.T) {
+	release := make(chan struct{})
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-release
+		json.NewEncoder(w).Encode(Identity{NodeID: "peer", ProtocolVersion: ProtocolVersion})
+	}))
+	defer peer.Close()
+	defer close(release)
+
+	n := newTestNetwork(t, newTestChain(t))
+	server := httptest.NewServer(n.Handler())
+	defer server.Close()
+
+	// 对端迟迟不返回身份时，注册也应立即完成
+	client := &http.Client{Timeout: time.Second}
+	body := `{"node_id": "peer", "address": "` + peer.Listener.Addr().String() + `"}`
+	resp, err := client.Post(server.URL+"/nodes/register", "application/json", strings.NewReader(body))
+	if err != nil {
+		t.Fatalf("注册被获取身份阻塞: %v", err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		t.Fatalf("注册返回 %d，应为 201", resp.StatusCode)
+	}
+
+	release <- struct{}{}
+	waitFor(t, "记录节点身份", func() bool {
+		nodes := n.Nodes()
+		return len(nodes) == 1 && nodes[0].Identity != nil
+	})
+	if got := n.Nodes()[0].Identity.NodeID; got != "peer" {
+		t.Fatalf("记录的身份为 %q，应为 peer", got)
+	}
+}
+
+func TestFetchIdentityLimitsResponseSize(t *testing.T) {
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(`{"node_id": "` + strings.Repeat("x", maxIdentitySize) + `"}`))
+	}))
+	defer peer.Close()
+
+	if _, err := fetchIdentity(peer.Listener.Addr().String()); err == nil {
+		t.Fatal("超过大小限制的身份应被拒绝")
+	}
+}
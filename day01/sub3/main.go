@@ -1,57 +1,294 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"flag"
 	"fmt"
 	"os"
+	"os/signal"
+	"strings"
+	"syscall"
+	"time"
+
+	"openspace/day01/pow"
+	"openspace/day01/signer"
 )
 
+// hashRateBenchmark 未指定难度时测量算力的时长
+const hashRateBenchmark = 300 * time.Millisecond
+
 func main() {
 	// 解析命令行参数
 	port := flag.Int("port", 5000, "Port to run the server on")
 	nodeID := flag.String("id", "node1", "Node ID")
+	debug := flag.Bool("debug", false, "Enable debug-only endpoints such as /debug/fork")
+	minPeersToMine := flag.Int("min-peers-to-mine", 0, "Refuse to mine while fewer peers than this are registered (0 disables)")
+	instantMine := flag.Bool("instant-mine", false, "Allow POST /transactions/new?mine=true to mine a block right after adding the transaction (for demos)")
+	relayTxTTL := flag.Int("relay-tx-ttl", DefaultTxRelayTTL, "Forward newly accepted transactions to registered peers for up to this many hops (0 disables)")
+	observer := flag.Bool("observer", false, "Run as a read-only observer that syncs and serves queries but never mines or accepts transactions")
+	minBlockInterval := flag.Duration("min-block-interval", 0, "Minimum time between blocks (0 disables)")
+	keyFile := flag.String("key", "", "PEM file with the node's RSA private key (generated if empty)")
+	keySeed := flag.String("key-seed", "", "Derive the node's RSA key deterministically from this seed phrase (demos only, not secure)")
+	powAlgorithm := flag.String("pow", PoWSHA256, "Proof-of-work function: sha256 or memhard")
+	minTxAmount := flag.String("min-tx-amount", "0", "Reject transactions below this amount as dust (0 disables)")
+	maxChainBlocks := flag.Int("max-chain-blocks", 0, "Keep at most this many blocks with full transactions in memory (0 disables archival)")
+	archivePath := flag.String("archive", "archive.jsonl", "File that archived blocks are appended to (one JSON block per line)")
+	difficultyZeros := flag.Int("difficulty", 0, "Mining difficulty in leading hex zeros of the hash, converted to bits (0 picks one from a hash-rate benchmark and -target-block-time; a chain loaded with -data keeps its own)")
+	difficulty := flag.Int("difficulty-bits", 0, "Mining difficulty in leading zero bits, for finer steps than -difficulty (cannot be combined with it)")
+	targetBlockTime := flag.Duration("target-block-time", time.Second, "Expected time per block used to pick the difficulty when neither -difficulty nor -difficulty-bits is set and to retarget it with -retarget-interval (0 uses the built-in default)")
+	retargetInterval := flag.Int("retarget-interval", 0, "Adjust the difficulty by one bit every this many blocks to keep blocks near -target-block-time; a consensus rule, so every node needs the same value (0 keeps it fixed)")
+	maxDifficulty := flag.Int("max-difficulty", 0, "Upper bound in leading zero bits for difficulty retargeting (0 means no bound)")
+	maxMiningAttempts := flag.Int64("max-mining-attempts", 0, "Give up mining a block after this many proof attempts instead of searching indefinitely (0 disables)")
+	genesisDifficulty := flag.Int("genesis-difficulty", 0, "Mine the genesis block at this difficulty in leading zero bits (0 exempts the genesis block from proof of work)")
+	initialReward := flag.String("initial-reward", "", "Mining reward for the first block, halved every -halving-interval blocks (empty keeps the chain's setting, 1 coin by default)")
+	halvingInterval := flag.Int("halving-interval", 0, "Halve the mining reward every this many blocks (0 keeps the chain's setting, no halving by default)")
+	maxBlockTxs := flag.Int("max-block-txs", 0, "Include at most this many transactions per mined block, highest fees first when the mempool holds more (0 disables)")
+	maxMempoolSize := flag.Int("max-mempool-size", 0, "Hold at most this many pending transactions; when full, only transactions paying a higher fee than the cheapest one are accepted (0 disables)")
+	replayWindow := flag.Int("replay-window", 0, "Transactions expire this many blocks after their ref_height, so only that many blocks of transaction IDs are remembered (0 remembers all); saved with the chain and must match across nodes")
+	addressFormatName := flag.String("address-format", "", "Require transaction addresses in this format: hex or bech32 (empty accepts any non-empty string)")
+	addressPrefix := flag.String("address-prefix", "", "Address prefix: 0x for hex and os for bech32 by default")
+	strictRecipients := flag.Bool("strict-recipients", false, "Reject transactions whose recipient has never appeared on chain and is not a valid derived address")
+	mineRate := flag.Float64("mine-rate", 0, "Mine continuously at about this many blocks per minute until Ctrl+C instead of running the demo (0 disables)")
+	miner := flag.String("miner", "", "Reward address for mined blocks, including /mine without ?rewards= (required with -address-format, "+DefaultMinerAddress+" otherwise)")
+	dataPath := flag.String("data", "", "JSON file the default chain is loaded from at startup (if it exists) and saved to on exit (empty keeps the chain in memory only)")
+	peerCheckInterval := flag.Duration("peer-check-interval", 0, "Probe registered peers this often and remove those that keep failing (0 disables)")
+	peerMaxFailures := flag.Int("peer-max-failures", 3, "Remove a peer after this many consecutive failed health checks")
+	extraChains := flag.String("chains", "", "Comma-separated names of extra chains served under /chains/{name}/")
 	flag.Parse()
 
+	if _, err := lookupPoW(*powAlgorithm); err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+
+	// -difficulty 沿用按十六进制前导0计的难度，链内部按比特保存
+	if *difficultyZeros > 0 {
+		if *difficulty > 0 {
+			fmt.Println("-difficulty 与 -difficulty-bits 不能同时指定")
+			os.Exit(1)
+		}
+		*difficulty = pow.ZerosToBits(*difficultyZeros)
+	}
+	if *difficulty <= 0 {
+		*difficulty = DefaultDifficulty
+		if *targetBlockTime > 0 {
+			hashRate, err := MeasureHashRate(*powAlgorithm, hashRateBenchmark)
+			if err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+			*difficulty = SuggestDifficulty(hashRate, *targetBlockTime)
+			fmt.Printf("测得算力 %.0f H/s，按目标出块时间 %s 选择难度 %d 比特\n", hashRate, *targetBlockTime, *difficulty)
+		}
+	}
+
+	dustLimit, err := ParseAmount(*minTxAmount)
+	if err != nil || dustLimit < 0 {
+		fmt.Printf("无效的最小交易金额: %s\n", *minTxAmount)
+		os.Exit(1)
+	}
+
+	var reward Amount
+	if *initialReward != "" {
+		reward, err = ParseAmount(*initialReward)
+		if err != nil || reward <= 0 {
+			fmt.Printf("无效的初始挖矿奖励: %s\n", *initialReward)
+			os.Exit(1)
+		}
+	}
+
+	addressFormat, err := ParseAddressFormat(*addressFormatName, *addressPrefix)
+	if err != nil {
+		fmt.Println(err)
+		os.Exit(1)
+	}
+	// 默认的奖励地址不符合任何地址格式，启用地址格式时奖励须发到有效的地址，否则永远无法花费
+	if *miner == "" {
+		if addressFormat != nil {
+			fmt.Printf("使用 -address-format 时须用 -miner 指定有效的 %s 奖励地址\n", *addressFormatName)
+			os.Exit(1)
+		}
+		*miner = DefaultMinerAddress
+	}
+	if err := validateRewardShares([]RewardShare{{Address: *miner, Fraction: 1}}, addressFormat); err != nil {
+		fmt.Printf("无效的 -miner: %v\n", err)
+		os.Exit(1)
+	}
+
+	// 加载或生成节点身份密钥
+	identityKey, err := LoadOrGenerateKey(*keyFile, *keySeed)
+	if err != nil {
+		fmt.Printf("加载节点密钥失败: %v\n", err)
+		os.Exit(1)
+	}
+
 	// 创建网络和区块链
 	network := NewNetwork()
+	network.SetIdentity(*nodeID, signer.NewKeySigner(identityKey))
+	network.DebugEnabled = *debug
+	network.InstantMineEnabled = *instantMine
+	network.MinPeersToMine = *minPeersToMine
+	network.ObserverMode = *observer
+	network.TxRelayTTL = *relayTxTTL
+	network.MinerAddress = *miner
+
+	// 默认链保存在数据文件中，已归档的区块保存在归档文件中
+	// 从存储恢复默认链，数据损坏或链无效时拒绝启动，以免覆盖原有数据
+	store := NewFileStoreWithChain(*dataPath, *archivePath)
+	loaded := false
+	if *dataPath != "" {
+		bc, err := LoadBlockchain(store)
+		switch {
+		case err == nil:
+			network.SetBlockchain(DefaultChainName, bc)
+			loaded = true
+			fmt.Printf("已从 %s 加载区块链（%d 个区块）\n", *dataPath, len(bc.Chain))
+		case !errors.Is(err, ErrChainNotSaved):
+			fmt.Printf("加载区块链失败: %v\n", err)
+			os.Exit(1)
+		}
+	}
+
+	// 额外的区块链与默认链使用相同的配置
+	for _, name := range strings.Split(*extraChains, ",") {
+		if name = strings.TrimSpace(name); name == "" {
+			continue
+		}
+		if err := network.AddChain(name, NewBlockchain()); err != nil {
+			fmt.Println(err)
+			os.Exit(1)
+		}
+	}
+	for _, name := range network.ChainNames() {
+		bc := network.Blockchain(name)
+		// 难度与难度调整规则是共识规则，随区块链保存；从文件加载的链沿用原有的设置，否则已有区块会不符合新的规则
+		fresh := !(loaded && name == DefaultChainName)
+		if fresh {
+			bc.Difficulty = *difficulty
+			bc.TargetBlockTime = *targetBlockTime
+			bc.RetargetInterval = *retargetInterval
+			bc.MaxDifficulty = *maxDifficulty
+		}
+		bc.MinBlockInterval = *minBlockInterval
+		bc.PoWAlgorithm = *powAlgorithm
+		bc.MinTxAmount = dustLimit
+		bc.AddressFormat = addressFormat
+		bc.StrictRecipients = *strictRecipients
+		bc.MaxChainBlocks = *maxChainBlocks
+		bc.MaxMiningAttempts = *maxMiningAttempts
+		bc.MaxBlockTransactions = *maxBlockTxs
+		bc.MaxMempoolSize = *maxMempoolSize
+		// 奖励规则随区块链保存，只在指定时覆盖，从文件加载的链沿用原有的规则
+		if reward > 0 {
+			bc.InitialReward = reward
+		}
+		if *halvingInterval > 0 {
+			bc.HalvingInterval = *halvingInterval
+		}
+		bc.Store = store
+		if fresh {
+			if err := bc.SetReplayWindow(*replayWindow); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+		if name != DefaultChainName {
+			bc.Store = NewFileStore(name + "-" + *archivePath)
+		}
 
-	// 启动HTTP服务器
-	go network.StartServer(*port)
+		// 创世区块须按最终的难度与工作量证明算法挖出；从文件加载的链保留原有的创世区块
+		if *genesisDifficulty > 0 && fresh {
+			bc.GenesisDifficulty = *genesisDifficulty
+			if err := bc.CreateGenesisBlock(); err != nil {
+				fmt.Println(err)
+				os.Exit(1)
+			}
+		}
+	}
+
+	// 启动HTTP服务器与后台任务，退出前取消ctx：服务器处理完进行中的请求后再保存区块链
+	ctx, stop := context.WithCancel(context.Background())
+	defer stop()
+	serverDone := make(chan struct{})
+	go func() {
+		defer close(serverDone)
+		if err := network.StartServer(ctx, *port); err != nil {
+			fmt.Printf("HTTP服务器出错: %v\n", err)
+			os.Exit(1)
+		}
+	}()
+
+	// 定期探测已注册的节点
+	if *peerCheckInterval > 0 {
+		go network.MonitorPeers(ctx, *peerCheckInterval, *peerMaxFailures)
+	}
 
 	// 注册自己到网络
 	if len(os.Args) > 1 && os.Args[1] == "--register" && len(os.Args) > 2 {
 		// 在实际应用中，这里应该向其他节点注册自己
 		// 这里简化为直接添加到自己的节点列表
-		network.RegisterNode(*nodeID, fmt.Sprintf("localhost:%d", *port))
+		if _, err := network.RegisterNode(*nodeID, fmt.Sprintf("localhost:%d", *port)); err != nil {
+			fmt.Printf("注册节点失败: %v\n", err)
+		}
+	}
+
+	switch {
+	case *mineRate > 0:
+		// 按目标速度持续出块，直到Ctrl+C
+		mineUntilInterrupted(network, *mineRate, *miner)
+	case *observer, *dataPath != "":
+		// 观察者节点不出块，也就不运行会挖矿的演示；
+		// 演示会篡改区块以展示校验，持久化的链上同样不运行
+		if *dataPath != "" {
+			go saveOnInterrupt(network, *dataPath)
+		}
+		if *observer {
+			fmt.Println("观察者模式：只同步与响应查询")
+		}
+		fmt.Println("节点已启动，按Enter键退出...")
+		fmt.Scanln()
+	default:
+		// 演示区块链功能
+		demoBlockchain(network.Blockchain(DefaultChainName), *miner)
 	}
 
-	// 演示区块链功能
-	demoBlockchain(network.blockchain)
+	stop()
+	<-serverDone
+
+	if *dataPath != "" {
+		if err := saveChain(network, *dataPath); err != nil {
+			fmt.Printf("保存区块链失败: %v\n", err)
+			os.Exit(1)
+		}
+	}
 }
 
-func demoBlockchain(bc *Blockchain) {
+func demoBlockchain(bc *Blockchain, miner string) {
+	// 先为Alice挖矿，获得可用于转账的余额
+	fmt.Println("为Alice挖矿以获得初始余额...")
+	mine(bc, "Alice")
+	mine(bc, "Alice")
+
 	// 创建一些交易
-	fmt.Println("创建交易...")
-	bc.CreateTransaction("Alice", "Bob", 1.5)
-	bc.CreateTransaction("Bob", "Charlie", 2.3)
+	fmt.Println("\n创建交易...")
+	createTransaction(bc, Transaction{Sender: "Alice", Recipient: "Bob", Amount: 3 * Coin / 2})
+	createTransaction(bc, Transaction{Sender: "Alice", Recipient: "Charlie", Amount: 3 * Coin / 10, Memo: "发票 #2024-001"})
 
 	// 挖矿（创建新区块）
 	fmt.Println("\n开始挖矿...")
-	lastProof := bc.GetLastBlock().Proof
-	_ = ProofOfWork(lastProof) // 计算工作量证明
-	bc.Mine("miner-address")
+	mine(bc, miner)
 
 	// 创建更多交易
 	fmt.Println("\n创建更多交易...")
-	bc.CreateTransaction("Charlie", "Alice", 0.7)
-	bc.CreateTransaction("Alice", "David", 0.3)
+	createTransaction(bc, Transaction{Sender: "Bob", Recipient: "Charlie", Amount: 7 * Coin / 10})
+	createTransaction(bc, Transaction{Sender: "Charlie", Recipient: "David", Amount: 3 * Coin / 10})
+	createTransaction(bc, Transaction{Sender: "David", Recipient: "Alice", Amount: 5 * Coin}) // 余额不足，会被拒绝
 
 	// 再次挖矿
 	fmt.Println("\n再次挖矿...")
-	lastProof = bc.GetLastBlock().Proof
-	_ = ProofOfWork(lastProof) // 计算工作量证明
-	bc.Mine("miner-address")
+	mine(bc, miner)
 
 	// 打印区块链信息
 	fmt.Println("\n区块链信息:")
@@ -64,9 +301,9 @@ func demoBlockchain(bc *Blockchain) {
 	fmt.Println("\n验证区块链是否有效:", bc.IsChainValid())
 
 	// 尝试篡改区块链
-	if len(bc.Chain) > 1 {
+	if len(bc.Chain) > 1 && len(bc.Chain[1].Transactions) > 0 {
 		// 修改第二个区块中的交易
-		bc.Chain[1].Transactions[0].Amount = 100.0
+		bc.Chain[1].Transactions[0].Amount = 100 * Coin
 		// 重新计算哈希值（但不会更新后续区块的PreviousHash）
 		bc.Chain[1].Hash = bc.Chain[1].CalculateHash()
 	}
@@ -78,3 +315,60 @@ func demoBlockchain(bc *Blockchain) {
 	fmt.Println("\n按Enter键退出...")
 	fmt.Scanln()
 }
+
+// mineUntilInterrupted 按每分钟blocksPerMinute个区块持续挖矿，直到收到Ctrl+C或SIGTERM
+func mineUntilInterrupted(network *Network, blocksPerMinute float64, miner string) {
+	c := network.chain(DefaultChainName)
+	c.Lock()
+	c.blockchain.MinBlockInterval = max(c.blockchain.MinBlockInterval, blockIntervalForRate(blocksPerMinute))
+	fmt.Printf("持续挖矿：每分钟约 %g 个区块（出块间隔 %s），按Ctrl+C停止...\n", blocksPerMinute, c.blockchain.MinBlockInterval)
+	c.Unlock()
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	if err := network.MineContinuously(ctx, miner); err != nil {
+		fmt.Printf("持续挖矿失败: %v\n", err)
+	}
+	fmt.Println("已停止挖矿")
+}
+
+// saveChain 把默认链保存到其存储（path指定的数据文件）
+func saveChain(network *Network, path string) error {
+	blocks, err := network.SaveChain(DefaultChainName)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("区块链（%d 个区块）已保存到 %s\n", blocks, path)
+	return nil
+}
+
+// saveOnInterrupt 收到Ctrl+C或SIGTERM时保存默认链后退出
+func saveOnInterrupt(network *Network, path string) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	<-ctx.Done()
+
+	if err := saveChain(network, path); err != nil {
+		fmt.Printf("保存区块链失败: %v\n", err)
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+// mine 为miner挖出一个区块，失败时打印原因
+func mine(bc *Blockchain, miner string) {
+	if _, err := bc.Mine(miner); err != nil {
+		fmt.Printf("挖矿未完成: %v\n", err)
+	}
+}
+
+// createTransaction 创建交易并打印结果
+func createTransaction(bc *Blockchain, tx Transaction) {
+	tx.Timestamp = time.Now().UnixNano()
+	tx.RefHeight = bc.GetLastBlock().Index
+	if _, err := bc.AddTransaction(tx); err != nil {
+		fmt.Printf("交易 %s -> %s (%s) 被拒绝: %v\n", tx.Sender, tx.Recipient, tx.Amount, err)
+		return
+	}
+	fmt.Printf("交易 %s -> %s (%s) 已加入交易池\n", tx.Sender, tx.Recipient, tx.Amount)
+}
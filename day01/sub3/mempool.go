@@ -0,0 +1,198 @@
+package main
+
+import (
+	"cmp"
+	"errors"
+	"slices"
+	"time"
+)
+
+// ErrMempoolFull 交易池已达到 MaxMempoolSize，且新交易的手续费不高于池中最低的手续费
+var ErrMempoolFull = errors.New("交易池已满")
+
+// DefaultStaleAfter 交易在交易池中等待多久后视为滞留（GET /transactions/stale 的默认阈值）
+const DefaultStaleAfter = 10 * time.Minute
+
+// MergeMempools 合并本地与对端的待处理交易
+// 先按交易ID去重；交易尚无nonce，同一发送方的交易在合计超出余额时相互冲突，
+// 此时按手续费从高到低（相同时按创建时间从早到晚）依次在state上校验，保留仍然有效的交易。
+// 返回的交易保持本地在前、对端在后的原有顺序，state不会被修改
+func MergeMempools(local, remote []Transaction, state *State) []Transaction {
+	seen := make(map[string]bool, len(local)+len(remote))
+	var candidates []Transaction
+	for _, tx := range append(append([]Transaction{}, local...), remote...) {
+		id := tx.ID()
+		if seen[id] {
+			continue
+		}
+		seen[id] = true
+		candidates = append(candidates, tx)
+	}
+
+	// 按优先级决定冲突时保留哪些交易
+	byPriority := make([]int, len(candidates))
+	for i := range byPriority {
+		byPriority[i] = i
+	}
+	slices.SortStableFunc(byPriority, func(a, b int) int {
+		if c := cmp.Compare(candidates[b].Fee, candidates[a].Fee); c != 0 {
+			return c
+		}
+		return cmp.Compare(candidates[a].Timestamp, candidates[b].Timestamp)
+	})
+
+	resolved := state.Clone()
+	keep := make([]bool, len(candidates))
+	for _, i := range byPriority {
+		if err := ValidateTransaction(candidates[i], resolved); err != nil {
+			continue
+		}
+		resolved.applyTransaction(candidates[i])
+		keep[i] = true
+	}
+
+	merged := make([]Transaction, 0, len(candidates))
+	for i, tx := range candidates {
+		if keep[i] {
+			merged = append(merged, tx)
+		}
+	}
+	return merged
+}
+
+// MergePending 将对端的待处理交易合并到本地交易池，返回新增的交易数
+// 对端交易同样要满足本节点接收交易的策略（不能转给自己、最小金额、地址格式、严格接收方），冲突时按 MergeMempools 的规则解决，
+// 因此本地交易也可能被手续费更高的对端交易替换
+func (bc *Blockchain) MergePending(remote []Transaction) int {
+	accepted := make([]Transaction, 0, len(remote))
+	for _, tx := range remote {
+		if tx.Sender == tx.Recipient || tx.Amount < bc.MinTxAmount || bc.validateAddresses(tx) != nil || bc.validateRecipient(tx) != nil {
+			continue
+		}
+		accepted = append(accepted, tx)
+	}
+
+	local := make(map[string]bool, len(bc.Transactions))
+	for _, tx := range bc.Transactions {
+		local[tx.ID()] = true
+	}
+
+	bc.setPending(MergeMempools(bc.Transactions, accepted, bc.state))
+
+	added := 0
+	for _, tx := range bc.Transactions {
+		if !local[tx.ID()] {
+			added++
+		}
+	}
+	return added
+}
+
+// blockCandidates 返回打包新区块时依次尝试的待处理交易
+// 交易池未超过 MaxBlockTransactions 时保持交易池中的顺序；超过时按手续费从高到低，手续费相同时保持原有顺序
+func (bc *Blockchain) blockCandidates() []Transaction {
+	candidates := append([]Transaction{}, bc.Transactions...)
+	if bc.MaxBlockTransactions <= 0 || len(candidates) <= bc.MaxBlockTransactions {
+		return candidates
+	}
+	slices.SortStableFunc(candidates, func(a, b Transaction) int {
+		return cmp.Compare(b.Fee, a.Fee)
+	})
+	return candidates
+}
+
+// MempoolSize 返回交易池中的交易数
+func (bc *Blockchain) MempoolSize() int {
+	return len(bc.Transactions)
+}
+
+// minPendingFee 返回交易池中最低的手续费，交易池为空时返回0
+func (bc *Blockchain) minPendingFee() Amount {
+	if len(bc.Transactions) == 0 {
+		return 0
+	}
+	lowest := bc.Transactions[0].Fee
+	for _, tx := range bc.Transactions[1:] {
+		lowest = min(lowest, tx.Fee)
+	}
+	return lowest
+}
+
+// capMempool 交易数超过 MaxMempoolSize 时移除手续费最低的交易（手续费相同时先移除后到的），其余保持原有顺序
+func (bc *Blockchain) capMempool(txs []Transaction) []Transaction {
+	excess := len(txs) - bc.MaxMempoolSize
+	if bc.MaxMempoolSize <= 0 || excess <= 0 {
+		return txs
+	}
+
+	byFee := make([]int, len(txs))
+	for i := range byFee {
+		byFee[i] = i
+	}
+	slices.SortFunc(byFee, func(a, b int) int {
+		if c := cmp.Compare(txs[a].Fee, txs[b].Fee); c != 0 {
+			return c
+		}
+		return cmp.Compare(b, a)
+	})
+	evicted := make(map[int]bool, excess)
+	for _, i := range byFee[:excess] {
+		evicted[i] = true
+	}
+
+	kept := make([]Transaction, 0, bc.MaxMempoolSize)
+	for i, tx := range txs {
+		if !evicted[i] {
+			kept = append(kept, tx)
+		}
+	}
+	return kept
+}
+
+// pendingOutgoing 返回交易池中由address发出的金额与手续费合计，超出 Amount 的上限时返回上限
+// 合并或重组放回交易池的交易没有逐笔检查余额，合计可能极大，相加时不能溢出为负数
+func (bc *Blockchain) pendingOutgoing(address string) Amount {
+	var total Amount
+	for _, tx := range bc.Transactions {
+		if tx.Sender == address {
+			total = addCapped(addCapped(total, tx.Amount), tx.Fee)
+		}
+	}
+	return total
+}
+
+// setPending 替换交易池并维护到达时间：仍在池中的交易保留原来的到达时间，新进入的交易记为当前时间
+// 链重组时放回交易池的交易视为重新到达；超出 MaxMempoolSize 的部分按 capMempool 移除
+func (bc *Blockchain) setPending(txs []Transaction) {
+	txs = bc.capMempool(txs)
+	now := time.Now()
+	arrivals := make(map[string]time.Time, len(txs))
+	for _, tx := range txs {
+		id := tx.ID()
+		if t, ok := bc.arrivals[id]; ok {
+			arrivals[id] = t
+		} else {
+			arrivals[id] = now
+		}
+	}
+	bc.Transactions = txs
+	bc.arrivals = arrivals
+}
+
+// ArrivalTime 返回待处理交易进入交易池的时间，交易不在交易池中时返回false
+func (bc *Blockchain) ArrivalTime(txID string) (time.Time, bool) {
+	t, ok := bc.arrivals[txID]
+	return t, ok
+}
+
+// StaleTransactions 返回在交易池中等待超过olderThan的交易，按交易池中的顺序
+// 长时间未被打包的交易通常是手续费过低，或在当前状态下已无效（如余额不足）
+func (bc *Blockchain) StaleTransactions(olderThan time.Duration) []Transaction {
+	stale := []Transaction{}
+	for _, tx := range bc.Transactions {
+		if t, ok := bc.arrivals[tx.ID()]; ok && time.Since(t) > olderThan {
+			stale = append(stale, tx)
+		}
+	}
+	return stale
+}
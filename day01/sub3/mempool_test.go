@@ -0,0 +1,158 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// newFullMempool 创建交易池上限为size的区块链，并用size笔手续费为fee的交易填满交易池
+func newFullMempool(t *testing.T, size int, fee Amount) *Blockchain {
+	t.Helper()
+	bc := newTestChain(t)
+	bc.MaxMempoolSize = size
+	mustMine(t, bc, "alice")
+	for i := 0; i < size; i++ {
+		tx := transfer(bc, "alice", fmt.Sprintf("bob%d", i), 1)
+		tx.Fee = fee
+		mustAddTransaction(t, bc, tx)
+	}
+	return bc
+}
+
+func TestMempoolFullRejectsTransaction(t *testing.T) {
+	bc := newFullMempool(t, 3, 0)
+
+	tx := transfer(bc, "alice", "carol", 1)
+	if _, err := bc.AddTransaction(tx); !errors.Is(err, ErrMempoolFull) {
+		t.Fatalf("交易池已满时的错误为 %v，应为 ErrMempoolFull", err)
+	}
+	if got := bc.MempoolSize(); got != 3 {
+		t.Fatalf("交易池有 %d 笔交易，应保持 3", got)
+	}
+}
+
+func TestMempoolFullRequiresHigherFee(t *testing.T) {
+	bc := newFullMempool(t, 2, 10)
+
+	tx := transfer(bc, "alice", "carol", 1)
+	tx.Fee = 10
+	if _, err := bc.AddTransaction(tx); !errors.Is(err, ErrMempoolFull) {
+		t.Fatalf("手续费与池中最低相同的交易的错误为 %v，应为 ErrMempoolFull", err)
+	}
+	tx.Fee = 11
+	mustAddTransaction(t, bc, tx)
+	if got := bc.MempoolSize(); got != 2 {
+		t.Fatalf("交易池有 %d 笔交易，不应超过上限 2", got)
+	}
+}
+
+func TestMempoolEvictsLowestFee(t *testing.T) {
+	bc := newTestChain(t)
+	bc.MaxMempoolSize = 3
+	mustMine(t, bc, "alice")
+	for i, fee := range []Amount{5, 1, 1} {
+		tx := transfer(bc, "alice", fmt.Sprintf("bob%d", i), 1)
+		tx.Fee = fee
+		mustAddTransaction(t, bc, tx)
+	}
+
+	tx := transfer(bc, "alice", "carol", 1)
+	tx.Fee = 2
+	mustAddTransaction(t, bc, tx)
+
+	// 手续费相同时后到的先被移除：bob2 被替换
+	var recipients []string
+	for _, pending := range bc.Transactions {
+		recipients = append(recipients, pending.Recipient)
+	}
+	if fmt.Sprint(recipients) != "[bob0 bob1 carol]" {
+		t.Fatalf("交易池中的接收方为 %v，应为 [bob0 bob1 carol]", recipients)
+	}
+}
+
+func TestMempoolFullHTTPStatus(t *testing.T) {
+	bc := newFullMempool(t, 1, 0)
+	n := newTestNetwork(t, bc)
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	tx := transfer(bc, "alice", "carol", 1)
+	if status := postJSON(t, n, "/transactions/new", tx, &body); status != http.StatusServiceUnavailable {
+		t.Fatalf("交易池已满时返回 %d，应为 503", status)
+	}
+	if body.Code != "mempool_full" {
+		t.Fatalf("错误码为 %q，应为 mempool_full", body.Code)
+	}
+}
+
+// recipientsOf 返回交易的接收方，便于比较交易序列
+func recipientsOf(txs []Transaction) []string {
+	recipients := make([]string, len(txs))
+	for i, tx := range txs {
+		recipients[i] = tx.Recipient
+	}
+	return recipients
+}
+
+func TestMergeMempools(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	mustMine(t, bc, "dave")
+
+	withFee := func(tx Transaction, fee Amount) Transaction {
+		tx.Fee = fee
+		return tx
+	}
+	toBob := transfer(bc, "alice", "bob", Coin/4)
+	toCarol := transfer(bc, "dave", "carol", Coin/4)
+	// alice 的余额只够其中一笔，二者冲突
+	cheap := transfer(bc, "alice", "erin", Coin*3/4)
+	pricey := withFee(transfer(bc, "alice", "frank", Coin*3/4), 10)
+
+	tests := []struct {
+		name          string
+		local, remote []Transaction
+		want          []string
+	}{
+		{"duplicate", []Transaction{toBob}, []Transaction{toBob, toCarol}, []string{"bob", "carol"}},
+		{"non-conflicting", []Transaction{toBob}, []Transaction{toCarol}, []string{"bob", "carol"}},
+		{"conflict keeps higher fee", []Transaction{cheap}, []Transaction{pricey}, []string{"frank"}},
+		{"conflict keeps local when it pays more", []Transaction{pricey}, []Transaction{cheap}, []string{"frank"}},
+		{"same sender within balance", []Transaction{toBob}, []Transaction{cheap}, []string{"bob", "erin"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			merged := MergeMempools(tt.local, tt.remote, bc.state)
+			if got := recipientsOf(merged); fmt.Sprint(got) != fmt.Sprint(tt.want) {
+				t.Fatalf("合并后的接收方为 %v，应为 %v", got, tt.want)
+			}
+		})
+	}
+	if got := bc.GetBalance("alice"); got != miningReward {
+		t.Fatalf("合并后 alice 的余额为 %s，state 不应被修改", got)
+	}
+}
+
+func TestTransactionsSyncEndpoint(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	local := transfer(bc, "alice", "bob", 1)
+	mustAddTransaction(t, bc, local)
+	n := newTestNetwork(t, bc)
+
+	remote := []Transaction{local, transfer(bc, "alice", "carol", 1), transfer(bc, "alice", "alice", 1)}
+	var body struct {
+		Added   int `json:"added"`
+		Pending int `json:"pending_transactions"`
+	}
+	if status := postJSON(t, n, "/transactions/sync", map[string][]Transaction{"transactions": remote}, &body); status != http.StatusOK {
+		t.Fatalf("/transactions/sync 返回 %d，应为 200", status)
+	}
+	// 重复的交易被去重，转给自己的交易不符合接收策略
+	if body.Added != 1 || body.Pending != 2 {
+		t.Fatalf("响应为 %+v，应新增1笔、共2笔待处理交易", body)
+	}
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+)
+
+// hashLeaf 计算Merkle树叶子节点的哈希
+func hashLeaf(data []byte) string {
+	h := sha256.Sum256(data)
+	return hex.EncodeToString(h[:])
+}
+
+// hashPair 计算Merkle树中两个子节点的父节点哈希
+func hashPair(left, right string) string {
+	h := sha256.Sum256([]byte(left + right))
+	return hex.EncodeToString(h[:])
+}
+
+// merkleLevels 由叶子哈希逐层构建Merkle树，levels[0] 为叶子层，最后一层只有根；没有叶子时返回nil
+// 节点数为奇数的层在计算上一层时复制最后一个节点（复制的节点不计入levels，也不修改leaves）
+func merkleLevels(leaves []string) [][]string {
+	if len(leaves) == 0 {
+		return nil
+	}
+
+	levels := [][]string{leaves}
+	for level := leaves; len(level) > 1; {
+		next := make([]string, 0, (len(level)+1)/2)
+		for i := 0; i < len(level); i += 2 {
+			next = append(next, hashPair(level[i], level[min(i+1, len(level)-1)]))
+		}
+		levels = append(levels, next)
+		level = next
+	}
+	return levels
+}
+
+// merkleRoot 由叶子哈希计算Merkle根，没有叶子时为空数据的哈希
+func merkleRoot(leaves []string) string {
+	levels := merkleLevels(leaves)
+	if levels == nil {
+		return hashLeaf(nil)
+	}
+	return levels[len(levels)-1][0]
+}
+
+// MerkleStep Merkle证明中的一步：与当前哈希相邻的兄弟节点
+type MerkleStep struct {
+	Hash string `json:"hash"` // 兄弟节点的哈希
+	Left bool   `json:"left"` // 兄弟节点是否在左侧
+}
+
+// merkleProof 返回第index个叶子到Merkle根的证明路径，与merkleRoot的构造方式一致
+func merkleProof(leaves []string, index int) []MerkleStep {
+	levels := merkleLevels(leaves)
+	var proof []MerkleStep
+	for _, level := range levels[:max(len(levels)-1, 0)] {
+		sibling := min(index^1, len(level)-1) // 奇数层的最后一个节点与自身的副本配对
+		proof = append(proof, MerkleStep{Hash: level[sibling], Left: index%2 == 1})
+		index /= 2
+	}
+	return proof
+}
+
+// verifyMerkleProof 沿证明路径由叶子哈希重新计算Merkle根，并与root比较
+func verifyMerkleProof(leaf string, proof []MerkleStep, root string) bool {
+	hash := leaf
+	for _, step := range proof {
+		if step.Left {
+			hash = hashPair(step.Hash, hash)
+		} else {
+			hash = hashPair(hash, step.Hash)
+		}
+	}
+	return hash == root
+}
+
+// txIDs 返回交易ID列表，即交易Merkle树的叶子
+func txIDs(txs []Transaction) []string {
+	ids := make([]string, len(txs))
+	for i, tx := range txs {
+		ids[i] = tx.ID()
+	}
+	return ids
+}
+
+// BuildMerkleTree 以交易ID为叶子构建Merkle树，返回根与逐层的节点哈希（与 merkleRoot 相同的构造）
+// tree[0] 为叶子层，最后一层只有根；节点数为奇数的层在计算上一层时复制最后一个节点（复制的节点不计入tree）。
+// 没有交易时根为空数据的哈希，tree 为空
+func BuildMerkleTree(txs []Transaction) (root string, tree [][]string) {
+	leaves := txIDs(txs)
+	return merkleRoot(leaves), merkleLevels(leaves)
+}
+
+// MerkleProof 返回区块中第txIndex笔交易的Merkle证明：从叶子层开始每一层的兄弟节点哈希
+// 配合交易ID、区块的 MerkleRoot 与交易位置即可由 VerifyMerkleProof 验证交易包含在区块中，无需其余交易
+func (b *Block) MerkleProof(txIndex int) ([]string, error) {
+	if b.isPruned() {
+		return nil, fmt.Errorf("区块 %d 的交易已归档", b.Index)
+	}
+	if txIndex < 0 || txIndex >= len(b.Transactions) {
+		return nil, fmt.Errorf("%w: 区块 %d 没有第 %d 笔交易", ErrTransactionNotFound, b.Index, txIndex)
+	}
+
+	steps := merkleProof(txIDs(b.Transactions), txIndex)
+	proof := make([]string, len(steps))
+	for i, step := range steps {
+		proof[i] = step.Hash
+	}
+	return proof, nil
+}
+
+// VerifyMerkleProof 沿证明路径由交易ID重新计算Merkle根并与root比较（见 verifyMerkleProof）
+// index 为交易在区块中的位置，决定每一层兄弟节点在左侧还是右侧
+func VerifyMerkleProof(txHash, root string, proof []string, index int) bool {
+	if index < 0 {
+		return false
+	}
+	steps := make([]MerkleStep, len(proof))
+	for i, hash := range proof {
+		steps[i] = MerkleStep{Hash: hash, Left: index%2 == 1}
+		index /= 2
+	}
+	return index == 0 && verifyMerkleProof(txHash, steps, root)
+}
+
+// validateMerkleRoot 校验 BlockVersionMerkle 及之后的区块记录的 MerkleRoot 与交易（或归档时保留的哈希）一致
+func validateMerkleRoot(block *Block) error {
+	if block.Version < BlockVersionMerkle {
+		return nil
+	}
+	if block.MerkleRoot != block.txHash() {
+		return fmt.Errorf("区块 %d 的Merkle根与交易不匹配", block.Index)
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package main
+
+import (
+	"fmt"
+	"testing"
+)
+
+// testTransactions 返回n笔互不相同的交易
+func testTransactions(n int) []Transaction {
+	txs := make([]Transaction, n)
+	for i := range txs {
+		txs[i] = Transaction{Sender: "alice", Recipient: fmt.Sprintf("bob%d", i), Amount: 1}
+	}
+	return txs
+}
+
+func TestBuildMerkleTreeRoot(t *testing.T) {
+	tests := []struct {
+		count int
+		root  func(ids []string) string
+	}{
+		{1, func(ids []string) string { return ids[0] }},
+		{2, func(ids []string) string { return hashPair(ids[0], ids[1]) }},
+		{3, func(ids []string) string {
+			return hashPair(hashPair(ids[0], ids[1]), hashPair(ids[2], ids[2]))
+		}},
+		{4, func(ids []string) string {
+			return hashPair(hashPair(ids[0], ids[1]), hashPair(ids[2], ids[3]))
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(fmt.Sprintf("%d txs", tt.count), func(t *testing.T) {
+			txs := testTransactions(tt.count)
+			want := tt.root(txIDs(txs))
+
+			root, tree := BuildMerkleTree(txs)
+			if root != want {
+				t.Fatalf("Merkle根为 %s，应为 %s", root, want)
+			}
+			if got := txMerkleRoot(txs); got != root {
+				t.Fatalf("txMerkleRoot 为 %s，应与 BuildMerkleTree 一致 %s", got, root)
+			}
+			if last := tree[len(tree)-1]; len(last) != 1 || last[0] != root {
+				t.Fatalf("最后一层为 %v，应只有根", last)
+			}
+		})
+	}
+}
+
+func TestBuildMerkleTreeEmpty(t *testing.T) {
+	root, tree := BuildMerkleTree(nil)
+	if root != hashLeaf(nil) || tree != nil {
+		t.Fatalf("没有交易时根为 %s、树为 %v，应为空数据的哈希与空树", root, tree)
+	}
+}
+
+func TestMerkleProof(t *testing.T) {
+	for count := 1; count <= 4; count++ {
+		block := &Block{Index: 1, Transactions: testTransactions(count)}
+		root, _ := BuildMerkleTree(block.Transactions)
+
+		for i, tx := range block.Transactions {
+			proof, err := block.MerkleProof(i)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !VerifyMerkleProof(tx.ID(), root, proof, i) {
+				t.Fatalf("%d 笔交易中第 %d 笔的证明未通过验证", count, i)
+			}
+			// 奇数层的最后一个节点与自身的副本配对，交换位置后哈希不变，只检查存在的兄弟位置
+			if i^1 < count && VerifyMerkleProof(tx.ID(), root, proof, i^1) {
+				t.Fatalf("%d 笔交易中第 %d 笔的证明在错误的位置上通过了验证", count, i)
+			}
+			if count > 1 && VerifyMerkleProof(block.Transactions[(i+1)%count].ID(), root, proof, i) {
+				t.Fatalf("%d 笔交易中第 %d 笔的证明对其他交易通过了验证", count, i)
+			}
+		}
+	}
+}
+
+func TestMerkleProofOutOfRange(t *testing.T) {
+	block := &Block{Index: 1, Transactions: testTransactions(2)}
+	for _, index := range []int{-1, 2} {
+		if _, err := block.MerkleProof(index); err == nil {
+			t.Fatalf("第 %d 笔交易不存在，应返回错误", index)
+		}
+	}
+}
@@ -0,0 +1,97 @@
+package main
+
+import (
+	"encoding/hex"
+	"time"
+
+	"openspace/day01/pow"
+)
+
+const (
+	// defaultStepAttempts 分步挖矿每次默认尝试的次数
+	defaultStepAttempts = 1000
+	// maxStepAttempts 分步挖矿每次允许的最大尝试次数
+	maxStepAttempts = 1000000
+)
+
+// mineStepSession 分步挖矿的进度，用于在课堂上逐步演示工作量证明
+// 链尾变化（如其他途径出块或链重组）后进度会重新开始
+type mineStepSession struct {
+	tipHash   string // 开始搜索时的链尾哈希
+	template  *Block // 正在求解的区块（交易与奖励在开始搜索时确定）
+	nextProof int64  // 下一个要尝试的证明
+	attempts  int64  // 累计尝试次数
+	bestProof int64  // 目前前导0比特最多的证明
+	bestHash  string // 对应的哈希值
+	bestBits  int    // 对应的前导0比特数
+}
+
+// MineStepResult 单次分步挖矿的结果
+type MineStepResult struct {
+	Found         bool   `json:"found"`           // 是否已找到满足难度的证明
+	Attempts      int64  `json:"attempts"`        // 本次尝试的次数
+	TotalAttempts int64  `json:"total_attempts"`  // 累计尝试次数
+	Difficulty    int    `json:"difficulty"`      // 目标难度（前导0比特）
+	BestProof     int64  `json:"best_proof"`      // 目前最好的证明
+	BestHash      string `json:"best_hash"`       // 目前最好的哈希
+	BestZeroBits  int    `json:"best_zero_bits"`  // 目前最好的哈希的前导0比特数
+	Block         *Block `json:"block,omitempty"` // 找到证明后挖出的区块
+}
+
+// MineStep 最多尝试maxAttempts次工作量证明，找到后立即出块
+// 未找到时保留进度，再次调用会从上次停下的位置继续；区块的交易与奖励分配在开始搜索时确定。
+// 调用方需持有该链的写锁。
+func (c *namedChain) MineStep(maxAttempts int64, shares []RewardShare) (MineStepResult, error) {
+	bc := c.blockchain
+	lastBlock := bc.GetLastBlock()
+
+	session := c.stepSession
+	if session == nil || session.tipHash != lastBlock.Hash {
+		template, err := bc.newBlockTemplate(shares)
+		if err != nil {
+			return MineStepResult{}, err
+		}
+		session = &mineStepSession{tipHash: lastBlock.Hash, template: template, bestBits: -1}
+		c.stepSession = session
+	}
+
+	block := session.template
+	result := MineStepResult{Difficulty: block.Difficulty}
+	header := block.Header()
+	started := time.Now()
+	for result.Attempts < maxAttempts {
+		header.Nonce = session.nextProof
+		sum, err := header.powSum(bc.PoWAlgorithm)
+		if err != nil {
+			return result, err
+		}
+		session.nextProof++
+		session.attempts++
+		result.Attempts++
+
+		if bits := pow.LeadingZeroBits(sum[:]); bits > session.bestBits {
+			session.bestProof, session.bestHash, session.bestBits = header.Nonce, hex.EncodeToString(sum[:]), bits
+		}
+		if session.bestBits >= block.Difficulty {
+			result.Found = true
+			break
+		}
+	}
+
+	if elapsed := time.Since(started); elapsed > 0 {
+		bc.emit(MiningEvent{Type: MiningEventHashRate, HashRate: float64(result.Attempts) / elapsed.Seconds()})
+	}
+
+	result.TotalAttempts = session.attempts
+	result.BestProof = session.bestProof
+	result.BestHash = session.bestHash
+	result.BestZeroBits = session.bestBits
+
+	if result.Found {
+		block.Nonce = session.bestProof
+		block.Hash = block.CalculateHash()
+		result.Block = bc.appendBlock(block)
+		c.stepSession = nil
+	}
+	return result, nil
+}
@@ -0,0 +1,138 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+)
+
+// PauseMining 暂停出块（如维护期间），节点仍接收交易并响应查询
+func (n *Network) PauseMining() {
+	n.Lock()
+	defer n.Unlock()
+
+	n.miningPaused = true
+}
+
+// ResumeMining 恢复出块
+func (n *Network) ResumeMining() {
+	n.Lock()
+	defer n.Unlock()
+
+	n.miningPaused = false
+}
+
+// IsMining 返回节点当前是否允许出块，观察者节点从不出块
+func (n *Network) IsMining() bool {
+	n.RLock()
+	defer n.RUnlock()
+
+	return !n.miningPaused && !n.ObserverMode
+}
+
+// ErrInsufficientPeers 已注册的节点数少于 MinPeersToMine
+var ErrInsufficientPeers = errors.New("已注册的节点数不足")
+
+// PeerCount 返回已注册的节点数，不含以本节点ID注册的自身
+func (n *Network) PeerCount() int {
+	n.RLock()
+	defer n.RUnlock()
+
+	return n.peerCount()
+}
+
+// peerCount 同PeerCount，调用方需持有读锁
+func (n *Network) peerCount() int {
+	count := 0
+	for id := range n.nodes {
+		if id != n.nodeID {
+			count++
+		}
+	}
+	return count
+}
+
+// checkCanMine 检查当前是否允许出块：未暂停且已注册的节点数不少于 MinPeersToMine
+func (n *Network) checkCanMine() error {
+	n.RLock()
+	defer n.RUnlock()
+
+	if n.miningPaused {
+		return errMiningPaused
+	}
+	if peers := n.peerCount(); peers < n.MinPeersToMine {
+		return fmt.Errorf("%w: 当前 %d 个，至少需要 %d 个", ErrInsufficientPeers, peers, n.MinPeersToMine)
+	}
+	return nil
+}
+
+// miningErrorStatus 返回挖矿失败时的HTTP状态：未找到工作量证明是节点的暂时状况（503），其余为请求错误
+func miningErrorStatus(err error) int {
+	if errors.Is(err, ErrProofNotFound) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
+
+// ChainStatus 单条区块链的概况
+type ChainStatus struct {
+	Length    int    `json:"length"`               // 区块数
+	Pending   int    `json:"pending_transactions"` // 待处理交易数
+	ChainWork string `json:"chain_work"`           // 累计工作量（期望哈希次数）的十六进制表示
+}
+
+// NodeStatus 节点的运行状态
+type NodeStatus struct {
+	NodeID   string                 `json:"node_id"`
+	Mining   bool                   `json:"mining"`   // 是否允许出块
+	Observer bool                   `json:"observer"` // 是否为只读的观察者节点
+	Peers    int                    `json:"peers"`    // 已注册的节点数（不含本节点）
+	Chains   map[string]ChainStatus `json:"chains"`
+
+	// 默认链的同步进度，同步完成前本节点的余额等查询结果可能已过时
+	Syncing       bool    `json:"syncing"`
+	CurrentHeight int     `json:"current_height"`
+	TargetHeight  int     `json:"target_height"`
+	SyncProgress  float64 `json:"sync_progress"` // 百分比
+}
+
+// Status 汇总节点的运行状态
+func (n *Network) Status() NodeStatus {
+	n.RLock()
+	status := NodeStatus{
+		NodeID:   n.nodeID,
+		Mining:   !n.miningPaused && !n.ObserverMode,
+		Observer: n.ObserverMode,
+		Peers:    n.peerCount(),
+		Chains:   make(map[string]ChainStatus, len(n.chains)),
+	}
+	chains := make([]*namedChain, 0, len(n.chains))
+	for _, c := range n.chains {
+		chains = append(chains, c)
+	}
+	n.RUnlock()
+
+	for _, c := range chains {
+		c.RLock()
+		status.Chains[c.name] = ChainStatus{
+			Length:    len(c.blockchain.Chain),
+			Pending:   len(c.blockchain.Transactions),
+			ChainWork: c.blockchain.ChainWork().Text(16),
+		}
+		c.RUnlock()
+	}
+
+	syncing, current, target := n.syncProgress.snapshot()
+	if !syncing {
+		current = status.Chains[DefaultChainName].Length - 1
+		target = current
+	}
+	status.Syncing = syncing
+	status.CurrentHeight = current
+	status.TargetHeight = target
+	status.SyncProgress = 100
+	if target > 0 && current < target {
+		status.SyncProgress = float64(current) / float64(target) * 100
+	}
+	return status
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestPauseAndResumeMiningEndpoints(t *testing.T) {
+	n := newTestNetwork(t, newTestChain(t))
+
+	var toggled struct {
+		Mining bool `json:"mining"`
+	}
+	if status := postJSON(t, n, "/mine/pause", nil, &toggled); status != http.StatusOK || toggled.Mining {
+		t.Fatalf("/mine/pause 返回 %d mining=%v，应为 200 且停止出块", status, toggled.Mining)
+	}
+	var status NodeStatus
+	getJSON(t, n, "/status", &status)
+	if status.Mining || n.IsMining() {
+		t.Fatal("暂停后 /status 与 IsMining 应报告不出块")
+	}
+
+	if code := postJSON(t, n, "/mine/resume", nil, &toggled); code != http.StatusOK || !toggled.Mining {
+		t.Fatalf("/mine/resume 返回 %d mining=%v，应为 200 且恢复出块", code, toggled.Mining)
+	}
+	getJSON(t, n, "/status", &status)
+	if !status.Mining || !n.IsMining() {
+		t.Fatal("恢复后 /status 与 IsMining 应报告出块")
+	}
+
+	if code := getJSON(t, n, "/mine/pause", nil); code != http.StatusMethodNotAllowed {
+		t.Fatalf("GET /mine/pause 返回 %d，应为 405", code)
+	}
+}
+
+func TestPausedNodeRejectsMineButAcceptsTransactions(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	n := newTestNetwork(t, bc)
+	n.PauseMining()
+
+	var body struct {
+		Code string `json:"code"`
+	}
+	if status := getJSON(t, n, "/mine", &body); status != http.StatusServiceUnavailable || body.Code != "mining_paused" {
+		t.Fatalf("暂停时 /mine 返回 %d %s，应为 503 mining_paused", status, body.Code)
+	}
+	if status := postJSON(t, n, "/transactions/new", transfer(bc, "alice", "bob", 1), nil); status != http.StatusCreated {
+		t.Fatalf("暂停时提交交易返回 %d，应为 201", status)
+	}
+	if got := pendingTransactions(n); got != 1 {
+		t.Fatalf("交易池有 %d 笔交易，应为 1", got)
+	}
+	if length := n.Status().Chains[DefaultChainName].Length; length != 2 {
+		t.Fatalf("链长为 %d，暂停时不应出块", length)
+	}
+}
+
+func TestMineContinuouslyWaitsWhilePaused(t *testing.T) {
+	n := newTestNetwork(t, newTestChain(t))
+	n.PauseMining()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	done := make(chan error, 1)
+	go func() { done <- n.MineContinuously(ctx, "miner") }()
+	t.Cleanup(func() {
+		cancel()
+		if err := <-done; err != nil {
+			t.Errorf("MineContinuously 返回 %v", err)
+		}
+	})
+
+	length := func() int { return n.Status().Chains[DefaultChainName].Length }
+	// 暂停时每秒重试一次，等待超过一次重试的时间
+	time.Sleep(1500 * time.Millisecond)
+	if got := length(); got != 1 {
+		t.Fatalf("暂停期间链长变为 %d，不应出块", got)
+	}
+
+	n.ResumeMining()
+	waitFor(t, "恢复后出块", func() bool { return length() > 1 })
+}
+
+func TestMineReturnsErrorWithoutProof(t *testing.T) {
+	bc := NewBlockchainWithDifficulty(48)
+	bc.MaxMiningAttempts = 100
+	block, err := bc.Mine("miner")
+	if !errors.Is(err, ErrProofNotFound) || block != nil {
+		t.Fatalf("Mine 返回 %v, %v，应返回 ErrProofNotFound 而不是区块", block, err)
+	}
+	if len(bc.Chain) != 1 {
+		t.Fatalf("链长为 %d，未找到工作量证明时不应出块", len(bc.Chain))
+	}
+
+	if _, err := newTestChain(t).Mine(""); err == nil {
+		t.Fatal("矿工地址为空时应返回错误")
+	}
+}
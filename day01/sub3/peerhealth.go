@@ -0,0 +1,93 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+)
+
+// DeregisterNode 移除已注册的节点及其全部地址，节点不存在时返回 ErrNodeNotFound
+func (n *Network) DeregisterNode(nodeID string) error {
+	n.Lock()
+	defer n.Unlock()
+
+	if _, ok := n.nodes[nodeID]; !ok {
+		return fmt.Errorf("%w: %s", ErrNodeNotFound, nodeID)
+	}
+	delete(n.nodes, nodeID)
+	return nil
+}
+
+// MonitorPeers 每隔interval探测一次所有已注册节点，连续maxFailures次没有响应的节点被移除，ctx被取消时返回
+// 探测请求对端默认链的 /tip：与 /chain 一样能证明节点在正常服务，但响应很小，不随链增长
+func (n *Network) MonitorPeers(ctx context.Context, interval time.Duration, maxFailures int) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			for _, id := range n.checkPeers(maxFailures) {
+				fmt.Printf("节点 %s 连续 %d 次没有响应，已移除\n", id, max(maxFailures, 1))
+			}
+		}
+	}
+}
+
+// checkPeers 并发探测所有已注册节点：任一地址响应即视为在线，更新 LastSeen 并清零失败次数；
+// 否则失败次数加1，达到maxFailures（至少为1）时移除该节点。返回被移除的节点ID
+func (n *Network) checkPeers(maxFailures int) []string {
+	n.RLock()
+	targets := make(map[string][]string, len(n.nodes))
+	for id, node := range n.nodes {
+		targets[id] = append([]string{}, node.Addresses...)
+	}
+	n.RUnlock()
+
+	// 探测期间不持有锁，不可达的节点不会阻塞注册与查询
+	path := n.chain(DefaultChainName).peerPath("/tip")
+	alive := make(map[string]bool, len(targets))
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for id, addresses := range targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for _, addr := range addresses {
+				if _, err := fetchPeerTip(addr, path); err == nil {
+					mu.Lock()
+					alive[id] = true
+					mu.Unlock()
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	n.Lock()
+	defer n.Unlock()
+
+	now := time.Now()
+	var removed []string
+	for id := range targets {
+		node, ok := n.nodes[id]
+		if !ok {
+			continue // 探测期间已被注销
+		}
+		if alive[id] {
+			node.LastSeen = now
+			node.failures = 0
+			continue
+		}
+		node.failures++
+		if node.failures >= max(maxFailures, 1) {
+			delete(n.nodes, id)
+			removed = append(removed, id)
+		}
+	}
+	return removed
+}
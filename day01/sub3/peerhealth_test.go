@@ -0,0 +1,100 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+)
+
+// startFlakyPeer 启动以bc为默认链的节点，down 为true时所有请求返回503，用于模拟停止响应的节点
+func startFlakyPeer(t *testing.T, bc *Blockchain) (string, *atomic.Bool) {
+	t.Helper()
+	var down atomic.Bool
+	handler := newTestNetwork(t, bc).Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if down.Load() {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	}))
+	t.Cleanup(server.Close)
+	return server.Listener.Addr().String(), &down
+}
+
+// nodeIDs 返回网络中已注册节点的ID
+func nodeIDs(n *Network) []string {
+	var ids []string
+	for _, node := range n.Nodes() {
+		ids = append(ids, node.ID)
+	}
+	return ids
+}
+
+func TestDeregisterNode(t *testing.T) {
+	n := newTestNetwork(t, newTestChain(t))
+	mustRegister(t, n, "peer", "127.0.0.1:5001")
+
+	var body struct {
+		Total int    `json:"total_nodes"`
+		Code  string `json:"code"`
+	}
+	if status := postJSON(t, n, "/nodes/deregister", map[string]string{"node_id": "peer"}, &body); status != http.StatusOK {
+		t.Fatalf("注销已注册的节点返回 %d，应为 200", status)
+	}
+	if body.Total != 0 || len(n.Nodes()) != 0 {
+		t.Fatalf("注销后还有 %d 个节点", len(n.Nodes()))
+	}
+
+	if status := postJSON(t, n, "/nodes/deregister", map[string]string{"node_id": "peer"}, &body); status != http.StatusNotFound {
+		t.Fatalf("注销不存在的节点返回 %d，应为 404", status)
+	}
+	if body.Code != "node_not_found" {
+		t.Fatalf("错误码为 %q，应为 node_not_found", body.Code)
+	}
+}
+
+func TestCheckPeersEvictsAfterMaxFailures(t *testing.T) {
+	n := newTestNetwork(t, newTestChain(t))
+	deadAddr, dead := startFlakyPeer(t, newTestChain(t))
+	liveAddr, _ := startFlakyPeer(t, newTestChain(t))
+	mustRegister(t, n, "dead", deadAddr)
+	mustRegister(t, n, "live", liveAddr)
+
+	if removed := n.checkPeers(2); len(removed) != 0 {
+		t.Fatalf("在线的节点被移除: %v", removed)
+	}
+
+	dead.Store(true)
+	if removed := n.checkPeers(2); len(removed) != 0 {
+		t.Fatalf("第一次探测失败后节点就被移除: %v", removed)
+	}
+	removed := n.checkPeers(2)
+	if !slices.Equal(removed, []string{"dead"}) {
+		t.Fatalf("连续两次探测失败后移除的节点为 %v，应为 [dead]", removed)
+	}
+	if ids := nodeIDs(n); !slices.Equal(ids, []string{"live"}) {
+		t.Fatalf("剩余的节点为 %v，应只有 live", ids)
+	}
+}
+
+func TestCheckPeersResetsFailuresWhenPeerRecovers(t *testing.T) {
+	n := newTestNetwork(t, newTestChain(t))
+	addr, down := startFlakyPeer(t, newTestChain(t))
+	mustRegister(t, n, "peer", addr)
+	before := n.Nodes()[0].LastSeen
+
+	down.Store(true)
+	n.checkPeers(2)
+	down.Store(false)
+	n.checkPeers(2)
+	down.Store(true)
+	if removed := n.checkPeers(2); len(removed) != 0 {
+		t.Fatalf("恢复响应后失败次数应清零，节点不应被移除: %v", removed)
+	}
+	if node := n.Nodes()[0]; !node.LastSeen.After(before) {
+		t.Fatalf("通过健康检查后 LastSeen 应更新，仍为 %v", node.LastSeen)
+	}
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+)
+
+var (
+	// ErrInvalidPeerAddress 节点地址不是有效的 host:port
+	ErrInvalidPeerAddress = errors.New("无效的节点地址")
+	// ErrDuplicatePeer 同一地址已被其他节点注册
+	ErrDuplicatePeer = errors.New("节点地址已被注册")
+	// ErrNodeNotFound 节点未注册
+	ErrNodeNotFound = errors.New("节点不存在")
+)
+
+// NormalizePeerAddress 将节点地址规范化为 host:port 形式
+// 去掉 http:// 或 https:// 前缀和末尾的斜杠，主机名转为小写，
+// localhost 与 ::1 统一为 127.0.0.1，使同一节点的不同写法得到相同的地址。
+func NormalizePeerAddress(address string) (string, error) {
+	addr := strings.TrimSpace(address)
+	lower := strings.ToLower(addr)
+	for _, scheme := range []string{"http://", "https://"} {
+		if strings.HasPrefix(lower, scheme) {
+			addr = addr[len(scheme):]
+			break
+		}
+	}
+	addr = strings.TrimSuffix(addr, "/")
+
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return "", fmt.Errorf("%w %q: %v", ErrInvalidPeerAddress, address, err)
+	}
+	portNum, err := strconv.Atoi(port)
+	if err != nil || portNum < 1 || portNum > 65535 {
+		return "", fmt.Errorf("%w %q: 端口必须是1-65535", ErrInvalidPeerAddress, address)
+	}
+
+	host = strings.ToLower(host)
+	if ip := net.ParseIP(host); ip != nil {
+		if ip.IsLoopback() {
+			ip = net.IPv4(127, 0, 0, 1)
+		}
+		host = ip.String()
+	} else if host == "localhost" {
+		host = "127.0.0.1"
+	} else if !validHostname(host) {
+		return "", fmt.Errorf("%w %q: 主机名无效", ErrInvalidPeerAddress, address)
+	}
+
+	return net.JoinHostPort(host, strconv.Itoa(portNum)), nil
+}
+
+// validHostname 判断是否为合法的DNS主机名（字母、数字、连字符，以点分隔）
+func validHostname(host string) bool {
+	if host == "" || len(host) > 253 {
+		return false
+	}
+	for _, label := range strings.Split(host, ".") {
+		if label == "" || len(label) > 63 || label[0] == '-' || label[len(label)-1] == '-' {
+			return false
+		}
+		for _, c := range label {
+			if !(c >= 'a' && c <= 'z' || c >= '0' && c <= '9' || c == '-') {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestNormalizePeerAddress(t *testing.T) {
+	tests := []struct {
+		in, want string
+	}{
+		{"127.0.0.1:5000", "127.0.0.1:5000"},
+		{"localhost:5000", "127.0.0.1:5000"},
+		{"LOCALHOST:5000", "127.0.0.1:5000"},
+		{"http://localhost:5000", "127.0.0.1:5000"},
+		{"HTTPS://Example.COM:443/", "example.com:443"},
+		{"  node-1.example.com:80  ", "node-1.example.com:80"},
+		{"[::1]:5000", "127.0.0.1:5000"},
+		{"127.0.0.2:5000", "127.0.0.1:5000"},
+		{"[2001:DB8::1]:5000", "[2001:db8::1]:5000"},
+		{"10.0.0.1:05000", "10.0.0.1:5000"},
+	}
+	for _, tt := range tests {
+		got, err := NormalizePeerAddress(tt.in)
+		if err != nil || got != tt.want {
+			t.Errorf("NormalizePeerAddress(%q) = %q, %v，应为 %q", tt.in, got, err, tt.want)
+		}
+	}
+}
+
+func TestNormalizePeerAddressRejectsInvalid(t *testing.T) {
+	for _, address := range []string{
+		"",
+		"localhost",
+		"http://",
+		"127.0.0.1:0",
+		"127.0.0.1:65536",
+		"127.0.0.1:http",
+		"bad_host:5000",
+		"-node.example.com:5000",
+		"node..example.com:5000",
+		"ftp://example.com:21",
+	} {
+		if got, err := NormalizePeerAddress(address); !errors.Is(err, ErrInvalidPeerAddress) {
+			t.Errorf("NormalizePeerAddress(%q) = %q, %v，应返回 ErrInvalidPeerAddress", address, got, err)
+		}
+	}
+}
+
+func TestRegisterNodeDeduplicatesAddresses(t *testing.T) {
+	n := NewNetwork()
+	mustRegister(t, n, "node2", "localhost:5001")
+
+	// 同一节点用另一种写法重复注册，不产生重复记录
+	if addr, err := n.RegisterNode("node2", "http://127.0.0.1:5001/"); err != nil || addr != "127.0.0.1:5001" {
+		t.Fatalf("重复注册返回 %q, %v", addr, err)
+	}
+	if nodes := n.Nodes(); len(nodes) != 1 || len(nodes[0].Addresses) != 1 {
+		t.Fatalf("节点列表为 %+v，应只有一个地址", nodes)
+	}
+
+	// 其他节点不能注册同一地址的另一种写法
+	if _, err := n.RegisterNode("node3", "[::1]:5001"); !errors.Is(err, ErrDuplicatePeer) {
+		t.Fatalf("其他节点注册同一地址返回 %v，应为 ErrDuplicatePeer", err)
+	}
+}
+
+func TestRegisterEndpointStatusCodes(t *testing.T) {
+	n := NewNetwork()
+	mustRegister(t, n, "node2", "127.0.0.1:5001")
+
+	tests := []struct {
+		name    string
+		address string
+		status  int
+		code    string
+	}{
+		{"invalid address", "not an address", http.StatusBadRequest, "invalid_peer_address"},
+		{"duplicate address", "localhost:5001", http.StatusConflict, "duplicate_peer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				Code string `json:"code"`
+			}
+			req := map[string]string{"node_id": "node3", "address": tt.address}
+			if status := postJSON(t, n, "/nodes/register", req, &body); status != tt.status || body.Code != tt.code {
+				t.Fatalf("注册返回 %d %s，应为 %d %s", status, body.Code, tt.status, tt.code)
+			}
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+)
+
+// txRelayTimeout 向对端转发交易的超时时间
+const txRelayTimeout = 3 * time.Second
+
+// DefaultTxRelayTTL 新建网络时交易的转发跳数：客户端提交给本节点的交易转发给直接相连的节点，
+// 对端收到的交易标记为已转发（?ttl=0），不再转发
+const DefaultTxRelayTTL = 1
+
+// relayHops 返回本节点接受交易后还可转发的跳数
+// 客户端直接提交的交易可转发 TxRelayTTL 跳；对端转发来的交易带有 ?ttl=，按它与 TxRelayTTL 中较小的计算
+func (n *Network) relayHops(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("ttl")
+	if v == "" {
+		return n.TxRelayTTL, nil
+	}
+	ttl, err := strconv.Atoi(v)
+	if err != nil || ttl < 0 {
+		return 0, errors.New("Invalid ttl")
+	}
+	return min(ttl, n.TxRelayTTL), nil
+}
+
+// BroadcastTransaction 把交易异步转发给所有已注册节点默认链的 /transactions/new，按 TxRelayTTL 跳计算，
+// 与客户端直接提交给本节点的交易相同；对端收到的交易带有剩余跳数，为0时不再转发
+func (n *Network) BroadcastTransaction(tx Transaction) {
+	n.broadcastTransaction(n.chain(DefaultChainName), tx, n.TxRelayTTL)
+}
+
+// broadcastTransaction 把新接受的交易异步转发给所有已注册节点同名链的 /transactions/new，对端还可再转发 hops-1 跳
+// 对端按交易ID去重，已在交易池中的交易不会被再次转发，因此转发环路会在一轮后停止；
+// 不可达或拒绝交易的节点被忽略
+func (n *Network) broadcastTransaction(c *namedChain, tx Transaction, hops int) {
+	if hops <= 0 {
+		return
+	}
+	body, err := json.Marshal(tx)
+	if err != nil {
+		return
+	}
+
+	path := c.peerPath("/transactions/new") + "?ttl=" + strconv.Itoa(hops-1)
+	client := peerClient(txRelayTimeout)
+	for _, addr := range n.peerAddresses() {
+		go func() {
+			resp, err := client.Post(fmt.Sprintf("http://%s%s", addr, path), "application/json", bytes.NewReader(body))
+			if err != nil {
+				return
+			}
+			resp.Body.Close()
+		}()
+	}
+}
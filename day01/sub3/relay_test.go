@@ -0,0 +1,187 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// statusRecorder 记录处理器写出的状态码
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+}
+
+func (r *statusRecorder) WriteHeader(status int) {
+	r.status = status
+	r.ResponseWriter.WriteHeader(status)
+}
+
+// startRecordingPeer 与 startPeer 相同地启动节点n，并把收到的 /transactions/new 请求的状态码依次发送到返回的通道
+func startRecordingPeer(t *testing.T, n *Network) (string, <-chan int) {
+	t.Helper()
+	statuses := make(chan int, 10)
+	handler := n.Handler()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if !strings.HasPrefix(r.URL.Path, "/transactions/new") {
+			handler.ServeHTTP(w, r)
+			return
+		}
+		rec := &statusRecorder{ResponseWriter: w, status: http.StatusOK}
+		handler.ServeHTTP(rec, r)
+		statuses <- rec.status
+	}))
+	t.Cleanup(server.Close)
+	return server.Listener.Addr().String(), statuses
+}
+
+// nextStatus 等待下一个请求的状态码
+func nextStatus(t *testing.T, statuses <-chan int) int {
+	t.Helper()
+	select {
+	case status := <-statuses:
+		return status
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待转发的交易超时")
+		return 0
+	}
+}
+
+func TestRelayTransactionReachesPeerWithoutLooping(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+
+	a, b := newTestNetwork(t, bc), newTestNetwork(t, copyChain(t, bc))
+	a.TxRelayTTL, b.TxRelayTTL = 2, 2
+	addrA, statusesA := startRecordingPeer(t, a)
+	addrB, statusesB := startRecordingPeer(t, b)
+	mustRegister(t, a, "b", addrB)
+	mustRegister(t, b, "a", addrA)
+
+	if status := postTransaction(t, addrA, transfer(bc, "alice", "bob", 1)); status != http.StatusCreated {
+		t.Fatalf("提交交易返回 %d，应为 201", status)
+	}
+	nextStatus(t, statusesA) // 客户端的提交
+
+	// A转发给B（还可再转发1跳），B接受后转发回A，A按交易ID去重拒绝，转发在此停止
+	if status := nextStatus(t, statusesB); status != http.StatusCreated {
+		t.Fatalf("B接受转发的交易返回 %d，应为 201", status)
+	}
+	if status := nextStatus(t, statusesA); status != http.StatusBadRequest {
+		t.Fatalf("转发回A的交易返回 %d，应作为重复交易被拒绝", status)
+	}
+	if got := pendingTransactions(b); got != 1 {
+		t.Fatalf("B的交易池有 %d 笔交易，应为 1", got)
+	}
+	if got := pendingTransactions(a); got != 1 {
+		t.Fatalf("A的交易池有 %d 笔交易，应为 1", got)
+	}
+}
+
+func TestRelayTransactionStopsAtTTL(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+
+	a, b := newTestNetwork(t, bc), newTestNetwork(t, copyChain(t, bc))
+	a.TxRelayTTL, b.TxRelayTTL = 1, 1
+	addrA, statusesA := startRecordingPeer(t, a)
+	addrB, statusesB := startRecordingPeer(t, b)
+	mustRegister(t, a, "b", addrB)
+	mustRegister(t, b, "a", addrA)
+
+	postTransaction(t, addrA, transfer(bc, "alice", "bob", 1))
+	nextStatus(t, statusesA)
+
+	// B收到的交易已没有剩余跳数，不再转发回A
+	if status := nextStatus(t, statusesB); status != http.StatusCreated {
+		t.Fatalf("B接受转发的交易返回 %d，应为 201", status)
+	}
+	select {
+	case status := <-statusesA:
+		t.Fatalf("跳数用尽后A仍收到转发的交易（%d）", status)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestRelayTransactionAcrossThreeNodes(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+
+	// A -> B -> C -> A 组成环：交易经B到达C，C转发回A时被按交易ID去重
+	a, b, c := newTestNetwork(t, bc), newTestNetwork(t, copyChain(t, bc)), newTestNetwork(t, copyChain(t, bc))
+	a.TxRelayTTL, b.TxRelayTTL, c.TxRelayTTL = 3, 3, 3
+	addrA, statusesA := startRecordingPeer(t, a)
+	addrB, _ := startRecordingPeer(t, b)
+	addrC, _ := startRecordingPeer(t, c)
+	mustRegister(t, a, "b", addrB)
+	mustRegister(t, b, "c", addrC)
+	mustRegister(t, c, "a", addrA)
+
+	if status := postTransaction(t, addrA, transfer(bc, "alice", "bob", 1)); status != http.StatusCreated {
+		t.Fatalf("提交交易返回 %d，应为 201", status)
+	}
+	nextStatus(t, statusesA) // 客户端的提交
+
+	waitFor(t, "交易到达C的交易池", func() bool { return pendingTransactions(c) == 1 })
+	if status := nextStatus(t, statusesA); status != http.StatusBadRequest {
+		t.Fatalf("转发回A的交易返回 %d，应作为重复交易被拒绝", status)
+	}
+	for name, n := range map[string]*Network{"A": a, "B": b, "C": c} {
+		if got := pendingTransactions(n); got != 1 {
+			t.Fatalf("%s的交易池有 %d 笔交易，应为 1", name, got)
+		}
+	}
+}
+
+func TestTransactionsRelayByDefault(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+
+	// 未设置 TxRelayTTL：提交给A的交易到达B，B不再转发回A
+	a, b := newTestNetwork(t, bc), newTestNetwork(t, copyChain(t, bc))
+	addrA, statusesA := startRecordingPeer(t, a)
+	addrB, statusesB := startRecordingPeer(t, b)
+	mustRegister(t, a, "b", addrB)
+	mustRegister(t, b, "a", addrA)
+
+	postTransaction(t, addrA, transfer(bc, "alice", "bob", 1))
+	nextStatus(t, statusesA)
+	if status := nextStatus(t, statusesB); status != http.StatusCreated {
+		t.Fatalf("B接受转发的交易返回 %d，应为 201", status)
+	}
+	select {
+	case status := <-statusesA:
+		t.Fatalf("已转发的交易被B转发回A（%d）", status)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
+
+func TestBroadcastTransaction(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+
+	a := newTestNetwork(t, bc)
+	b := newTestNetwork(t, copyChain(t, bc))
+	addrB, statusesB := startRecordingPeer(t, b)
+	mustRegister(t, a, "b", addrB)
+
+	tx := transfer(bc, "alice", "bob", 1)
+	a.BroadcastTransaction(tx)
+	if status := nextStatus(t, statusesB); status != http.StatusCreated {
+		t.Fatalf("B接受广播的交易返回 %d，应为 201", status)
+	}
+	if got := pendingTransactions(b); got != 1 {
+		t.Fatalf("B的交易池有 %d 笔交易，应为 1", got)
+	}
+
+	// TxRelayTTL 为0时不转发
+	a.TxRelayTTL = 0
+	a.BroadcastTransaction(transfer(bc, "alice", "carol", 1))
+	select {
+	case status := <-statusesB:
+		t.Fatalf("关闭转发后B仍收到交易（%d）", status)
+	case <-time.After(200 * time.Millisecond):
+	}
+}
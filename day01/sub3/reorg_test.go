@@ -0,0 +1,112 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+// newFork 返回在alice获得一个区块奖励后分叉的两条链：
+// local 打包了 alice -> bob 的转账，remote 由dave多挖了一个区块，累计工作量更大
+func newFork(t *testing.T) (local, remote *Blockchain, tx Transaction) {
+	t.Helper()
+	local = newTestChain(t)
+	mustMine(t, local, "alice")
+	remote = copyChain(t, local)
+
+	tx = transfer(local, "alice", "bob", 1)
+	mustAddTransaction(t, local, tx)
+	mustMine(t, local, "carol")
+
+	mustMine(t, remote, "dave")
+	mustMine(t, remote, "dave")
+	return local, remote, tx
+}
+
+// checkBalances 检查各地址的余额
+func checkBalances(t *testing.T, bc *Blockchain, want map[string]Amount) {
+	t.Helper()
+	for address, balance := range want {
+		if got := bc.GetBalance(address); got != balance {
+			t.Errorf("%s 的余额为 %s，应为 %s", address, got, balance)
+		}
+	}
+}
+
+func TestReplaceChainRebuildsBalances(t *testing.T) {
+	local, remote, tx := newFork(t)
+	checkBalances(t, local, map[string]Amount{"alice": miningReward - 1, "bob": 1, "carol": miningReward})
+
+	if err := local.ReplaceChain(remote.GetChain()); err != nil {
+		t.Fatal(err)
+	}
+	checkBalances(t, local, map[string]Amount{"alice": miningReward, "bob": 0, "carol": 0, "dave": 2 * miningReward})
+
+	// 孤立区块中的转账回到交易池，之后仍可以打包
+	if len(local.Transactions) != 1 || local.Transactions[0].ID() != tx.ID() {
+		t.Fatalf("交易池为 %v，应只有孤立区块中的转账", local.Transactions)
+	}
+	mustMine(t, local, "dave")
+	checkBalances(t, local, map[string]Amount{"alice": miningReward - 1, "bob": 1})
+	if !local.IsChainValid() {
+		t.Fatal("重组后挖出的链应有效")
+	}
+}
+
+func TestReplaceChainDropsConfirmedFromMempool(t *testing.T) {
+	local := newTestChain(t)
+	mustMine(t, local, "alice")
+	tx := transfer(local, "alice", "bob", 1)
+	mustAddTransaction(t, local, tx)
+
+	// 对端已打包了本地交易池中的转账
+	remote := copyChain(t, local)
+	mustMine(t, remote, "dave")
+	if err := local.ReplaceChain(remote.GetChain()); err != nil {
+		t.Fatal(err)
+	}
+	if len(local.Transactions) != 0 {
+		t.Fatalf("交易池有 %d 笔交易，新链上已确认的转账应被移除", len(local.Transactions))
+	}
+	checkBalances(t, local, map[string]Amount{"alice": miningReward - 1, "bob": 1})
+	if _, err := local.AddTransaction(tx); !errors.Is(err, ErrDuplicateTransaction) {
+		t.Fatalf("再次提交已确认转账的错误为 %v，应为 ErrDuplicateTransaction", err)
+	}
+}
+
+func TestReplaceChainFailureLeavesStateUntouched(t *testing.T) {
+	local, remote, _ := newFork(t)
+	remote.Chain[len(remote.Chain)-1].Transactions[0].Amount++
+
+	if err := local.ReplaceChain(remote.GetChain()); !errors.Is(err, ErrInvalidChain) {
+		t.Fatalf("替换为被篡改的链返回 %v，应为 ErrInvalidChain", err)
+	}
+	checkBalances(t, local, map[string]Amount{"alice": miningReward - 1, "bob": 1, "carol": miningReward, "dave": 0})
+	if len(local.Chain) != 3 || len(local.Transactions) != 0 {
+		t.Fatalf("链长 %d、交易池 %d 笔，失败的替换不应修改本地链", len(local.Chain), len(local.Transactions))
+	}
+}
+
+func TestBalanceQueriedRightAfterResolve(t *testing.T) {
+	local, remote, _ := newFork(t)
+	n := newTestNetwork(t, local)
+	_, addr := startPeer(t, remote)
+	mustRegister(t, n, "remote", addr)
+
+	if !n.ResolveConflicts() {
+		t.Fatal("应采用工作量更大的对端链")
+	}
+	want := map[string]Amount{"alice": miningReward, "bob": 0, "carol": 0, "dave": 2 * miningReward}
+	for address, balance := range want {
+		var body struct {
+			Balance Amount `json:"balance"`
+		}
+		if status := getJSON(t, n, fmt.Sprintf("/address/%s/balance", address), &body); status != http.StatusOK {
+			t.Fatalf("查询 %s 的余额返回 %d", address, status)
+		}
+		if body.Balance != balance {
+			t.Errorf("重组后 %s 的余额为 %s，应为 %s", address, body.Balance, balance)
+		}
+	}
+}
@@ -0,0 +1,129 @@
+package main
+
+import (
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+	"strings"
+)
+
+// miningReward 未设置 InitialReward 时每个区块的挖矿奖励
+const miningReward = 1 * Coin
+
+// DefaultMinerAddress 未指定矿工地址时的奖励地址
+// 它不是任何地址格式下的有效地址，启用地址格式（见 AddressFormat）时须指定有效的矿工地址，否则奖励无法花费
+const DefaultMinerAddress = "miner-address"
+
+// BlockReward 返回高度为height的区块的挖矿奖励
+// 奖励从 InitialReward（未设置时为 miningReward）开始，每 HalvingInterval 个区块减半，
+// 按最小单位向下取整，减半足够多次后为0；HalvingInterval 为0时奖励不变
+func (bc *Blockchain) BlockReward(height int) Amount {
+	reward := bc.InitialReward
+	if reward <= 0 {
+		reward = miningReward
+	}
+	if bc.HalvingInterval <= 0 {
+		return reward
+	}
+	halvings := height / bc.HalvingInterval
+	if halvings >= 63 {
+		return 0 // Amount为int64，再右移没有意义
+	}
+	return reward >> halvings
+}
+
+// rewardFractionTolerance 奖励比例之和与1之间允许的误差
+const rewardFractionTolerance = 1e-9
+
+// RewardShare 表示奖励分配中的一个接收方及其比例
+type RewardShare struct {
+	Address  string  `json:"address"`  // 接收地址
+	Fraction float64 `json:"fraction"` // 分配比例，(0, 1]
+}
+
+// splitReward 按比例拆分奖励
+// 最后一个接收方获得扣除其他份额后的余数，保证各份额之和恰好等于总奖励
+func splitReward(total Amount, shares []RewardShare) ([]Amount, error) {
+	if err := validateRewardShares(shares, nil); err != nil {
+		return nil, err
+	}
+
+	// 以最小单位做精确的整数运算并向下取整，舍去的零头归最后一个接收方；
+	// 比例之和在误差范围内略大于1时，前面的份额可能已超出总奖励，最后一个接收方会得到负数
+	amounts := make([]Amount, len(shares))
+	remaining := total
+	for i, share := range shares[:len(shares)-1] {
+		amounts[i] = floorShare(total, share.Fraction)
+		remaining -= amounts[i]
+	}
+	if remaining < 0 {
+		return nil, fmt.Errorf("奖励比例之和超过1，最后一个接收方 %s 的份额为 %s", shares[len(shares)-1].Address, remaining)
+	}
+	amounts[len(shares)-1] = remaining
+	return amounts, nil
+}
+
+// floorShare 返回 total*fraction 按最小单位向下取整的结果
+// 比例按其最短的十进制表示（如 0.7，而不是最接近它的二进制浮点数 0.6999...）转为有理数后精确计算，
+// 不经过 float64 表示金额，金额较大时也不会因舍入多分或少分
+func floorShare(total Amount, fraction float64) Amount {
+	r, _ := new(big.Rat).SetString(strconv.FormatFloat(fraction, 'g', -1, 64))
+	r.Mul(r, new(big.Rat).SetInt64(int64(total)))
+	return Amount(new(big.Int).Quo(r.Num(), r.Denom()).Int64())
+}
+
+// validateRewardShares 校验奖励分配：至少一个接收方，地址非空且互不相同，各比例在 (0, 1] 内且合计为1
+// 同一地址在一个区块中只能有一笔奖励交易（见 validateCoinbase），重复的地址应合并为一个份额
+// format 不为nil时接收地址还须符合该地址格式，否则奖励会发到无法花费的地址
+func validateRewardShares(shares []RewardShare, format AddressFormat) error {
+	if len(shares) == 0 {
+		return fmt.Errorf("奖励分配不能为空")
+	}
+
+	sum := 0.0
+	seen := make(map[string]bool, len(shares))
+	for _, share := range shares {
+		if share.Address == "" {
+			return fmt.Errorf("奖励接收地址不能为空")
+		}
+		if seen[share.Address] {
+			return fmt.Errorf("奖励接收地址 %s 重复", share.Address)
+		}
+		seen[share.Address] = true
+		if format != nil {
+			if _, err := format.Decode(share.Address); err != nil {
+				return fmt.Errorf("奖励接收地址 %q: %w", share.Address, err)
+			}
+		}
+		if math.IsNaN(share.Fraction) || share.Fraction <= 0 || share.Fraction > 1 {
+			return fmt.Errorf("无效的奖励比例 %v (地址 %s)", share.Fraction, share.Address)
+		}
+		sum += share.Fraction
+	}
+	if math.Abs(sum-1) > rewardFractionTolerance {
+		return fmt.Errorf("奖励比例之和必须为1，当前为 %v", sum)
+	}
+	return nil
+}
+
+// ParseRewardSplit 解析形如 "addr1:0.7,addr2:0.3" 的奖励分配描述
+func ParseRewardSplit(spec string) ([]RewardShare, error) {
+	var shares []RewardShare
+	for _, part := range strings.Split(spec, ",") {
+		part = strings.TrimSpace(part)
+		if part == "" {
+			continue
+		}
+		idx := strings.LastIndex(part, ":")
+		if idx <= 0 {
+			return nil, fmt.Errorf("无效的奖励分配项: %s", part)
+		}
+		fraction, err := strconv.ParseFloat(part[idx+1:], 64)
+		if err != nil {
+			return nil, fmt.Errorf("无效的奖励比例 %s: %v", part[idx+1:], err)
+		}
+		shares = append(shares, RewardShare{Address: part[:idx], Fraction: fraction})
+	}
+	return shares, nil
+}
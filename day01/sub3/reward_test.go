@@ -0,0 +1,273 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"testing"
+)
+
+func TestValidateRewardSharesChecksAddressFormat(t *testing.T) {
+	format := HexAddressFormat{Prefix: "0x"}
+	valid := format.Encode(make([]byte, AddressLength))
+
+	if err := validateRewardShares([]RewardShare{{Address: valid, Fraction: 1}}, format); err != nil {
+		t.Fatalf("有效的地址被拒绝: %v", err)
+	}
+	shares := []RewardShare{{Address: valid, Fraction: 0.5}, {Address: DefaultMinerAddress, Fraction: 0.5}}
+	if err := validateRewardShares(shares, format); !errors.Is(err, ErrInvalidAddress) {
+		t.Fatalf("无效的奖励地址返回 %v，应为 ErrInvalidAddress", err)
+	}
+	if err := validateRewardShares(shares, nil); err != nil {
+		t.Fatalf("未配置地址格式时不应校验地址: %v", err)
+	}
+}
+
+func TestMineRejectsRewardAddressOutsideFormat(t *testing.T) {
+	bc := newTestChain(t)
+	bc.AddressFormat = HexAddressFormat{Prefix: "0x"}
+	if _, err := bc.MineWithRewardSplit([]RewardShare{{Address: DefaultMinerAddress, Fraction: 1}}); !errors.Is(err, ErrInvalidAddress) {
+		t.Fatalf("向无效地址发放奖励返回 %v，应为 ErrInvalidAddress", err)
+	}
+
+	n := newTestNetwork(t, bc)
+	var body struct {
+		Code string `json:"code"`
+	}
+	for _, target := range []string{"/mine", "/mine?rewards=miner-address:1"} {
+		if status := getJSON(t, n, target, &body); status != http.StatusBadRequest || body.Code != "invalid_address" {
+			t.Fatalf("%s 返回 %d %s，应为 400 invalid_address", target, status, body.Code)
+		}
+	}
+
+	n.MinerAddress = bc.AddressFormat.Encode(make([]byte, AddressLength))
+	if status := getJSON(t, n, "/mine", nil); status != http.StatusOK {
+		t.Fatalf("设置有效的矿工地址后 /mine 返回 %d，应为 200", status)
+	}
+	if got := bc.GetBalance(n.MinerAddress); got != bc.BlockReward(1) {
+		t.Fatalf("矿工余额为 %s，应为区块奖励 %s", got, bc.BlockReward(1))
+	}
+}
+
+func TestBlockRewardHalving(t *testing.T) {
+	bc := newTestChain(t)
+	bc.InitialReward = 50 * Coin
+	bc.HalvingInterval = 210
+
+	tests := []struct {
+		height int
+		want   Amount
+	}{
+		{0, 50 * Coin},
+		{209, 50 * Coin},
+		{210, 25 * Coin},
+		{419, 25 * Coin},
+		{420, 25 * Coin / 2},
+		{630, 25 * Coin / 4},
+		{210 * 33, 0},
+		{210 * 64, 0},
+		{210 * 1000, 0},
+	}
+	for _, tt := range tests {
+		if got := bc.BlockReward(tt.height); got != tt.want {
+			t.Errorf("高度 %d 的奖励为 %s，应为 %s", tt.height, got, tt.want)
+		}
+	}
+}
+
+func TestBlockRewardDefaults(t *testing.T) {
+	bc := newTestChain(t)
+	for _, height := range []int{0, 1, 1000000} {
+		if got := bc.BlockReward(height); got != miningReward {
+			t.Fatalf("未设置减半时高度 %d 的奖励为 %s，应为 %s", height, got, miningReward)
+		}
+	}
+}
+
+func TestMineUsesBlockReward(t *testing.T) {
+	bc := newTestChain(t)
+	bc.InitialReward = 2
+	bc.HalvingInterval = 2
+
+	// 高度1: 2，高度2、3: 1，高度4起奖励为0，区块不包含奖励交易
+	for i, want := range []Amount{2, 1, 1, 0} {
+		height := i + 1
+		block := mustMine(t, bc, "miner")
+		var paid Amount
+		for _, tx := range block.Transactions {
+			if tx.Coinbase {
+				paid += tx.Amount
+			}
+		}
+		if paid != want {
+			t.Fatalf("高度 %d 的区块发放了 %s，应为 %s", height, paid, want)
+		}
+	}
+	if got := bc.GetBalance("miner"); got != 4 {
+		t.Fatalf("矿工余额为 %s，应为 4", got)
+	}
+	if !bc.IsChainValid() {
+		t.Fatal("按减半规则挖出的链应有效")
+	}
+}
+
+func TestSplitRewardRounding(t *testing.T) {
+	third := 1.0 / 3
+	tests := []struct {
+		name      string
+		total     Amount
+		fractions []float64
+		want      []Amount
+	}{
+		{"single recipient", 100, []float64{1}, []Amount{100}},
+		{"even split", 100, []float64{0.5, 0.5}, []Amount{50, 50}},
+		{"odd total", 101, []float64{0.5, 0.5}, []Amount{50, 51}},
+		{"thirds", 100, []float64{third, third, third}, []Amount{33, 33, 34}},
+		{"decimal fractions", 10, []float64{0.7, 0.3}, []Amount{7, 3}},
+		{"share below one unit", 1, []float64{0.5, 0.5}, []Amount{0, 1}},
+		{"remainder to last", 7, []float64{0.1, 0.2, 0.7}, []Amount{0, 1, 6}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			shares := make([]RewardShare, len(tt.fractions))
+			for i, fraction := range tt.fractions {
+				shares[i] = RewardShare{Address: fmt.Sprintf("pool%d", i), Fraction: fraction}
+			}
+			amounts, err := splitReward(tt.total, shares)
+			if err != nil {
+				t.Fatal(err)
+			}
+			var sum Amount
+			for i, amount := range amounts {
+				if amount != tt.want[i] {
+					t.Fatalf("拆分结果为 %v，应为 %v", amounts, tt.want)
+				}
+				sum += amount
+			}
+			if sum != tt.total {
+				t.Fatalf("各份额之和为 %s，应等于总奖励 %s", sum, tt.total)
+			}
+		})
+	}
+}
+
+func TestSplitRewardRejectsNegativeRemainder(t *testing.T) {
+	// 比例之和在误差之内，但前两个份额向下取整后已超出总奖励
+	shares := []RewardShare{{"a", 0.6}, {"b", 0.4000000005}, {"c", 1e-10}}
+	if err := validateRewardShares(shares, nil); err != nil {
+		t.Fatalf("比例之和应在误差之内: %v", err)
+	}
+	if amounts, err := splitReward(100*Coin, shares); err == nil {
+		t.Fatalf("拆分结果为 %v，最后一个份额为负时应返回错误", amounts)
+	}
+
+	bc := newTestChain(t)
+	bc.InitialReward = 100 * Coin
+	if _, err := bc.MineWithRewardSplit(shares); err == nil {
+		t.Fatal("份额为负时不应出块")
+	}
+	if len(bc.Chain) != 1 || !bc.IsChainValid() {
+		t.Fatalf("链长为 %d，拆分失败时链应保持不变且有效", len(bc.Chain))
+	}
+}
+
+func TestSplitRewardLargeTotalIsExact(t *testing.T) {
+	// float64 无法精确表示这个总额，按浮点数相乘会多分出最小单位
+	total := Amount(1<<62 + 1)
+	amounts, err := splitReward(total, []RewardShare{{"a", 0.5}, {"b", 0.5}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if amounts[0] != total/2 || amounts[1] != total-total/2 {
+		t.Fatalf("拆分结果为 %v，应为 [%d %d]", amounts, total/2, total-total/2)
+	}
+}
+
+func TestValidateRewardSharesFractions(t *testing.T) {
+	tests := []struct {
+		name   string
+		shares []RewardShare
+		valid  bool
+	}{
+		// 0.1+0.2+0.7 的浮点和不恰好等于1，在误差之内
+		{"sum within tolerance", []RewardShare{{"a", 0.1}, {"b", 0.2}, {"c", 0.7}}, true},
+		{"sum below one", []RewardShare{{"a", 0.5}, {"b", 0.4}}, false},
+		{"sum above one", []RewardShare{{"a", 0.5}, {"b", 0.6}}, false},
+		{"sum just outside tolerance", []RewardShare{{"a", 0.5}, {"b", 0.5 + 1e-6}}, false},
+		{"zero fraction", []RewardShare{{"a", 1}, {"b", 0}}, false},
+		{"negative fraction", []RewardShare{{"a", 1.5}, {"b", -0.5}}, false},
+		{"NaN fraction", []RewardShare{{"a", math.NaN()}}, false},
+		{"empty address", []RewardShare{{"", 1}}, false},
+		{"duplicate address", []RewardShare{{"a", 0.5}, {"a", 0.5}}, false},
+		{"no recipients", nil, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := validateRewardShares(tt.shares, nil)
+			if tt.valid && err != nil {
+				t.Fatalf("有效的奖励分配被拒绝: %v", err)
+			}
+			if !tt.valid && err == nil {
+				t.Fatal("无效的奖励分配应被拒绝")
+			}
+		})
+	}
+}
+
+func TestParseRewardSplit(t *testing.T) {
+	shares, err := ParseRewardSplit(" alice:0.7, host:8080:0.3 ,")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 按最后一个冒号分隔，地址本身可以包含冒号
+	want := []RewardShare{{"alice", 0.7}, {"host:8080", 0.3}}
+	if len(shares) != len(want) || shares[0] != want[0] || shares[1] != want[1] {
+		t.Fatalf("解析结果为 %v，应为 %v", shares, want)
+	}
+
+	for _, spec := range []string{"alice", ":1", "alice:half"} {
+		if _, err := ParseRewardSplit(spec); err == nil {
+			t.Fatalf("%q 应解析失败", spec)
+		}
+	}
+}
+
+func TestMineWithRewardSplit(t *testing.T) {
+	bc := newTestChain(t)
+	third := 1.0 / 3
+	shares := []RewardShare{{"a", third}, {"b", third}, {"c", third}}
+	block, err := bc.MineWithRewardSplit(shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	want, err := splitReward(miningReward, shares)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(block.Transactions) != len(shares) {
+		t.Fatalf("区块有 %d 笔交易，应为每个接收方一笔奖励交易", len(block.Transactions))
+	}
+	for i, share := range shares {
+		if tx := block.Transactions[i]; tx.Recipient != share.Address || tx.Amount != want[i] {
+			t.Fatalf("第 %d 笔奖励交易为 %s %s，应为 %s %s", i, tx.Recipient, tx.Amount, share.Address, want[i])
+		}
+		if got := bc.GetBalance(share.Address); got != want[i] {
+			t.Fatalf("%s 的余额为 %s，应为 %s", share.Address, got, want[i])
+		}
+	}
+	if !bc.IsChainValid() {
+		t.Fatal("按比例分配奖励的链应有效")
+	}
+
+	if _, err := bc.MineWithRewardSplit([]RewardShare{{"a", 0.5}, {"b", 0.4}}); err == nil {
+		t.Fatal("比例之和不为1时不应出块")
+	}
+	if _, err := bc.MineWithRewardSplit([]RewardShare{{"a", 0.5}, {"a", 0.5}}); err == nil {
+		t.Fatal("接收地址重复时不应出块")
+	}
+	if len(bc.Chain) != 2 || !bc.IsChainValid() {
+		t.Fatalf("链长为 %d，无效的奖励分配不应出块", len(bc.Chain))
+	}
+}
@@ -0,0 +1,108 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// maxSelfTestDetails 自检报告中最多列出的不一致地址数
+const maxSelfTestDetails = 10
+
+// SelfTestCheck 自检中的单项检查
+type SelfTestCheck struct {
+	Name   string `json:"name"`
+	Passed bool   `json:"passed"`
+	Detail string `json:"detail,omitempty"` // 未通过的原因
+}
+
+// SelfTestReport 节点自检的结果，任一项未通过即为失败
+type SelfTestReport struct {
+	Passed bool            `json:"passed"`
+	Checks []SelfTestCheck `json:"checks"`
+}
+
+// SelfTest 检查区块链内部状态的一致性：
+// 整条链（含创世区块）有效、维护的账户状态与重放整条链的结果一致、链尾高度等于 len(chain)-1。
+// 用于发现链重组等操作后状态漂移之类的内部错误。调用方需持有该链的读锁
+func (bc *Blockchain) SelfTest() SelfTestReport {
+	report := SelfTestReport{Passed: true}
+	add := func(name string, err error) {
+		check := SelfTestCheck{Name: name, Passed: err == nil}
+		if err != nil {
+			check.Detail = err.Error()
+			report.Passed = false
+		}
+		report.Checks = append(report.Checks, check)
+	}
+
+	chainReport := bc.ValidateChainDetailed(bc.Chain)
+	if chainReport.Valid {
+		add("chain_valid", nil)
+	} else if chainReport.FirstInvalid != nil {
+		add("chain_valid", fmt.Errorf("区块 %d: %s", *chainReport.FirstInvalid, chainReport.Reason))
+	} else {
+		add("chain_valid", fmt.Errorf("%s", chainReport.Reason))
+	}
+
+	add("state_matches_replay", bc.checkStateReplay())
+
+	var tipErr error
+	if len(bc.Chain) == 0 {
+		tipErr = fmt.Errorf("区块链为空")
+	} else if tip := bc.GetLastBlock(); tip.Index != len(bc.Chain)-1 {
+		tipErr = fmt.Errorf("链尾高度 %d，应为 %d", tip.Index, len(bc.Chain)-1)
+	}
+	add("tip_index", tipErr)
+
+	return report
+}
+
+// checkStateReplay 重放整条链（已归档的区块从归档存储恢复），与维护的账户状态比较
+func (bc *Blockchain) checkStateReplay() error {
+	chain, err := bc.fullChain(bc.Chain)
+	if err != nil {
+		return fmt.Errorf("恢复归档区块失败: %v", err)
+	}
+	return bc.state.diff(stateFromChain(chain, bc.ReplayWindow))
+}
+
+// diff 比较两个状态，返回描述差异的错误，一致时返回nil
+func (s *State) diff(other *State) error {
+	var mismatched []string
+	for addr := range unionKeys(s.balances, other.balances) {
+		if s.balances[addr] != other.balances[addr] {
+			mismatched = append(mismatched, fmt.Sprintf("%s（维护 %s，重放 %s）", addr, s.balances[addr], other.balances[addr]))
+		}
+	}
+	if len(mismatched) > 0 {
+		sort.Strings(mismatched)
+		total := len(mismatched)
+		if total > maxSelfTestDetails {
+			mismatched = mismatched[:maxSelfTestDetails]
+		}
+		return fmt.Errorf("%d 个地址余额不一致: %s", total, strings.Join(mismatched, "; "))
+	}
+
+	if len(s.confirmed) != len(other.confirmed) {
+		return fmt.Errorf("已确认交易数不一致：维护 %d，重放 %d", len(s.confirmed), len(other.confirmed))
+	}
+	for id, height := range other.confirmed {
+		if h, ok := s.confirmed[id]; !ok || h != height {
+			return fmt.Errorf("交易 %s 未记录为已确认", id)
+		}
+	}
+	return nil
+}
+
+// unionKeys 返回两个余额表中出现过的所有地址
+func unionKeys(a, b map[string]Amount) map[string]struct{} {
+	keys := make(map[string]struct{}, len(a))
+	for k := range a {
+		keys[k] = struct{}{}
+	}
+	for k := range b {
+		keys[k] = struct{}{}
+	}
+	return keys
+}
@@ -1,105 +1,273 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"math"
+	"net"
 	"net/http"
+	"slices"
+	"strconv"
+	"strings"
 	"sync"
+	"time"
+
+	"openspace/day01/signer"
 )
 
 // Node 表示网络中的一个节点
 type Node struct {
-	ID        string   `json:"id"`
-	Addresses []string `json:"addresses"`
+	ID        string    `json:"id"`
+	Addresses []string  `json:"addresses"`
+	Identity  *Identity `json:"identity,omitempty"` // 注册后在后台获取的节点身份
+	LastSeen  time.Time `json:"last_seen"`          // 最近一次注册或通过健康检查的时间
+
+	failures int // 健康检查连续失败的次数（见 MonitorPeers）
 }
 
 // Network 表示P2P网络
 type Network struct {
-	nodes    map[string]*Node
-	blockchain *Blockchain
+	nodes  map[string]*Node
+	chains map[string]*namedChain // 按名称区分的多条独立区块链
 	sync.RWMutex
+
+	// 本节点的ID与身份签名器，私钥不会对外暴露
+	nodeID         string
+	identitySigner signer.Signer
+
+	miningPaused bool         // 是否暂停出块
+	syncProgress syncProgress // 默认链的同步进度
+	miningEvents miningEvents // 挖矿事件的订阅者
+
+	// DebugEnabled 开启仅用于测试的调试接口（如 /debug/fork），默认关闭
+	DebugEnabled bool
+	// MinPeersToMine 出块前至少需要的已注册节点数（不含本节点），0表示不限制
+	// 避免多节点测试中孤立的节点单独出块，产生难以合并的分叉
+	MinPeersToMine int
+	// InstantMineEnabled 允许 POST /transactions/new?mine=true 提交交易后立即出块，仅用于演示，默认关闭
+	InstantMineEnabled bool
+	// ObserverMode 只读的观察者节点：不出块也不接收交易（挖矿与提交交易的接口返回403），
+	// 仍然响应查询并校验、采用对端的更长链，适合作为区块浏览器的后端
+	ObserverMode bool
+	// MinerAddress 挖矿接口未指定 ?rewards= 时的奖励地址，为空时使用 DefaultMinerAddress
+	// 区块链启用地址格式时须为该格式的有效地址，否则挖矿接口返回400
+	MinerAddress string
+	// TxRelayTTL 新接受的交易最多向对端转发的跳数（见 BroadcastTransaction），新建网络时为 DefaultTxRelayTTL，0表示不转发
+	// 转发使交易传播到整个网络，任何节点都能打包它，而不只是收到交易的节点
+	TxRelayTTL int
+
+	mux     *http.ServeMux // 本网络的接口路由，见 Handler
+	muxOnce sync.Once
 }
 
-// NewNetwork 创建新的网络
+// NewNetwork 创建新的网络，包含一条名为 DefaultChainName 的区块链
 func NewNetwork() *Network {
-	return &Network{
-		nodes:     make(map[string]*Node),
-		blockchain: NewBlockchain(),
+	n := &Network{
+		nodes:      make(map[string]*Node),
+		chains:     make(map[string]*namedChain),
+		TxRelayTTL: DefaultTxRelayTTL,
 	}
+	n.AddChain(DefaultChainName, NewBlockchain())
+	return n
 }
 
-// RegisterNode 注册新节点
-func (n *Network) RegisterNode(nodeID, address string) {
+// RegisterNode 注册新节点，返回规范化后的地址
+// 地址无效，或同一地址已属于其他节点时返回错误；重复注册同一节点的同一地址不会产生重复记录
+func (n *Network) RegisterNode(nodeID, address string) (string, error) {
+	address, err := NormalizePeerAddress(address)
+	if err != nil {
+		return "", err
+	}
+
 	n.Lock()
 	defer n.Unlock()
 
+	for id, node := range n.nodes {
+		if id == nodeID {
+			continue
+		}
+		for _, addr := range node.Addresses {
+			if addr == address {
+				return "", fmt.Errorf("%w: %s 属于节点 %s", ErrDuplicatePeer, address, id)
+			}
+		}
+	}
+
 	if _, exists := n.nodes[nodeID]; !exists {
 		n.nodes[nodeID] = &Node{
 			ID:        nodeID,
 			Addresses: []string{address},
+			LastSeen:  time.Now(),
 		}
 	} else {
+		n.nodes[nodeID].LastSeen = time.Now()
 		// 添加新地址（如果不存在）
 		for _, addr := range n.nodes[nodeID].Addresses {
 			if addr == address {
-				return
+				return address, nil
 			}
 		}
 		n.nodes[nodeID].Addresses = append(n.nodes[nodeID].Addresses, address)
 	}
+	return address, nil
 }
 
-// ResolveConflicts 使用最长链规则解决冲突
+// Nodes 返回所有已注册节点的副本，按节点ID排序
+func (n *Network) Nodes() []Node {
+	n.RLock()
+	defer n.RUnlock()
+
+	nodes := make([]Node, 0, len(n.nodes))
+	for _, node := range n.nodes {
+		copied := *node
+		copied.Addresses = append([]string{}, node.Addresses...)
+		nodes = append(nodes, copied)
+	}
+	slices.SortFunc(nodes, func(a, b Node) int {
+		return strings.Compare(a.ID, b.ID)
+	})
+	return nodes
+}
+
+// ResolveConflicts 按累计工作量最大的有效链解决默认区块链的冲突
+// 工作量（见 ChainWork）而不是区块数决定采用哪条链：以极低难度挖出的长链工作量很小，不会被采用。
+// 获取对端区块链时不持有任何锁，同步进度可通过 /status 查询
 func (n *Network) ResolveConflicts() bool {
-	n.Lock()
-	defer n.Unlock()
+	c := n.chain(DefaultChainName)
 
-	maxLength := len(n.blockchain.Chain)
-	var newChain []*Block
+	addresses := n.peerAddresses()
 
-	// 从所有节点获取区块链
-	for _, node := range n.nodes {
-		for _, addr := range node.Addresses {
-			resp, err := http.Get(fmt.Sprintf("http://%s/chain", addr))
-			if err != nil {
-				continue
-			}
-			defer resp.Body.Close()
+	c.RLock()
+	height := len(c.blockchain.Chain) - 1
+	maxWork := c.blockchain.ChainWork()
+	c.RUnlock()
 
-			var chainResp struct {
-				Chain  []*Block `json:"chain"`
-				Length int      `json:"length"`
-			}
+	n.syncProgress.start(height)
+	defer n.syncProgress.finish()
 
-			if err := json.NewDecoder(resp.Body).Decode(&chainResp); err != nil {
-				continue
-			}
+	// 从所有节点获取区块链
+	var newChain []*Block
+	for _, addr := range addresses {
+		chain, err := fetchPeerChain(addr, c.chainPath())
+		if err != nil {
+			continue
+		}
+		n.syncProgress.observe(len(chain) - 1)
 
-			// 检查是否是最长链
-			if chainResp.Length > maxLength && n.blockchain.IsChainValid() {
-				maxLength = chainResp.Length
-				newChain = chainResp.Chain
-			}
+		// 只考虑工作量比目前最大的链更多、且本身有效的链，无效的链不会挤掉其他节点的有效链
+		work := chainWork(chain)
+		if work.Cmp(maxWork) <= 0 {
+			continue
 		}
+		c.RLock()
+		valid := c.blockchain.IsValidChain(chain)
+		c.RUnlock()
+		if valid {
+			maxWork = work
+			newChain = chain
+		}
+	}
+
+	// 如果找到工作量更大的有效链，则替换当前链并重建派生状态
+	if newChain == nil {
+		return false
 	}
 
-	// 如果找到更长的有效链，则替换当前链
-	if newChain != nil {
-		n.blockchain.Chain = newChain
-		return true
+	c.Lock()
+	defer c.Unlock()
+
+	if err := c.blockchain.ReplaceChain(newChain); err != nil {
+		return false
 	}
+	n.syncProgress.advance(len(c.blockchain.Chain) - 1)
+	return true
+}
+
+// serverShutdownTimeout 关闭HTTP服务器时等待处理中的请求完成的最长时间，超时后强制断开连接
+const serverShutdownTimeout = 10 * time.Second
+
+// Handler 返回本网络的全部HTTP接口，首次调用时注册路由
+// 每个Network有自己的路由而不使用全局的 http.DefaultServeMux，同一进程中的多个节点
+// （如测试中分别用 httptest.NewServer 启动）互不影响
+func (n *Network) Handler() http.Handler {
+	n.muxOnce.Do(n.registerRoutes)
+	return n.mux
+}
 
-	return false
+// StartServer 启动HTTP服务器，阻塞直到ctx被取消或服务器出错
+// ctx被取消后不再接受新连接，等待处理中的请求完成（最多 serverShutdownTimeout）后返回nil；
+// 监听失败等错误直接返回
+func (n *Network) StartServer(ctx context.Context, port int) error {
+	ln, err := net.Listen("tcp", fmt.Sprintf(":%d", port))
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Starting server on port %d\n", port)
+	return n.serve(ctx, ln)
 }
 
-// StartServer 启动HTTP服务器
-func (n *Network) StartServer(port int) {
-	http.HandleFunc("/mine", func(w http.ResponseWriter, r *http.Request) {
-		n.Lock()
-		defer n.Unlock()
+// serve 在ln上提供HTTP服务直到ctx被取消，关闭方式与 StartServer 相同
+func (n *Network) serve(ctx context.Context, ln net.Listener) error {
+	server := &http.Server{Handler: n.Handler()}
+	shutdownDone := make(chan struct{})
+	stopShutdown := context.AfterFunc(ctx, func() {
+		defer close(shutdownDone)
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), serverShutdownTimeout)
+		defer cancel()
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			server.Close()
+		}
+	})
+
+	if err := server.Serve(ln); !errors.Is(err, http.ErrServerClosed) {
+		if !stopShutdown() {
+			<-shutdownDone
+		}
+		return err
+	}
+	<-shutdownDone
+	return nil
+}
+
+// registerRoutes 创建本网络的路由并注册全部接口
+func (n *Network) registerRoutes() {
+	n.mux = http.NewServeMux()
+
+	n.handleChain("/mine", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		if n.ObserverMode {
+			writeError(w, http.StatusForbidden, errObserverMode)
+			return
+		}
+
+		shares, err := n.rewardShares(r, c)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := n.checkCanMine(); err != nil {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+
+		c.Lock()
+		defer c.Unlock()
+
+		// 距上一个区块过近时拒绝，避免持锁等待
+		if delay := c.blockchain.NextBlockDelay(); delay > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, errTooSoonToMine)
+			return
+		}
 
 		// 挖矿
-		block := n.blockchain.Mine("miner-address")
+		block, err := c.blockchain.MineContext(r.Context(), shares)
+		if err != nil {
+			writeError(w, miningErrorStatus(err), err)
+			return
+		}
 
 		response := struct {
 			Message string `json:"message"`
@@ -112,63 +280,501 @@ func (n *Network) StartServer(port int) {
 		sendJSON(w, http.StatusOK, response)
 	})
 
-	http.HandleFunc("/transactions/new", func(w http.ResponseWriter, r *http.Request) {
+	n.handleChain("/mine/step", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if n.ObserverMode {
+			writeError(w, http.StatusForbidden, errObserverMode)
+			return
+		}
+
+		maxAttempts := int64(defaultStepAttempts)
+		if v := r.URL.Query().Get("maxAttempts"); v != "" {
+			parsed, err := strconv.ParseInt(v, 10, 64)
+			if err != nil || parsed <= 0 || parsed > maxStepAttempts {
+				writeError(w, http.StatusBadRequest, fmt.Errorf("Invalid maxAttempts (1-%d)", maxStepAttempts))
+				return
+			}
+			maxAttempts = parsed
+		}
+
+		shares := []RewardShare{{Address: n.minerAddress(), Fraction: 1}}
+		if err := validateRewardShares(shares, c.blockchain.AddressFormat); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		if err := n.checkCanMine(); err != nil {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+
+		c.Lock()
+		defer c.Unlock()
+
+		if delay := c.blockchain.NextBlockDelay(); delay > 0 {
+			w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+			writeError(w, http.StatusTooManyRequests, errTooSoonToMine)
+			return
+		}
+
+		result, err := c.MineStep(maxAttempts, shares)
+		if err != nil {
+			writeError(w, http.StatusInternalServerError, err)
+			return
+		}
+
+		sendJSON(w, http.StatusOK, result)
+	})
+
+	n.mux.HandleFunc("/mine/pause", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		n.PauseMining()
+
+		response := struct {
+			Message string `json:"message"`
+			Mining  bool   `json:"mining"`
+		}{
+			Message: "Mining paused",
+			Mining:  n.IsMining(),
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.mux.HandleFunc("/mine/resume", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		n.ResumeMining()
+
+		response := struct {
+			Message string `json:"message"`
+			Mining  bool   `json:"mining"`
+		}{
+			Message: "Mining resumed",
+			Mining:  n.IsMining(),
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.mux.HandleFunc("/status", func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, http.StatusOK, n.Status())
+	})
+
+	n.handleChain("/transactions/new", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if n.ObserverMode {
+			writeError(w, http.StatusForbidden, errObserverMode)
 			return
 		}
 
 		var tx Transaction
 		if err := json.NewDecoder(r.Body).Decode(&tx); err != nil {
-			http.Error(w, "Invalid transaction data", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %v", errInvalidRequestBody, err))
+			return
+		}
+
+		// 未指定创建时间与参考高度时由节点填写，使内容相同的转账具有不同的交易ID；已签名的交易不能再修改
+		if !tx.IsSigned() {
+			if tx.Timestamp == 0 {
+				tx.Timestamp = time.Now().UnixNano()
+			}
+			if tx.RefHeight == 0 {
+				c.RLock()
+				tx.RefHeight = c.blockchain.GetLastBlock().Index
+				c.RUnlock()
+			}
+		}
+
+		if r.URL.Query().Get("mine") == "true" {
+			n.handleTransactionAndMine(w, r, c, tx)
+			return
+		}
+
+		hops, err := n.relayHops(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
 			return
 		}
 
-		n.Lock()
-		n.blockchain.CreateTransaction(tx.Sender, tx.Recipient, tx.Amount)
-		n.Unlock()
+		c.Lock()
+		_, err = c.blockchain.AddTransaction(tx)
+		c.Unlock()
+
+		if err != nil {
+			writeError(w, addTransactionErrorStatus(err), err)
+			return
+		}
+		n.broadcastTransaction(c, tx, hops)
 
 		response := struct {
 			Message string `json:"message"`
+			TxID    string `json:"txid"`
 		}{
 			Message: "Transaction will be added to the next block",
+			TxID:    tx.ID(),
 		}
 
 		sendJSON(w, http.StatusCreated, response)
 	})
 
-	http.HandleFunc("/chain", func(w http.ResponseWriter, r *http.Request) {
-		n.RLock()
-		defer n.RUnlock()
+	// 交易池同步：合并对端提交的待处理交易，冲突时保留手续费更高的交易
+	n.handleChain("/transactions/sync", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		if n.ObserverMode {
+			writeError(w, http.StatusForbidden, errObserverMode)
+			return
+		}
+
+		var data struct {
+			Transactions []Transaction `json:"transactions"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %v", errInvalidRequestBody, err))
+			return
+		}
+
+		c.Lock()
+		added := c.blockchain.MergePending(data.Transactions)
+		pending := len(c.blockchain.Transactions)
+		c.Unlock()
+
+		response := struct {
+			Message string `json:"message"`
+			Added   int    `json:"added"`
+			Pending int    `json:"pending_transactions"`
+		}{
+			Message: "Mempool merged",
+			Added:   added,
+			Pending: pending,
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.handleChain("/chain", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		c.RLock()
+		defer c.RUnlock()
+
+		response := struct {
+			Chain  interface{} `json:"chain"`
+			Length int         `json:"length"`
+		}{
+			Chain:  renderChain(c.blockchain.GetChain(), parseViewOptions(r)),
+			Length: len(c.blockchain.Chain),
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.handleChain("/tip", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		c.RLock()
+		defer c.RUnlock()
+
+		sendJSON(w, http.StatusOK, c.blockchain.Tip())
+	})
+
+	n.handleChain("/chain/asof", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		t, err := parseTimestamp(r.URL.Query().Get("ts"))
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		c.RLock()
+		defer c.RUnlock()
+
+		chain := c.blockchain.GetChainAsOf(t)
+		response := struct {
+			AsOf   int64       `json:"as_of"`
+			Chain  interface{} `json:"chain"`
+			Length int         `json:"length"`
+		}{
+			AsOf:   t.Unix(),
+			Chain:  renderChain(chain, parseViewOptions(r)),
+			Length: len(chain),
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	// 单个区块：按高度查找；为兼容也接受区块哈希（64位十六进制），按哈希查找见 /block/hash/{hash}
+	n.handleChain("/block/{index}", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		id := r.PathValue("index")
+		c.RLock()
+		var block *Block
+		var err error
+		if index, parseErr := strconv.Atoi(id); parseErr == nil {
+			block, err = c.blockchain.GetBlockByIndex(index)
+		} else if isHexHash(id) {
+			block, err = c.blockchain.GetBlockByHash(id)
+		} else {
+			err = errors.New("Invalid block index or hash")
+		}
+		c.RUnlock()
+
+		sendBlock(w, r, block, err)
+	})
+
+	n.handleChain("/block/hash/{hash}", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		hash := r.PathValue("hash")
+		if !isHexHash(hash) {
+			writeError(w, http.StatusBadRequest, errors.New("Invalid block hash"))
+			return
+		}
+		c.RLock()
+		block, err := c.blockchain.GetBlockByHash(hash)
+		c.RUnlock()
+
+		sendBlock(w, r, block, err)
+	})
+
+	// 区块的子资源 /block/{index}/path 与 /block/{index}/package 经同一个路由分派：
+	// 分别注册时与 /block/hash/{hash} 冲突（/block/hash/path 同时匹配两者，且二者都不更具体）
+	n.handleChain("/block/{index}/{resource}", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		switch r.PathValue("resource") {
+		case "path":
+			serveBlockPath(w, r, c)
+		case "package":
+			serveBlockPackage(w, r, c)
+		default:
+			writeError(w, http.StatusNotFound, errNotFound)
+		}
+	})
+
+	n.handleChain("/export.csv", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		c.RLock()
+		defer c.RUnlock()
+
+		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		w.Header().Set("Content-Disposition", `attachment; filename="chain.csv"`)
+		c.blockchain.WriteCSV(w)
+	})
+
+	n.handleChain("/export.dot", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		c.RLock()
+		dot := c.blockchain.ExportDOT()
+		c.RUnlock()
+
+		w.Header().Set("Content-Type", "text/vnd.graphviz; charset=utf-8")
+		fmt.Fprint(w, dot)
+	})
+
+	n.handleChain("/verify", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		peer := strings.TrimSpace(r.URL.Query().Get("peer"))
+		if peer == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w peer", errMissingQueryArgument))
+			return
+		}
+
+		// 只访问已注册的节点，不能借助本节点请求任意地址
+		peer, err := n.registeredPeer(peer)
+		switch {
+		case errors.Is(err, ErrNodeNotFound):
+			writeError(w, http.StatusForbidden, err)
+			return
+		case err != nil:
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		report, err := c.VerifyPeer(peer)
+		if err != nil {
+			writeError(w, http.StatusBadGateway, err)
+			return
+		}
+
+		sendJSON(w, http.StatusOK, report)
+	})
+
+	// 在交易池中等待超过阈值仍未被打包的交易，用于排查手续费过低等原因
+	n.handleChain("/transactions/stale", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		olderThan := DefaultStaleAfter
+		if v := r.URL.Query().Get("olderThan"); v != "" {
+			parsed, err := time.ParseDuration(v)
+			if err != nil || parsed < 0 {
+				writeError(w, http.StatusBadRequest, errors.New("Invalid olderThan duration"))
+				return
+			}
+			olderThan = parsed
+		}
+
+		c.RLock()
+		defer c.RUnlock()
+
+		type staleTransaction struct {
+			Transaction
+			TxID       string    `json:"txid"`
+			ReceivedAt time.Time `json:"received_at"`
+			Waiting    float64   `json:"waiting_seconds"`
+		}
+		stale := []staleTransaction{}
+		for _, tx := range c.blockchain.StaleTransactions(olderThan) {
+			receivedAt, _ := c.blockchain.ArrivalTime(tx.ID())
+			stale = append(stale, staleTransaction{
+				Transaction: tx,
+				TxID:        tx.ID(),
+				ReceivedAt:  receivedAt,
+				Waiting:     time.Since(receivedAt).Seconds(),
+			})
+		}
+
+		response := struct {
+			OlderThan    string             `json:"older_than"`
+			Transactions []staleTransaction `json:"transactions"`
+		}{
+			OlderThan:    olderThan.String(),
+			Transactions: stale,
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.handleChain("/fees/estimate", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		c.RLock()
+		defer c.RUnlock()
+
+		sendJSON(w, http.StatusOK, c.blockchain.EstimateFees())
+	})
+
+	n.mux.HandleFunc("/nodes", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		nodes := n.Nodes()
+		response := struct {
+			Total int    `json:"total_nodes"`
+			Nodes []Node `json:"nodes"`
+		}{
+			Total: len(nodes),
+			Nodes: nodes,
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	// 共识：从所有已注册节点获取默认链，采用其中累计工作量最大的有效链
+	n.mux.HandleFunc("/nodes/resolve", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		replaced := n.ResolveConflicts()
+
+		c := n.chain(DefaultChainName)
+		c.RLock()
+		length := len(c.blockchain.Chain)
+		c.RUnlock()
 
 		response := struct {
-			Chain  []*Block `json:"chain"`
-			Length int      `json:"length"`
+			Message   string `json:"message"`
+			Replaced  bool   `json:"replaced"`
+			NewLength int    `json:"new_length"`
 		}{
-			Chain:  n.blockchain.Chain,
-			Length: len(n.blockchain.Chain),
+			Message:   "Our chain is authoritative",
+			Replaced:  replaced,
+			NewLength: length,
+		}
+		if replaced {
+			response.Message = "Our chain was replaced"
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.mux.HandleFunc("/nodes/deregister", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		var data struct {
+			NodeID string `json:"node_id"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %v", errInvalidRequestBody, err))
+			return
+		}
+
+		if err := n.DeregisterNode(data.NodeID); err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		response := struct {
+			Message string `json:"message"`
+			Total   int    `json:"total_nodes"`
+		}{
+			Message: "Node has been removed",
+			Total:   len(n.Nodes()),
 		}
 
 		sendJSON(w, http.StatusOK, response)
 	})
 
-	http.HandleFunc("/nodes/register", func(w http.ResponseWriter, r *http.Request) {
+	n.mux.HandleFunc("/nodes/register", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
-			http.Error(w, "Method not allowed", http.StatusMethodNotAllowed)
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
 			return
 		}
 
 		var data struct {
-			NodeID  string   `json:"node_id"`
-			Address string   `json:"address"`
+			NodeID  string `json:"node_id"`
+			Address string `json:"address"`
 		}
 
 		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
-			http.Error(w, "Invalid data", http.StatusBadRequest)
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %v", errInvalidRequestBody, err))
+			return
+		}
+
+		address, err := n.RegisterNode(data.NodeID, data.Address)
+		if errors.Is(err, ErrDuplicatePeer) {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
 			return
 		}
 
-		n.RegisterNode(data.NodeID, data.Address)
+		// 在后台记录对端节点的身份，获取失败不影响注册
+		n.lookupIdentity(data.NodeID, address)
+
+		n.RLock()
+		defer n.RUnlock()
 
 		response := struct {
 			Message string   `json:"message"`
@@ -187,10 +793,569 @@ func (n *Network) StartServer(port int) {
 		sendJSON(w, http.StatusCreated, response)
 	})
 
-	// 启动服务器
-	addr := fmt.Sprintf(":%d", port)
-	fmt.Printf("Starting server on port %d\n", port)
-	http.ListenAndServe(addr, nil)
+	n.handleChain("/search", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		query := strings.TrimSpace(r.URL.Query().Get("q"))
+		if query == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w q", errMissingQueryArgument))
+			return
+		}
+
+		c.RLock()
+		resultType, result := c.search(query)
+		c.RUnlock()
+
+		if block, ok := result.(*Block); ok {
+			result = renderBlock(block, parseViewOptions(r))
+		}
+
+		if result == nil {
+			writeError(w, http.StatusNotFound, errNotFound)
+			return
+		}
+
+		response := struct {
+			Type   string      `json:"type"`
+			Result interface{} `json:"result"`
+		}{
+			Type:   resultType,
+			Result: result,
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.handleChain("/address/{addr}/balance", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		address := r.PathValue("addr")
+
+		c.RLock()
+		defer c.RUnlock()
+
+		// 默认查询最新高度，直接使用维护的账户状态
+		height := len(c.blockchain.Chain) - 1
+		balance := c.blockchain.GetBalance(address)
+		if h := r.URL.Query().Get("height"); h != "" {
+			parsed, err := strconv.Atoi(h)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, errors.New("Invalid height"))
+				return
+			}
+			height = parsed
+
+			balance, err = c.blockchain.GetBalanceAtHeight(address, height)
+			if err != nil {
+				writeError(w, http.StatusBadRequest, err)
+				return
+			}
+		}
+
+		response := struct {
+			Address string `json:"address"`
+			Height  int    `json:"height"`
+			Balance Amount `json:"balance"`
+		}{
+			Address: address,
+			Height:  height,
+			Balance: balance,
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	// 余额的Merkle证明，节点设置了身份时附带签名的检查点，轻客户端可据此验证余额
+	n.handleChain("/state/proof", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		address := r.URL.Query().Get("address")
+		if address == "" {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w address", errMissingQueryArgument))
+			return
+		}
+
+		n.RLock()
+		identitySigner := n.identitySigner
+		n.RUnlock()
+
+		c.RLock()
+		defer c.RUnlock()
+
+		proof, err := c.blockchain.ProveBalance(address)
+		if err != nil {
+			writeError(w, http.StatusNotFound, err)
+			return
+		}
+
+		var snapshot *Snapshot
+		if identitySigner != nil {
+			signed, err := c.blockchain.SignSnapshot(identitySigner)
+			if err != nil {
+				writeError(w, http.StatusInternalServerError, err)
+				return
+			}
+			snapshot = &signed
+		}
+
+		response := struct {
+			StateProof
+			Snapshot *Snapshot `json:"snapshot,omitempty"`
+		}{
+			StateProof: proof,
+			Snapshot:   snapshot,
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	// 通过WebSocket推送交易确认进度，直到达到请求的确认深度
+	n.handleChain("/ws/confirmations", n.watchConfirmations)
+
+	// 自检：链（含创世区块）有效、账户状态与重放结果一致、链尾高度正确；未通过时返回503
+	n.handleChain("/selftest", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		c.RLock()
+		report := c.blockchain.SelfTest()
+		c.RUnlock()
+
+		status := http.StatusOK
+		if !report.Passed {
+			status = http.StatusServiceUnavailable
+		}
+		sendJSON(w, status, report)
+	})
+
+	// 奖励了某个矿工地址的区块，从新到旧分页返回
+	n.handleChain("/miner/{address}/blocks", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		address := r.PathValue("address")
+		offset, limit, err := parsePagination(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		c.RLock()
+		blocks := c.blockchain.MinedBlocks(address)
+		c.RUnlock()
+
+		total := len(blocks)
+		start := min(offset, total)
+		end := min(start+limit, total)
+
+		response := struct {
+			Address string        `json:"address"`
+			Total   int           `json:"total"`
+			Offset  int           `json:"offset"`
+			Limit   int           `json:"limit"`
+			Blocks  []BlockReward `json:"blocks"`
+		}{
+			Address: address,
+			Total:   total,
+			Offset:  offset,
+			Limit:   limit,
+			Blocks:  blocks[start:end],
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.handleChain("/addresses", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		offset, limit, err := parsePagination(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		c.RLock()
+		addresses := c.blockchain.AllAddresses()
+		c.RUnlock()
+
+		total := len(addresses)
+		start := min(offset, total)
+		end := min(start+limit, total)
+
+		response := struct {
+			Total     int      `json:"total"`
+			Offset    int      `json:"offset"`
+			Limit     int      `json:"limit"`
+			Addresses []string `json:"addresses"`
+		}{
+			Total:     total,
+			Offset:    offset,
+			Limit:     limit,
+			Addresses: addresses[start:end],
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.handleChain("/stats", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		window, err := parseBlockTimeWindow(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		c.RLock()
+		stats := c.blockchain.Stats(window)
+		c.RUnlock()
+		stats.Peers = n.PeerCount()
+
+		sendJSON(w, http.StatusOK, stats)
+	})
+
+	n.handleChain("/stats/blocktime", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		window, err := parseBlockTimeWindow(r)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		c.RLock()
+		stats := c.blockchain.BlockTimeStats(window)
+		c.RUnlock()
+
+		sendJSON(w, http.StatusOK, stats)
+	})
+
+	// 调试接口：强制采用提交的（有效且工作量更大的）链，用于测试链重组
+	n.handleChain("/debug/fork", func(w http.ResponseWriter, r *http.Request, c *namedChain) {
+		if !n.DebugEnabled {
+			writeError(w, http.StatusNotFound, errNotFound)
+			return
+		}
+		if r.Method != http.MethodPost {
+			writeError(w, http.StatusMethodNotAllowed, errMethodNotAllowed)
+			return
+		}
+
+		var data struct {
+			Chain []*Block `json:"chain"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&data); err != nil {
+			writeError(w, http.StatusBadRequest, fmt.Errorf("%w: %v", errInvalidRequestBody, err))
+			return
+		}
+		if err := checkBlocks(data.Chain); err != nil {
+			writeError(w, http.StatusBadRequest, err)
+			return
+		}
+
+		c.Lock()
+		defer c.Unlock()
+
+		if err := c.blockchain.ReplaceChain(data.Chain); err != nil {
+			writeError(w, http.StatusConflict, err)
+			return
+		}
+
+		response := struct {
+			Message string `json:"message"`
+			Length  int    `json:"length"`
+			Pending int    `json:"pending_transactions"`
+		}{
+			Message: "Chain replaced",
+			Length:  len(c.blockchain.Chain),
+			Pending: len(c.blockchain.Transactions),
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.mux.HandleFunc("/chains", func(w http.ResponseWriter, r *http.Request) {
+		response := struct {
+			Default string   `json:"default"`
+			Chains  []string `json:"chains"`
+		}{
+			Default: DefaultChainName,
+			Chains:  n.ChainNames(),
+		}
+
+		sendJSON(w, http.StatusOK, response)
+	})
+
+	n.mux.HandleFunc("/identity", func(w http.ResponseWriter, r *http.Request) {
+		identity, err := n.Identity()
+		if err != nil {
+			writeError(w, http.StatusServiceUnavailable, err)
+			return
+		}
+
+		sendJSON(w, http.StatusOK, identity)
+	})
+}
+
+// handleTransactionAndMine 处理 POST /transactions/new?mine=true：加入交易后立即出块
+// 出块与 /mine 一样打包交易池中的全部有效交易并使用当前难度；需使用 -instant-mine 启动
+func (n *Network) handleTransactionAndMine(w http.ResponseWriter, r *http.Request, c *namedChain, tx Transaction) {
+	if !n.InstantMineEnabled {
+		writeError(w, http.StatusForbidden, errInstantMineDisabled)
+		return
+	}
+	shares, err := n.rewardShares(r, c)
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := n.checkCanMine(); err != nil {
+		writeError(w, http.StatusServiceUnavailable, err)
+		return
+	}
+
+	c.Lock()
+	defer c.Unlock()
+
+	// 先检查出块间隔，避免交易已加入交易池却无法出块
+	if delay := c.blockchain.NextBlockDelay(); delay > 0 {
+		w.Header().Set("Retry-After", strconv.Itoa(int(math.Ceil(delay.Seconds()))))
+		writeError(w, http.StatusTooManyRequests, errTooSoonToMine)
+		return
+	}
+	if _, err := c.blockchain.AddTransaction(tx); err != nil {
+		writeError(w, addTransactionErrorStatus(err), err)
+		return
+	}
+	block, err := c.blockchain.MineContext(r.Context(), shares)
+	if err != nil {
+		writeError(w, miningErrorStatus(err), err)
+		return
+	}
+
+	response := struct {
+		Message string `json:"message"`
+		TxID    string `json:"txid"`
+		Block   *Block `json:"block"`
+	}{
+		Message: "Transaction mined",
+		TxID:    tx.ID(),
+		Block:   block,
+	}
+
+	sendJSON(w, http.StatusCreated, response)
+}
+
+// addTransactionErrorStatus 返回交易被拒绝时的HTTP状态：交易池已满是暂时的，返回503以便客户端稍后重试或提高手续费
+func addTransactionErrorStatus(err error) int {
+	if errors.Is(err, ErrMempoolFull) {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusBadRequest
+}
+
+// rewardShares 解析可选的矿池分账参数，如 ?rewards=addr1:0.7,addr2:0.3，未指定时全部奖励归 MinerAddress
+// 按链c的地址格式校验接收地址，在挖矿之前拒绝无效的分配
+func (n *Network) rewardShares(r *http.Request, c *namedChain) ([]RewardShare, error) {
+	shares := []RewardShare{{Address: n.minerAddress(), Fraction: 1}}
+	if spec := r.URL.Query().Get("rewards"); spec != "" {
+		parsed, err := ParseRewardSplit(spec)
+		if err != nil {
+			return nil, err
+		}
+		shares = parsed
+	}
+	if err := validateRewardShares(shares, c.blockchain.AddressFormat); err != nil {
+		return nil, err
+	}
+	return shares, nil
+}
+
+// minerAddress 返回默认的奖励地址
+func (n *Network) minerAddress() string {
+	if n.MinerAddress == "" {
+		return DefaultMinerAddress
+	}
+	return n.MinerAddress
+}
+
+const (
+	// defaultPageLimit 分页查询的默认每页数量
+	defaultPageLimit = 100
+	// maxPageLimit 分页查询允许的最大每页数量
+	maxPageLimit = 1000
+)
+
+// parsePagination 解析 ?offset=&limit= 分页参数
+func parsePagination(r *http.Request) (int, int, error) {
+	offset, limit := 0, defaultPageLimit
+
+	if v := r.URL.Query().Get("offset"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed < 0 {
+			return 0, 0, fmt.Errorf("invalid offset")
+		}
+		offset = parsed
+	}
+	if v := r.URL.Query().Get("limit"); v != "" {
+		parsed, err := strconv.Atoi(v)
+		if err != nil || parsed <= 0 || parsed > maxPageLimit {
+			return 0, 0, fmt.Errorf("invalid limit (1-%d)", maxPageLimit)
+		}
+		limit = parsed
+	}
+	return offset, limit, nil
+}
+
+// sendBlock 输出按高度或哈希查找到的区块：不存在时返回404，参数无效时返回400
+func sendBlock(w http.ResponseWriter, r *http.Request, block *Block, err error) {
+	if errors.Is(err, ErrBlockNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	sendJSON(w, http.StatusOK, renderBlock(block, parseViewOptions(r)))
+}
+
+// serveBlockPath 处理 /block/{index}/path：从 ?from= 到该区块的区块头路径
+func serveBlockPath(w http.ResponseWriter, r *http.Request, c *namedChain) {
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("Invalid block index"))
+		return
+	}
+	from := 0
+	if v := r.URL.Query().Get("from"); v != "" {
+		from, err = strconv.Atoi(v)
+		if err != nil {
+			writeError(w, http.StatusBadRequest, errors.New("Invalid from"))
+			return
+		}
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	path, err := c.blockchain.GetBlockPath(from, index)
+
+	if errors.Is(err, ErrPathTooLong) {
+		writeError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+
+	response := struct {
+		From            int           `json:"from"`
+		To              int           `json:"to"`
+		Length          int           `json:"length"`
+		PoWAlgorithm    string        `json:"pow_algorithm,omitempty"`
+		LegacyPoWHeight int           `json:"legacy_pow_height,omitempty"`
+		Path            []BlockHeader `json:"path"`
+	}{
+		From:            from,
+		To:              index,
+		Length:          len(path),
+		PoWAlgorithm:    c.blockchain.PoWAlgorithm,
+		LegacyPoWHeight: c.blockchain.LegacyPoWHeight,
+		Path:            path,
+	}
+
+	sendJSON(w, http.StatusOK, response)
+}
+
+// serveBlockPackage 处理 /block/{index}/package：可独立验证的单个区块（见 VerifyBlockPackage），以附件形式下载
+func serveBlockPackage(w http.ResponseWriter, r *http.Request, c *namedChain) {
+	index, err := strconv.Atoi(r.PathValue("index"))
+	if err != nil {
+		writeError(w, http.StatusBadRequest, errors.New("Invalid block index"))
+		return
+	}
+
+	c.RLock()
+	data, err := c.blockchain.ExportBlockPackage(index)
+	c.RUnlock()
+
+	if errors.Is(err, ErrBlockNotFound) {
+		writeError(w, http.StatusNotFound, err)
+		return
+	}
+	if err != nil {
+		writeError(w, http.StatusInternalServerError, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="block-%d.json"`, index))
+	w.Write(data)
+}
+
+// parseBlockTimeWindow 解析出块间隔统计的窗口参数 window，缺省为 defaultBlockTimeWindow
+func parseBlockTimeWindow(r *http.Request) (int, error) {
+	v := r.URL.Query().Get("window")
+	if v == "" {
+		return defaultBlockTimeWindow, nil
+	}
+	window, err := strconv.Atoi(v)
+	if err != nil || window <= 0 {
+		return 0, errors.New("Invalid window")
+	}
+	return window, nil
+}
+
+// search 判断查询内容是区块高度、区块哈希、交易ID还是地址，并返回对应的资源
+// 调用方需持有该链的读锁
+func (c *namedChain) search(query string) (string, interface{}) {
+	// 纯数字视为区块高度
+	if index, err := strconv.Atoi(query); err == nil {
+		if block, err := c.blockchain.GetBlockByIndex(index); err == nil {
+			return "block", block
+		}
+	}
+
+	// 64位十六进制串可能是区块哈希或交易ID
+	if isHexHash(query) {
+		if block, err := c.blockchain.GetBlockByHash(query); err == nil {
+			return "block", block
+		}
+		if tx, index, err := c.blockchain.FindTransaction(query); err == nil {
+			return "transaction", struct {
+				Transaction *Transaction `json:"transaction"`
+				BlockIndex  int          `json:"block_index"`
+			}{
+				Transaction: tx,
+				BlockIndex:  index,
+			}
+		}
+	}
+
+	// 其余情况视为地址
+	if txs := c.blockchain.GetAddressTransactions(query); len(txs) > 0 {
+		return "address", struct {
+			Address      string        `json:"address"`
+			Transactions []Transaction `json:"transactions"`
+		}{
+			Address:      query,
+			Transactions: txs,
+		}
+	}
+
+	return "", nil
+}
+
+// parseTimestamp 解析Unix秒数或RFC3339格式的时间
+func parseTimestamp(s string) (time.Time, error) {
+	if s == "" {
+		return time.Time{}, fmt.Errorf("ts is required")
+	}
+	if secs, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return time.Unix(secs, 0), nil
+	}
+	t, err := time.Parse(time.RFC3339, s)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid ts: expected unix seconds or RFC3339")
+	}
+	return t, nil
+}
+
+// isHexHash 判断字符串是否为SHA-256哈希的十六进制形式
+func isHexHash(s string) bool {
+	if len(s) != 64 {
+		return false
+	}
+	for _, c := range s {
+		if !strings.ContainsRune("0123456789abcdef", c) {
+			return false
+		}
+	}
+	return true
 }
 
 func sendJSON(w http.ResponseWriter, status int, data interface{}) {
@@ -0,0 +1,236 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+// newConsensusChain 创建每个区块都按10秒出块时间调整难度的区块链，创世区块提前一小时以便使用过去的时间戳
+func newConsensusChain(t *testing.T) *Blockchain {
+	t.Helper()
+	bc := NewBlockchainWithDifficulty(4)
+	bc.TargetBlockTime = 10 * time.Second
+	bc.RetargetInterval = 1
+	backdateGenesis(t, bc, time.Hour)
+	return bc
+}
+
+func TestResolveConflictsRejectsTamperedLongerChain(t *testing.T) {
+	local := newTestChain(t)
+	mustMine(t, local, "alice")
+	n := newTestNetwork(t, local)
+
+	remote := newTestChain(t)
+	for i := 0; i < 3; i++ {
+		mustMine(t, remote, "mallory")
+	}
+	// 与演示中的篡改相同：修改交易并重新计算该区块的哈希，之后的区块不再与它相连
+	remote.Chain[1].Transactions[0].Amount = 100 * Coin
+	remote.Chain[1].MerkleRoot = remote.Chain[1].txHash()
+	remote.Chain[1].Hash = remote.Chain[1].CalculateHash()
+	_, addr := startPeer(t, remote)
+	mustRegister(t, n, "mallory", addr)
+
+	tip := local.GetLastBlock().Hash
+	if n.ResolveConflicts() {
+		t.Fatal("被篡改的更长的链不应被采用")
+	}
+	if len(local.Chain) != 2 || local.GetLastBlock().Hash != tip {
+		t.Fatalf("本地链被修改为 %d 个区块", len(local.Chain))
+	}
+}
+
+func TestResolveConflictsRejectsLowWorkLongerChain(t *testing.T) {
+	// 本地：两个区块出得很快，第二个区块难度升为5比特
+	local := newConsensusChain(t)
+	genesis := local.GetLastBlock().Timestamp
+	mineAt(t, local, "alice", genesis)
+	mineAt(t, local, "alice", genesis)
+	n := newTestNetwork(t, local)
+
+	// 对端：六个区块出得很慢，难度逐步降到1比特，区块更多但累计工作量更小
+	remote := newConsensusChain(t)
+	for i := 1; i <= 6; i++ {
+		mineAt(t, remote, "mallory", remote.GetLastBlock().Timestamp+100)
+	}
+	if !local.IsValidChain(remote.Chain) {
+		t.Fatal("对端的链本身应符合本地的规则")
+	}
+	if chainWork(remote.Chain).Cmp(local.ChainWork()) >= 0 {
+		t.Fatalf("对端链的工作量 %s 应小于本地的 %s", chainWork(remote.Chain), local.ChainWork())
+	}
+
+	_, addr := startPeer(t, remote)
+	mustRegister(t, n, "mallory", addr)
+
+	if n.ResolveConflicts() {
+		t.Fatal("工作量更小的更长的链不应被采用")
+	}
+	if len(local.Chain) != 3 {
+		t.Fatalf("本地链被替换为 %d 个区块", len(local.Chain))
+	}
+}
+
+func TestResolveConflictsRejectsOffScheduleChain(t *testing.T) {
+	local := NewBlockchainWithDifficulty(8)
+	mustMine(t, local, "alice")
+	n := newTestNetwork(t, local)
+
+	// 对端的链从1比特难度开始（起点难度取自链本身），但第二个区块未经难度调整就提高了难度：
+	// 总工作量超过本地，但难度不符合链自身的调整规则
+	remote := newTestChain(t)
+	mustMine(t, remote, "mallory")
+	mineWith(t, remote, "mallory", func(b *Block) { b.Difficulty = 12 })
+	if chainWork(remote.Chain).Cmp(local.ChainWork()) <= 0 {
+		t.Fatal("对端链的工作量应大于本地")
+	}
+	_, addr := startPeer(t, remote)
+	mustRegister(t, n, "mallory", addr)
+
+	if n.ResolveConflicts() {
+		t.Fatal("难度不符合调整规则的链不应被采用")
+	}
+	if len(local.Chain) != 2 {
+		t.Fatalf("本地链被替换为 %d 个区块", len(local.Chain))
+	}
+}
+
+func TestResolveEndpointAdoptsLongerChain(t *testing.T) {
+	local := newTestChain(t)
+	mustMine(t, local, "alice")
+	n := newTestNetwork(t, local)
+
+	// 对端在同一条链上多挖了两个区块
+	remote := copyChain(t, local)
+	mustMine(t, remote, "bob")
+	mustMine(t, remote, "bob")
+	_, addr := startPeer(t, remote)
+	mustRegister(t, n, "bob", addr)
+
+	var body struct {
+		Replaced  bool `json:"replaced"`
+		NewLength int  `json:"new_length"`
+	}
+	if status := getJSON(t, n, "/nodes/resolve", &body); status != http.StatusOK {
+		t.Fatalf("/nodes/resolve 返回 %d，应为 200", status)
+	}
+	if !body.Replaced || body.NewLength != 4 {
+		t.Fatalf("响应为 %+v，应采用对端的4个区块", body)
+	}
+	if tip := n.Blockchain(DefaultChainName).GetLastBlock().Hash; tip != remote.GetLastBlock().Hash {
+		t.Fatalf("本地链尾为 %s，应为对端的链尾 %s", tip, remote.GetLastBlock().Hash)
+	}
+
+	// 已与对端一致，再次解决冲突不替换
+	if status := getJSON(t, n, "/nodes/resolve", &body); status != http.StatusOK || body.Replaced {
+		t.Fatalf("再次解决冲突返回 %d %+v，不应替换本地链", status, body)
+	}
+}
+
+func TestBlockLookup(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	mustMine(t, bc, "bob")
+	n := newTestNetwork(t, bc)
+	want := bc.Chain[1]
+
+	for _, target := range []string{"/block/1", "/block/" + want.Hash, "/block/hash/" + want.Hash, "/chains/" + DefaultChainName + "/block/hash/" + want.Hash} {
+		var block Block
+		if status := getJSON(t, n, target, &block); status != http.StatusOK {
+			t.Fatalf("%s 返回 %d，应为 200", target, status)
+		}
+		if block.Index != 1 || block.Hash != want.Hash {
+			t.Fatalf("%s 得到高度 %d、哈希 %s，应为区块 1", target, block.Index, block.Hash)
+		}
+	}
+
+	// 与 /block/hash/{hash} 共用路由分派的子资源仍可访问
+	var path struct {
+		Length int `json:"length"`
+	}
+	if status := getJSON(t, n, "/block/2/path", &path); status != http.StatusOK || path.Length != 3 {
+		t.Fatalf("/block/2/path 返回 %d，路径长度 %d，应为 200 与 3", status, path.Length)
+	}
+	if status := getJSON(t, n, "/block/1/package", nil); status != http.StatusOK {
+		t.Fatalf("/block/1/package 返回 %d，应为 200", status)
+	}
+}
+
+func TestBlockLookupErrors(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	n := newTestNetwork(t, bc)
+
+	unknown := strings.Repeat("ab", 32)
+	tests := []struct {
+		name   string
+		target string
+		status int
+		code   string
+	}{
+		{"unknown hash", "/block/" + unknown, http.StatusNotFound, "block_not_found"},
+		{"index out of range", "/block/2", http.StatusNotFound, "block_not_found"},
+		{"negative index", "/block/-1", http.StatusNotFound, "block_not_found"},
+		{"neither index nor hash", "/block/tip", http.StatusBadRequest, "bad_request"},
+		{"unknown hash by hash", "/block/hash/" + unknown, http.StatusNotFound, "block_not_found"},
+		{"index by hash", "/block/hash/1", http.StatusBadRequest, "bad_request"},
+		{"unknown block resource", "/block/1/unknown", http.StatusNotFound, "not_found"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				Code string `json:"code"`
+			}
+			if status := getJSON(t, n, tt.target, &body); status != tt.status {
+				t.Fatalf("%s 返回 %d，应为 %d", tt.target, status, tt.status)
+			}
+			if body.Code != tt.code {
+				t.Fatalf("错误码为 %q，应为 %q", body.Code, tt.code)
+			}
+		})
+	}
+}
+
+func TestNetworksHaveIndependentMuxes(t *testing.T) {
+	short := newTestChain(t)
+	long := newTestChain(t)
+	mustMine(t, long, "alice")
+	n1 := newTestNetwork(t, short)
+	n2 := newTestNetwork(t, long)
+
+	if n1.Handler() == n2.Handler() {
+		t.Fatal("两个网络不应共用同一个路由")
+	}
+	if n1.Handler() != n1.Handler() {
+		t.Fatal("同一个网络应只注册一次路由")
+	}
+
+	for _, tt := range []struct {
+		n      *Network
+		length int
+	}{{n1, 1}, {n2, 2}} {
+		var body struct {
+			Length int `json:"length"`
+		}
+		if status := getJSON(t, tt.n, "/chain", &body); status != http.StatusOK || body.Length != tt.length {
+			t.Fatalf("/chain 返回 %d，长度 %d，应为各自链的长度 %d", status, body.Length, tt.length)
+		}
+	}
+
+	// 只加到一个网络上的路由不影响另一个网络，也不注册到全局的 http.DefaultServeMux
+	n1.mux.HandleFunc("/test/only-n1", func(w http.ResponseWriter, r *http.Request) {})
+	if status := getJSON(t, n1, "/test/only-n1", nil); status != http.StatusOK {
+		t.Fatalf("n1 的路由返回 %d，应为 200", status)
+	}
+	if status := getJSON(t, n2, "/test/only-n1", nil); status != http.StatusNotFound {
+		t.Fatalf("n2 访问 n1 的路由返回 %d，应为 404", status)
+	}
+	for _, path := range []string{"/chain", "/test/only-n1"} {
+		if _, pattern := http.DefaultServeMux.Handler(httptest.NewRequest(http.MethodGet, path, nil)); pattern != "" {
+			t.Fatalf("%s 不应注册到 http.DefaultServeMux（模式 %q）", path, pattern)
+		}
+	}
+}
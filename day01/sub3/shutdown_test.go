@@ -0,0 +1,91 @@
+package main
+
+import (
+	"context"
+	"io"
+	"net"
+	"net/http"
+	"testing"
+	"time"
+)
+
+func TestServeDrainsInFlightRequestsOnCancel(t *testing.T) {
+	n := newTestNetwork(t, newTestChain(t))
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	n.Handler()
+	n.mux.HandleFunc("/test/slow", func(w http.ResponseWriter, r *http.Request) {
+		close(entered)
+		<-release
+		io.WriteString(w, "done")
+	})
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	served := make(chan error, 1)
+	go func() { served <- n.serve(ctx, ln) }()
+
+	type result struct {
+		body string
+		err  error
+	}
+	inFlight := make(chan result, 1)
+	go func() {
+		resp, err := http.Get("http://" + addr + "/test/slow")
+		if err != nil {
+			inFlight <- result{err: err}
+			return
+		}
+		defer resp.Body.Close()
+		body, err := io.ReadAll(resp.Body)
+		inFlight <- result{string(body), err}
+	}()
+	<-entered
+
+	cancel()
+	// 关闭后不再接受新连接，但处理中的请求没有完成之前 serve 不返回
+	waitFor(t, "停止接受新连接", func() bool {
+		conn, err := net.Dial("tcp", addr)
+		if err == nil {
+			conn.Close()
+		}
+		return err != nil
+	})
+	select {
+	case err := <-served:
+		t.Fatalf("处理中的请求完成之前 serve 就返回了: %v", err)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	if r := <-inFlight; r.err != nil || r.body != "done" {
+		t.Fatalf("处理中的请求结果为 %q, %v，应正常完成", r.body, r.err)
+	}
+	select {
+	case err := <-served:
+		if err != nil {
+			t.Fatalf("serve 返回 %v，应为nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("请求完成后 serve 没有返回")
+	}
+}
+
+func TestStartServerReturnsListenError(t *testing.T) {
+	ln, err := net.Listen("tcp", ":0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer ln.Close()
+
+	n := newTestNetwork(t, newTestChain(t))
+	port := ln.Addr().(*net.TCPAddr).Port
+	if err := n.StartServer(context.Background(), port); err == nil {
+		t.Fatalf("端口 %d 已被占用，StartServer 应返回错误", port)
+	}
+}
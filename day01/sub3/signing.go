@@ -0,0 +1,71 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"errors"
+	"fmt"
+
+	"openspace/day01/signer"
+)
+
+// ErrInvalidSignature 交易签名缺失、格式不正确或与签名内容不符
+var ErrInvalidSignature = errors.New("交易签名无效")
+
+// SigningBytes 返回交易被签名的规范字节：除签名本身以外的全部字段（含公钥）
+// 签名与验证都使用它，签名不会出现在被签名的内容中；交易ID也由它计算而不随签名变化，
+// 修改任何被签名的字段都会使签名失效
+func (tx Transaction) SigningBytes() []byte {
+	return tx.canonicalJSON()
+}
+
+// IsSigned 判断交易是否带有签名或公钥
+func (tx Transaction) IsSigned() bool {
+	return len(tx.Signature) > 0 || len(tx.PublicKey) > 0
+}
+
+// SignTransaction 用签名器为交易签名：写入签名器的公钥（PKIX DER），再对 SigningBytes 签名
+// 签名器的公钥须为RSA公钥
+func SignTransaction(tx *Transaction, s signer.Signer) error {
+	pub, ok := s.Public().(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("签名器的公钥不是RSA公钥")
+	}
+	der, err := x509.MarshalPKIXPublicKey(pub)
+	if err != nil {
+		return fmt.Errorf("编码公钥失败: %v", err)
+	}
+
+	tx.PublicKey = der
+	tx.Signature = nil
+	signature, err := signer.SignMessage(s, tx.SigningBytes())
+	if err != nil {
+		return err
+	}
+	tx.Signature = signature
+	return nil
+}
+
+// VerifyTransaction 用交易携带的公钥验证签名是否覆盖 SigningBytes
+// 只证明交易由该公钥的持有者签名且未被篡改，发送方地址与公钥的对应关系由调用方按地址格式核对
+func VerifyTransaction(tx Transaction) error {
+	if len(tx.PublicKey) == 0 || len(tx.Signature) == 0 {
+		return fmt.Errorf("%w: 缺少公钥或签名", ErrInvalidSignature)
+	}
+	parsed, err := x509.ParsePKIXPublicKey(tx.PublicKey)
+	if err != nil {
+		return fmt.Errorf("%w: 解析公钥失败: %v", ErrInvalidSignature, err)
+	}
+	pub, ok := parsed.(*rsa.PublicKey)
+	if !ok {
+		return fmt.Errorf("%w: 公钥不是RSA公钥", ErrInvalidSignature)
+	}
+
+	digest := sha256.Sum256(tx.SigningBytes())
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, digest[:], tx.Signature); err != nil {
+		return fmt.Errorf("%w: %v", ErrInvalidSignature, err)
+	}
+	return nil
+}
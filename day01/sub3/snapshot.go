@@ -0,0 +1,124 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"openspace/day01/signer"
+)
+
+// ErrAddressNotFound 地址从未出现在交易中，不在状态树里
+var ErrAddressNotFound = errors.New("地址不存在")
+
+// Snapshot 表示区块链状态的签名检查点
+// 节点之间交换检查点后，无需从创世区块重新验证即可就状态达成一致
+type Snapshot struct {
+	Height    int    `json:"height"`     // 区块高度
+	TipHash   string `json:"tip_hash"`   // 最新区块的哈希
+	StateRoot string `json:"state_root"` // 余额状态的Merkle根
+	Signature []byte `json:"signature"`  // 对以上字段的签名
+}
+
+// payload 返回检查点中被签名的内容
+func (s Snapshot) payload() []byte {
+	return []byte(strconv.Itoa(s.Height) + "|" + s.TipHash + "|" + s.StateRoot)
+}
+
+// StateRoot 计算当前余额状态的Merkle根
+// 叶子为按地址排序后的 "地址:余额"
+func (bc *Blockchain) StateRoot() string {
+	_, leaves := bc.stateLeaves()
+	return merkleRoot(leaves)
+}
+
+// stateLeaves 返回按字典序排序的地址及对应的Merkle叶子哈希
+func (bc *Blockchain) stateLeaves() ([]string, []string) {
+	balances := bc.state.Balances()
+
+	addresses := make([]string, 0, len(balances))
+	for addr := range balances {
+		addresses = append(addresses, addr)
+	}
+	sort.Strings(addresses)
+
+	leaves := make([]string, 0, len(addresses))
+	for _, addr := range addresses {
+		leaves = append(leaves, stateLeaf(addr, balances[addr]))
+	}
+	return addresses, leaves
+}
+
+// stateLeaf 计算单个地址余额的Merkle叶子哈希
+func stateLeaf(address string, balance Amount) string {
+	return hashLeaf([]byte(address + ":" + balance.String()))
+}
+
+// StateProof 地址余额包含在状态根中的Merkle证明
+// 轻客户端拿到签名的检查点（Snapshot）后，只需验证此证明即可确认余额，无需重放区块链
+type StateProof struct {
+	Address   string       `json:"address"`
+	Balance   Amount       `json:"balance"`
+	Height    int          `json:"height"`     // 状态对应的区块高度
+	StateRoot string       `json:"state_root"` // 证明所针对的状态根
+	Proof     []MerkleStep `json:"proof"`      // 从叶子到状态根的路径
+}
+
+// ProveBalance 生成地址当前余额相对于当前状态根的Merkle证明
+// 从未出现在交易中的地址不在状态树中，返回ErrAddressNotFound
+func (bc *Blockchain) ProveBalance(address string) (StateProof, error) {
+	addresses, leaves := bc.stateLeaves()
+	index := sort.SearchStrings(addresses, address)
+	if index == len(addresses) || addresses[index] != address {
+		return StateProof{}, fmt.Errorf("%w: %s", ErrAddressNotFound, address)
+	}
+
+	return StateProof{
+		Address:   address,
+		Balance:   bc.state.Balance(address),
+		Height:    bc.GetLastBlock().Index,
+		StateRoot: merkleRoot(leaves),
+		Proof:     merkleProof(leaves, index),
+	}, nil
+}
+
+// VerifyStateProof 验证余额证明是否与给定的状态根（通常来自已验证签名的检查点）一致
+func VerifyStateProof(proof StateProof, stateRoot string) error {
+	if proof.StateRoot != stateRoot {
+		return fmt.Errorf("证明针对的状态根与给定状态根不一致")
+	}
+	if !verifyMerkleProof(stateLeaf(proof.Address, proof.Balance), proof.Proof, stateRoot) {
+		return fmt.Errorf("余额证明无效")
+	}
+	return nil
+}
+
+// SignSnapshot 生成当前区块链状态的检查点并使用签名器签名
+func (bc *Blockchain) SignSnapshot(s signer.Signer) (Snapshot, error) {
+	tip := bc.GetLastBlock()
+	snapshot := Snapshot{
+		Height:    tip.Index,
+		TipHash:   tip.Hash,
+		StateRoot: bc.StateRoot(),
+	}
+
+	signature, err := signer.SignMessage(s, snapshot.payload())
+	if err != nil {
+		return Snapshot{}, fmt.Errorf("签名检查点失败: %v", err)
+	}
+	snapshot.Signature = signature
+	return snapshot, nil
+}
+
+// VerifySnapshot 使用公钥验证检查点签名
+func (s Snapshot) VerifySnapshot(pub *rsa.PublicKey) error {
+	hash := sha256.Sum256(s.payload())
+	if err := rsa.VerifyPKCS1v15(pub, crypto.SHA256, hash[:], s.Signature); err != nil {
+		return fmt.Errorf("检查点签名无效: %v", err)
+	}
+	return nil
+}
@@ -0,0 +1,203 @@
+package main
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"sort"
+)
+
+// State 表示由已确认交易推导出的账户状态
+// 区块链在出块和链重组时维护它，避免每次查询都重放整条链
+type State struct {
+	balances  map[string]Amount
+	confirmed map[string]int           // 已确认的普通交易ID及其所在区块高度，用于拒绝重复交易
+	height    int                      // 已应用的最后一个区块的高度，空状态为-1
+	rewards   map[string][]BlockReward // 各地址获得挖矿奖励的区块，按高度从低到高
+
+	// 随区块增量维护的汇总值，统计接口无需重新扫描整条链
+	txCount int      // 已确认的交易总数（含挖矿奖励）
+	supply  Amount   // 流通总量：奖励交易发放的金额减去支付的手续费，等于所有余额之和
+	work    *big.Int // 已应用区块的累计工作量
+
+	// replayWindow 已确认交易ID的保留区块数，0表示永久保留
+	// 只记住最近的交易ID使内存有界；更早的交易已超出有效期（见 validateTxHeight），再次提交时因过期被拒绝
+	replayWindow int
+}
+
+// NewState 创建空状态，永久记住所有已确认的交易ID
+func NewState() *State {
+	return newStateWithWindow(0)
+}
+
+// newStateWithWindow 创建空状态，已确认的交易ID只保留replayWindow个区块
+func newStateWithWindow(replayWindow int) *State {
+	return &State{
+		balances:     make(map[string]Amount),
+		confirmed:    make(map[string]int),
+		rewards:      make(map[string][]BlockReward),
+		height:       -1,
+		work:         new(big.Int),
+		replayWindow: replayWindow,
+	}
+}
+
+// stateFromChain 按给定的重放保护窗口重放区块序列构建状态
+func stateFromChain(chain []*Block, replayWindow int) *State {
+	state := newStateWithWindow(replayWindow)
+	for _, block := range chain {
+		state.ApplyBlock(block)
+	}
+	return state
+}
+
+// BlockReward 地址在某个区块中获得的挖矿奖励
+type BlockReward struct {
+	Index  int    `json:"index"`  // 区块高度
+	Hash   string `json:"hash"`   // 区块哈希
+	Amount Amount `json:"amount"` // 该地址在此区块获得的奖励合计
+}
+
+// ApplyBlock 将区块中的交易应用到状态，记录挖矿奖励，并清理超出重放保护窗口的交易ID
+func (s *State) ApplyBlock(block *Block) {
+	for _, tx := range block.Transactions {
+		if block.isReward(tx) {
+			s.applyReward(tx)
+		} else {
+			s.applyTransaction(tx)
+		}
+	}
+	s.finishBlock(block)
+}
+
+// finishBlock 在区块的交易应用之后记录挖矿奖励、高度与工作量，并清理超出重放保护窗口的交易ID
+func (s *State) finishBlock(block *Block) {
+	s.recordRewards(block)
+	s.height = block.Index
+	s.work.Add(s.work, blockWork(block))
+	s.pruneConfirmed()
+}
+
+// ApplyValidatedBlock 与 ApplyBlock 相同，但先按当前状态用 ValidateTransaction 校验每笔普通交易
+// 交易按区块中的顺序依次应用，同一区块中靠后的交易可以花费靠前交易的转入。
+// 遇到无效交易时返回错误，此时状态已应用了部分交易，调用方应丢弃它
+func (s *State) ApplyValidatedBlock(block *Block) error {
+	for _, tx := range block.Transactions {
+		if block.isReward(tx) {
+			s.applyReward(tx)
+			continue
+		}
+		if err := ValidateTransaction(tx, s); err != nil {
+			return fmt.Errorf("交易 %s 无效: %w", tx.ID(), err)
+		}
+		s.applyTransaction(tx)
+	}
+	s.finishBlock(block)
+	return nil
+}
+
+// recordRewards 记录区块中的挖矿奖励，奖励分给多个地址时每个地址各记一条
+func (s *State) recordRewards(block *Block) {
+	amounts := make(map[string]Amount)
+	var recipients []string
+	for _, tx := range block.Transactions {
+		if !block.isReward(tx) {
+			continue
+		}
+		if _, ok := amounts[tx.Recipient]; !ok {
+			recipients = append(recipients, tx.Recipient)
+		}
+		amounts[tx.Recipient] += tx.Amount
+	}
+	for _, addr := range recipients {
+		s.rewards[addr] = append(s.rewards[addr], BlockReward{Index: block.Index, Hash: block.Hash, Amount: amounts[addr]})
+	}
+}
+
+// Rewards 返回地址获得挖矿奖励的区块（按高度从低到高），从未获得奖励时返回nil
+// 返回的切片与状态共享底层数组，调用方不能修改
+func (s *State) Rewards(address string) []BlockReward {
+	return s.rewards[address]
+}
+
+// pruneConfirmed 删除不再需要的交易ID：下一个区块（高度 height+1）中
+// 只有确认高度与之相差小于replayWindow的交易才算重复，与 validateChain 的规则一致
+func (s *State) pruneConfirmed() {
+	if s.replayWindow <= 0 {
+		return
+	}
+	for id, height := range s.confirmed {
+		if s.height+1-height >= s.replayWindow {
+			delete(s.confirmed, id)
+		}
+	}
+}
+
+// applyTransaction 将单笔普通交易应用到状态，手续费从发送方扣除，不计入接收方
+func (s *State) applyTransaction(tx Transaction) {
+	s.balances[tx.Sender] -= tx.Amount + tx.Fee
+	s.confirmed[tx.ID()] = s.height + 1
+	s.balances[tx.Recipient] += tx.Amount
+	s.supply -= tx.Fee
+	s.txCount++
+}
+
+// applyReward 将挖矿奖励计入接收方，奖励由网络新发行，没有扣款的一方
+func (s *State) applyReward(tx Transaction) {
+	s.balances[tx.Recipient] += tx.Amount
+	s.supply += tx.Amount
+	s.txCount++
+}
+
+// IsConfirmed 判断交易是否已在重放保护窗口内被确认
+func (s *State) IsConfirmed(txID string) bool {
+	_, ok := s.confirmed[txID]
+	return ok
+}
+
+// Balance 返回地址的余额
+func (s *State) Balance(address string) Amount {
+	return s.balances[address]
+}
+
+// Balances 返回所有地址余额的副本
+func (s *State) Balances() map[string]Amount {
+	balances := make(map[string]Amount, len(s.balances))
+	for addr, balance := range s.balances {
+		balances[addr] = balance
+	}
+	return balances
+}
+
+// Addresses 返回所有曾作为发送方或接收方出现过的地址（按字典序排序）
+func (s *State) Addresses() []string {
+	addresses := make([]string, 0, len(s.balances))
+	for addr := range s.balances {
+		addresses = append(addresses, addr)
+	}
+	sort.Strings(addresses)
+	return addresses
+}
+
+// Clone 复制状态
+func (s *State) Clone() *State {
+	confirmed := make(map[string]int, len(s.confirmed))
+	for id, height := range s.confirmed {
+		confirmed[id] = height
+	}
+	// 奖励记录只会追加，截断容量后各副本追加时会各自重新分配，因此可以共享
+	rewards := make(map[string][]BlockReward, len(s.rewards))
+	for addr, list := range s.rewards {
+		rewards[addr] = slices.Clip(list)
+	}
+	return &State{
+		balances:     s.Balances(),
+		confirmed:    confirmed,
+		height:       s.height,
+		rewards:      rewards,
+		txCount:      s.txCount,
+		supply:       s.supply,
+		work:         new(big.Int).Set(s.work),
+		replayWindow: s.replayWindow,
+	}
+}
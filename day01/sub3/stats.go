@@ -0,0 +1,66 @@
+package main
+
+// defaultBlockTimeWindow 出块间隔统计默认使用的区块数
+const defaultBlockTimeWindow = 10
+
+// BlockTimeStats 最近若干个区块之间的出块间隔统计（秒）
+type BlockTimeStats struct {
+	Intervals int     `json:"intervals"`       // 参与统计的间隔个数
+	Average   float64 `json:"average_seconds"` // 平均出块间隔
+	Min       int64   `json:"min_seconds"`     // 最短出块间隔
+	Max       int64   `json:"max_seconds"`     // 最长出块间隔
+}
+
+// BlockTimeStats 统计最近window个区块间隔的平均值、最小值和最大值
+// window 超过链长时按整条链统计，链上不足两个区块时返回零值
+func (bc *Blockchain) BlockTimeStats(window int) BlockTimeStats {
+	var stats BlockTimeStats
+	if window <= 0 || len(bc.Chain) < 2 {
+		return stats
+	}
+
+	start := max(len(bc.Chain)-1-window, 0)
+	var total int64
+	for i := start + 1; i < len(bc.Chain); i++ {
+		interval := bc.Chain[i].Timestamp - bc.Chain[i-1].Timestamp
+		if stats.Intervals == 0 || interval < stats.Min {
+			stats.Min = interval
+		}
+		if stats.Intervals == 0 || interval > stats.Max {
+			stats.Max = interval
+		}
+		total += interval
+		stats.Intervals++
+	}
+
+	stats.Average = float64(total) / float64(stats.Intervals)
+	return stats
+}
+
+// ChainStats 区块链的汇总统计，供浏览器首页一次取回
+type ChainStats struct {
+	Height          int            `json:"height"`             // 最新区块的高度
+	Transactions    int            `json:"total_transactions"` // 已确认的交易总数（含挖矿奖励）
+	Supply          Amount         `json:"total_supply"`       // 流通总量：奖励交易发放的金额减去支付的手续费
+	Difficulty      int            `json:"difficulty"`         // 下一个区块的挖矿难度（前导0比特数）
+	BlockTime       BlockTimeStats `json:"block_time"`         // 最近window个区块的出块间隔
+	BlockTimeWindow int            `json:"block_time_window"`
+	Pending         int            `json:"pending_transactions"` // 交易池中的交易数
+	Peers           int            `json:"peers"`                // 已注册的节点数（不含本节点），由调用方填写
+	ChainWork       string         `json:"chain_work"`           // 累计工作量（期望哈希次数）的十六进制表示
+}
+
+// Stats 汇总区块链的统计信息，出块间隔按最近window个区块计算
+// 交易数、流通总量与累计工作量取自随出块维护的账户状态，耗时与链长无关
+func (bc *Blockchain) Stats(window int) ChainStats {
+	return ChainStats{
+		Height:          bc.GetLastBlock().Index,
+		Transactions:    bc.state.txCount,
+		Supply:          bc.state.supply,
+		Difficulty:      bc.NextDifficulty(),
+		BlockTime:       bc.BlockTimeStats(window),
+		BlockTimeWindow: window,
+		Pending:         len(bc.Transactions),
+		ChainWork:       bc.ChainWork().Text(16),
+	}
+}
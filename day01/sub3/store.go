@@ -0,0 +1,192 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+)
+
+// Store 区块链的持久化后端
+// 区块链只通过它保存与读取整条链以及已归档的区块，不直接访问文件系统：
+// 默认使用 FileStore，测试可以换成不落盘的 MemoryStore，生产环境也可以接入键值数据库
+type Store interface {
+	// SaveChain 保存序列化后的整条区块链，替换之前保存的内容；返回nil时数据须已完整持久化
+	SaveChain(data []byte) error
+	// LoadChain 返回最近一次 SaveChain 保存的内容，尚未保存过时返回nil
+	LoadChain() ([]byte, error)
+	// SaveBlock 追加保存一个已归档的区块
+	SaveBlock(block *Block) error
+	// SaveBlocks 按顺序追加保存已归档的区块，返回nil时区块须已持久化
+	SaveBlocks(blocks []*Block) error
+	// LoadBlocks 按保存的顺序返回全部已归档的区块，尚未保存过区块时返回空列表
+	LoadBlocks() ([]*Block, error)
+}
+
+// FileStore 把整条链写入JSON文件，把已归档的区块逐行追加写入JSON Lines文件，两者都维护配套的 .sha256 校验和
+type FileStore struct {
+	Path      string // 归档文件
+	ChainPath string // 区块链文件，为空时不能保存整条链
+}
+
+// NewFileStore 创建以path为归档文件的存储
+func NewFileStore(path string) *FileStore {
+	return &FileStore{Path: path}
+}
+
+// NewFileStoreWithChain 创建以chainPath为区块链文件、archivePath为归档文件的存储
+func NewFileStoreWithChain(chainPath, archivePath string) *FileStore {
+	return &FileStore{Path: archivePath, ChainPath: chainPath}
+}
+
+// SaveChain 把区块链写入临时文件，校验和就位后再重命名为区块链文件
+// 中途失败不会破坏已有的文件；重命名前中断时旧文件与新校验和不一致，LoadChain 报告 ErrChecksumMismatch 而不会读到未经校验的数据
+func (s *FileStore) SaveChain(data []byte) error {
+	if s.ChainPath == "" {
+		return fmt.Errorf("未配置区块链文件")
+	}
+
+	tmp := s.ChainPath + ".tmp"
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("写入区块链文件失败: %v", err)
+	}
+	sum := sha256.Sum256(data)
+	if err := writeChecksumFile(s.ChainPath, hex.EncodeToString(sum[:])); err != nil {
+		os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(tmp, s.ChainPath); err != nil {
+		os.Remove(tmp)
+		return fmt.Errorf("写入区块链文件失败: %v", err)
+	}
+	return nil
+}
+
+// LoadChain 读取区块链文件并用配套的校验和校验，文件不存在时返回nil
+// 没有校验和的文件（手工编辑或旧版本写入）照常读取并输出警告
+func (s *FileStore) LoadChain() ([]byte, error) {
+	if s.ChainPath == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(s.ChainPath)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("读取区块链文件失败: %v", err)
+	}
+	verified, err := VerifyChecksum(s.ChainPath)
+	if err != nil {
+		return nil, err
+	}
+	if !verified {
+		fmt.Fprintf(os.Stderr, "警告: 区块链文件 %s 没有校验和，未校验完整性\n", s.ChainPath)
+	}
+	return data, nil
+}
+
+// SaveBlock 追加写入一个区块
+func (s *FileStore) SaveBlock(block *Block) error {
+	return s.SaveBlocks([]*Block{block})
+}
+
+// SaveBlocks 追加写入区块并同步到磁盘，然后更新校验和
+func (s *FileStore) SaveBlocks(blocks []*Block) error {
+	if s.Path == "" {
+		return fmt.Errorf("未配置归档文件")
+	}
+	f, err := os.OpenFile(s.Path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("打开归档文件失败: %v", err)
+	}
+	defer f.Close()
+
+	// 每行一个完整区块
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, block := range blocks {
+		if err := enc.Encode(block); err != nil {
+			return fmt.Errorf("写入归档文件失败: %v", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("写入归档文件失败: %v", err)
+	}
+	if err := f.Sync(); err != nil {
+		return fmt.Errorf("写入归档文件失败: %v", err)
+	}
+	return WriteChecksum(s.Path)
+}
+
+// LoadBlocks 读取归档文件中的所有区块，文件不存在时返回空列表
+func (s *FileStore) LoadBlocks() ([]*Block, error) {
+	if s.Path == "" {
+		return nil, nil
+	}
+	if _, err := os.Stat(s.Path); os.IsNotExist(err) {
+		return nil, nil
+	}
+	return LoadArchive(s.Path)
+}
+
+// MemoryStore 把区块链与区块保存在内存中，用于测试或不需要持久化的节点
+type MemoryStore struct {
+	mu     sync.Mutex
+	chain  []byte
+	blocks []*Block
+}
+
+// NewMemoryStore 创建空的内存存储
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{}
+}
+
+// SaveChain 保存数据的副本
+func (s *MemoryStore) SaveChain(data []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.chain = bytes.Clone(data)
+	return nil
+}
+
+// LoadChain 返回已保存数据的副本，尚未保存过时返回nil
+func (s *MemoryStore) LoadChain() ([]byte, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return bytes.Clone(s.chain), nil
+}
+
+// SaveBlock 保存区块的副本
+func (s *MemoryStore) SaveBlock(block *Block) error {
+	return s.SaveBlocks([]*Block{block})
+}
+
+// SaveBlocks 保存区块的副本，之后修改传入的区块不影响已保存的内容
+func (s *MemoryStore) SaveBlocks(blocks []*Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, block := range blocks {
+		s.blocks = append(s.blocks, block.Clone())
+	}
+	return nil
+}
+
+// LoadBlocks 返回已保存区块的副本
+func (s *MemoryStore) LoadBlocks() ([]*Block, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	blocks := make([]*Block, len(s.blocks))
+	for i, block := range s.blocks {
+		blocks[i] = block.Clone()
+	}
+	return blocks, nil
+}
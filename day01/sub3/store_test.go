@@ -0,0 +1,146 @@
+package main
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// newArchivedChain 创建以store为存储、内存中只保留一个完整区块的区块链，alice的前几笔奖励已被归档
+func newArchivedChain(t *testing.T, store Store) *Blockchain {
+	t.Helper()
+	bc := newTestChain(t)
+	bc.MaxChainBlocks = 1
+	bc.Store = store
+	for i := 0; i < 3; i++ {
+		mustMine(t, bc, "alice")
+	}
+	if !bc.Chain[1].isPruned() {
+		t.Fatal("区块 1 应已被归档")
+	}
+	return bc
+}
+
+func TestLoadBlockchainRestoresArchivedBalances(t *testing.T) {
+	bc := newArchivedChain(t, NewMemoryStore())
+	if err := bc.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBlockchain(bc.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loaded.GetBalance("alice"), bc.GetBalance("alice"); got != want {
+		t.Fatalf("加载后 alice 的余额为 %s，应为 %s", got, want)
+	}
+
+	// 只有区块链而没有归档时拒绝加载，而不是丢失已归档区块中的余额
+	data, err := bc.Store.LoadChain()
+	if err != nil {
+		t.Fatal(err)
+	}
+	withoutArchive := NewMemoryStore()
+	if err := withoutArchive.SaveChain(data); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := LoadBlockchain(withoutArchive); err == nil {
+		t.Fatal("缺少归档时应拒绝加载")
+	}
+}
+
+func TestLoadBlockchainFromEmptyStore(t *testing.T) {
+	if _, err := LoadBlockchain(NewMemoryStore()); !errors.Is(err, ErrChainNotSaved) {
+		t.Fatalf("空存储返回 %v，应为 ErrChainNotSaved", err)
+	}
+	if _, err := LoadFromFile(filepath.Join(t.TempDir(), "missing.json"), ""); !errors.Is(err, ErrChainNotSaved) {
+		t.Fatalf("不存在的文件返回 %v，应为 ErrChainNotSaved", err)
+	}
+}
+
+func TestMemoryStoreRoundTrip(t *testing.T) {
+	bc := newTestChain(t)
+	bc.Store = NewMemoryStore()
+	mustMine(t, bc, "alice")
+	mustAddTransaction(t, bc, transfer(bc, "alice", "bob", 1))
+	if err := bc.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBlockchain(bc.Store)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if loaded.GetLastBlock().Hash != bc.GetLastBlock().Hash {
+		t.Fatal("读回的链尾与保存的不一致")
+	}
+	if len(loaded.Transactions) != 1 {
+		t.Fatalf("读回 %d 笔待处理交易，应为 1", len(loaded.Transactions))
+	}
+
+	// 保存的是副本，之后修改区块不影响存储中的内容
+	block := bc.GetLastBlock().Clone()
+	if err := bc.Store.SaveBlock(block); err != nil {
+		t.Fatal(err)
+	}
+	block.Transactions[0].Recipient = "mallory"
+	blocks, err := bc.Store.LoadBlocks()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(blocks) != 1 || blocks[0].Transactions[0].Recipient != "alice" {
+		t.Fatal("修改传入的区块不应影响已保存的区块")
+	}
+}
+
+func TestFileStoreRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	chainPath, archivePath := filepath.Join(dir, "chain.json"), filepath.Join(dir, "archive.jsonl")
+	bc := newArchivedChain(t, NewFileStoreWithChain(chainPath, archivePath))
+	if err := bc.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	loaded, err := LoadBlockchain(NewFileStoreWithChain(chainPath, archivePath))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := loaded.GetBalance("alice"), bc.GetBalance("alice"); got != want {
+		t.Fatalf("加载后 alice 的余额为 %s，应为 %s", got, want)
+	}
+}
+
+func TestLoadFromFileDetectsCorruption(t *testing.T) {
+	tests := []struct {
+		name    string
+		corrupt func(data []byte) []byte
+	}{
+		{"truncated", func(data []byte) []byte { return data[:len(data)/2] }},
+		{"tampered", func(data []byte) []byte {
+			data[len(data)/2] ^= 1
+			return data
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := newTestChain(t)
+			mustMine(t, bc, "alice")
+			path := filepath.Join(t.TempDir(), "chain.json")
+			if err := bc.SaveToFile(path); err != nil {
+				t.Fatal(err)
+			}
+
+			data, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if err := os.WriteFile(path, tt.corrupt(data), 0644); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := LoadFromFile(path, ""); !errors.Is(err, ErrChecksumMismatch) {
+				t.Fatalf("加载损坏的文件返回 %v，应为 ErrChecksumMismatch", err)
+			}
+		})
+	}
+}
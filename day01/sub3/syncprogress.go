@@ -0,0 +1,54 @@
+package main
+
+import "sync"
+
+// syncProgress 默认链与对端同步的进度
+// 使用独立的锁，同步期间查询进度不会被链的写锁阻塞
+type syncProgress struct {
+	sync.Mutex
+	syncing bool
+	current int // 本地链的高度
+	target  int // 已知对端的最高高度
+}
+
+// start 开始一次同步，目标高度先记为本地高度
+func (p *syncProgress) start(height int) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.syncing = true
+	p.current = height
+	p.target = height
+}
+
+// observe 记录对端公布的高度
+func (p *syncProgress) observe(height int) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.target = max(p.target, height)
+}
+
+// advance 更新本地已应用到的高度
+func (p *syncProgress) advance(height int) {
+	p.Lock()
+	defer p.Unlock()
+
+	p.current = height
+}
+
+// finish 结束同步
+func (p *syncProgress) finish() {
+	p.Lock()
+	defer p.Unlock()
+
+	p.syncing = false
+}
+
+// snapshot 返回是否正在同步以及当前、目标高度
+func (p *syncProgress) snapshot() (bool, int, int) {
+	p.Lock()
+	defer p.Unlock()
+
+	return p.syncing, p.current, p.target
+}
@@ -0,0 +1,135 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// peerTipTimeout 查询对端链尾的超时时间，链尾很小，不可达的节点应尽快放弃
+const peerTipTimeout = 3 * time.Second
+
+// maxPeerTipSize 对端链尾响应的最大字节数，正常的链尾不到200字节
+const maxPeerTipSize = 4 << 10
+
+// ErrNoPeerResponded 没有任何已注册的节点返回链尾
+var ErrNoPeerResponded = errors.New("没有节点响应")
+
+// Tip 区块链的链尾：最新区块的高度、哈希与整条链的累计工作量
+type Tip struct {
+	Height    int    `json:"height"`
+	Hash      string `json:"hash"`
+	TotalWork string `json:"total_work"` // 累计工作量（期望哈希次数）的十六进制表示，与 /status 的 chain_work 相同
+}
+
+// Tip 返回本链的链尾
+func (bc *Blockchain) Tip() Tip {
+	last := bc.GetLastBlock()
+	return Tip{
+		Height:    last.Index,
+		Hash:      last.Hash,
+		TotalWork: bc.ChainWork().Text(16),
+	}
+}
+
+// fetchPeerTip 从对端节点获取链尾，path 为对端的链尾接口路径
+// 与 fetchPeerChain 一样不跟随重定向，响应超过 maxPeerTipSize 字节时返回错误；节点健康检查也用它探测对端
+func fetchPeerTip(address, path string) (Tip, error) {
+	resp, err := peerClient(peerTipTimeout).Get(fmt.Sprintf("http://%s%s", address, path))
+	if err != nil {
+		return Tip{}, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return Tip{}, fmt.Errorf("获取链尾失败: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPeerTipSize+1))
+	if err != nil {
+		return Tip{}, fmt.Errorf("读取链尾失败: %v", err)
+	}
+	if len(data) > maxPeerTipSize {
+		return Tip{}, fmt.Errorf("链尾响应超过 %d 字节", maxPeerTipSize)
+	}
+
+	var tip Tip
+	if err := json.Unmarshal(data, &tip); err != nil {
+		return Tip{}, fmt.Errorf("解析链尾失败: %v", err)
+	}
+	return tip, nil
+}
+
+// peerAddresses 返回所有已注册节点的地址
+func (n *Network) peerAddresses() []string {
+	n.RLock()
+	defer n.RUnlock()
+
+	var addresses []string
+	for _, node := range n.nodes {
+		addresses = append(addresses, node.Addresses...)
+	}
+	return addresses
+}
+
+// registeredPeer 规范化address并确认它是已注册节点的地址，不是时返回 ErrNodeNotFound
+// 由请求参数指定的出站请求只发往已注册的节点，避免节点被用来访问内网等任意地址
+func (n *Network) registeredPeer(address string) (string, error) {
+	addr, err := NormalizePeerAddress(address)
+	if err != nil {
+		return "", err
+	}
+	for _, peer := range n.peerAddresses() {
+		if peer == addr {
+			return addr, nil
+		}
+	}
+	return "", fmt.Errorf("%w: %s 不是已注册节点的地址", ErrNodeNotFound, addr)
+}
+
+// BestPeerTip 并发查询所有已注册节点默认链的链尾，返回累计工作量最大的节点（工作量相同时取高度更高的）
+// 用于同步前确定从哪个节点同步；不可达或响应无效的节点被忽略，只有没有任何节点响应时才返回错误
+func (n *Network) BestPeerTip() (address string, height int, hash string, err error) {
+	addresses := n.peerAddresses()
+	if len(addresses) == 0 {
+		return "", 0, "", fmt.Errorf("%w: 没有已注册的节点", ErrNoPeerResponded)
+	}
+
+	path := n.chain(DefaultChainName).peerPath("/tip")
+	tips := make([]Tip, len(addresses))
+	errs := make([]error, len(addresses))
+	var wg sync.WaitGroup
+	for i, addr := range addresses {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			tips[i], errs[i] = fetchPeerTip(addr, path)
+		}()
+	}
+	wg.Wait()
+
+	var bestWork *big.Int
+	best := -1
+	for i, tip := range tips {
+		if errs[i] != nil {
+			continue
+		}
+		work, ok := new(big.Int).SetString(tip.TotalWork, 16)
+		if !ok {
+			errs[i] = fmt.Errorf("无效的累计工作量 %q", tip.TotalWork)
+			continue
+		}
+		if best < 0 || work.Cmp(bestWork) > 0 || work.Cmp(bestWork) == 0 && tip.Height > tips[best].Height {
+			best, bestWork = i, work
+		}
+	}
+	if best < 0 {
+		return "", 0, "", fmt.Errorf("%w: 查询了 %d 个节点: %w", ErrNoPeerResponded, len(addresses), errors.Join(errs...))
+	}
+	return addresses[best], tips[best].Height, tips[best].Hash, nil
+}
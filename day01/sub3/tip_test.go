@@ -0,0 +1,54 @@
+package main
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestFetchPeerTip(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	_, addr := startPeer(t, bc)
+
+	tip, err := fetchPeerTip(addr, "/tip")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := bc.Tip(); tip != want {
+		t.Fatalf("链尾为 %+v，应为 %+v", tip, want)
+	}
+}
+
+func TestFetchPeerTipDoesNotFollowRedirects(t *testing.T) {
+	internal := false
+	hidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internal = true
+	}))
+	defer hidden.Close()
+	peer := httptest.NewServer(http.RedirectHandler(hidden.URL, http.StatusFound))
+	defer peer.Close()
+
+	if _, err := fetchPeerTip(peer.Listener.Addr().String(), "/tip"); err == nil {
+		t.Fatal("重定向的响应应被视为失败")
+	}
+	if internal {
+		t.Fatal("不应跟随对端的重定向")
+	}
+}
+
+func TestFetchPeerTipLimitsResponseSize(t *testing.T) {
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"height":1`)
+		fmt.Fprint(w, strings.Repeat(" ", maxPeerTipSize))
+		fmt.Fprint(w, `}`)
+	}))
+	defer peer.Close()
+
+	_, err := fetchPeerTip(peer.Listener.Addr().String(), "/tip")
+	if err == nil || !strings.Contains(err.Error(), "超过") {
+		t.Fatalf("超大的响应返回 %v，应报告超过大小限制", err)
+	}
+}
@@ -0,0 +1,151 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestTransactionID(t *testing.T) {
+	tx := Transaction{Sender: "alice", Recipient: "bob", Amount: 1, Timestamp: 1}
+	if tx.ID() != tx.ID() || len(tx.ID()) != 64 {
+		t.Fatalf("交易ID %q 应为确定的64位十六进制SHA-256", tx.ID())
+	}
+
+	later := tx
+	later.Timestamp = 2
+	if later.ID() == tx.ID() {
+		t.Fatal("时间戳不同的交易ID应不同")
+	}
+
+	signed := tx
+	signed.Signature = []byte{1, 2, 3}
+	if signed.ID() != tx.ID() {
+		t.Fatal("交易ID不应包含签名")
+	}
+}
+
+func TestCreateTransactionReturnsID(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+
+	first, _, err := bc.CreateTransaction("alice", "bob", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	second, _, err := bc.CreateTransaction("alice", "bob", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if first == second {
+		t.Fatal("内容相同的两笔转账应有不同的交易ID")
+	}
+	if got := bc.Transactions[0].ID(); got != first {
+		t.Fatalf("交易池中的交易ID为 %s，应为返回的 %s", got, first)
+	}
+}
+
+func TestAddTransactionRejectsDuplicateID(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	tx := transfer(bc, "alice", "bob", 1)
+	mustAddTransaction(t, bc, tx)
+
+	if _, err := bc.AddTransaction(tx); !errors.Is(err, ErrDuplicateTransaction) {
+		t.Fatalf("交易池中已有的交易返回 %v，应为 ErrDuplicateTransaction", err)
+	}
+	mustMine(t, bc, "miner")
+	if _, err := bc.AddTransaction(tx); !errors.Is(err, ErrDuplicateTransaction) {
+		t.Fatalf("已确认的交易返回 %v，应为 ErrDuplicateTransaction", err)
+	}
+}
+
+func TestFindTransaction(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	id, _, err := bc.CreateTransaction("alice", "bob", 1)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if _, _, err := bc.FindTransaction(id); !errors.Is(err, ErrTransactionNotFound) {
+		t.Fatalf("未确认的交易返回 %v，应为 ErrTransactionNotFound", err)
+	}
+	mustMine(t, bc, "miner")
+
+	tx, index, err := bc.FindTransaction(id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if index != 2 || tx.ID() != id || tx.Recipient != "bob" {
+		t.Fatalf("找到区块 %d 中的交易 %+v，应为区块 2 中的 %s", index, tx, id)
+	}
+	if _, index, err := bc.FindTransaction("unknown"); !errors.Is(err, ErrTransactionNotFound) || index != -1 {
+		t.Fatalf("不存在的交易返回 %d, %v，应为 -1, ErrTransactionNotFound", index, err)
+	}
+}
+
+func TestCreateTransactionRejectsInvalidTransfers(t *testing.T) {
+	tests := []struct {
+		name      string
+		sender    string
+		recipient string
+		amount    Amount
+		err       error
+	}{
+		{"zero amount", "alice", "bob", 0, ErrInvalidAmount},
+		{"negative amount", "alice", "bob", -1, ErrInvalidAmount},
+		{"empty sender", "", "bob", 1, ErrEmptyAddress},
+		{"empty recipient", "alice", "", 1, ErrEmptyAddress},
+		{"self transfer", "alice", "alice", 1, ErrSelfTransfer},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			bc := newTestChain(t)
+			mustMine(t, bc, "alice")
+			if _, _, err := bc.CreateTransaction(tt.sender, tt.recipient, tt.amount); !errors.Is(err, tt.err) {
+				t.Fatalf("返回 %v，应为 %v", err, tt.err)
+			}
+			if bc.MempoolSize() != 0 {
+				t.Fatal("被拒绝的交易不应进入交易池")
+			}
+		})
+	}
+}
+
+func TestNewTransactionEndpointRejectsInvalidTransfers(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	n := newTestNetwork(t, bc)
+
+	tests := []struct {
+		name string
+		body string
+		code string
+	}{
+		{"zero amount", `{"sender":"alice","recipient":"bob","amount":0}`, "invalid_amount"},
+		{"negative amount", `{"sender":"alice","recipient":"bob","amount":-1}`, "invalid_amount"},
+		{"not a number", `{"sender":"alice","recipient":"bob","amount":"NaN"}`, "invalid_request_body"},
+		{"infinite", `{"sender":"alice","recipient":"bob","amount":"Inf"}`, "invalid_request_body"},
+		{"empty recipient", `{"sender":"alice","recipient":"","amount":1}`, "empty_address"},
+		{"self transfer", `{"sender":"alice","recipient":"alice","amount":1}`, "self_transfer"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var body struct {
+				Error string `json:"error"`
+				Code  string `json:"code"`
+			}
+			if status := postJSON(t, n, "/transactions/new", json.RawMessage(tt.body), &body); status != http.StatusBadRequest {
+				t.Fatalf("返回 %d，应为 400", status)
+			}
+			if body.Code != tt.code || body.Error == "" {
+				t.Fatalf("响应为 %+v，错误码应为 %s 并附带错误说明", body, tt.code)
+			}
+		})
+	}
+	if got := pendingTransactions(n); got != 0 {
+		t.Fatalf("交易池有 %d 笔交易，被拒绝的交易不应进入交易池", got)
+	}
+}
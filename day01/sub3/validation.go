@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+)
+
+// MaxMemoLength 交易附言的最大字节数
+const MaxMemoLength = 256
+
+var (
+	// ErrEmptyAddress 发送方或接收方为空
+	ErrEmptyAddress = errors.New("发送方和接收方不能为空")
+	// ErrSelfTransfer 发送方与接收方相同
+	ErrSelfTransfer = errors.New("发送方与接收方不能相同")
+	// ErrInvalidAmount 金额不是正数
+	ErrInvalidAmount = errors.New("交易金额必须大于0")
+	// ErrInvalidFee 手续费为负数
+	ErrInvalidFee = errors.New("手续费不能为负数")
+	// ErrDustAmount 交易金额低于节点接受的最小金额
+	ErrDustAmount = errors.New("交易金额过小")
+	// ErrMemoTooLong 交易附言过长
+	ErrMemoTooLong = errors.New("交易附言过长")
+	// ErrDuplicateTransaction 交易已被确认或已在交易池中
+	ErrDuplicateTransaction = errors.New("重复的交易")
+	// ErrTransactionExpired 交易的 RefHeight 超出了重放保护窗口（或晚于链尾），不能再被打包
+	ErrTransactionExpired = errors.New("交易已过期")
+	// ErrInsufficientBalance 发送方余额不足
+	ErrInsufficientBalance = errors.New("余额不足")
+)
+
+// ValidateTransaction 根据给定状态校验一笔普通（非挖矿奖励）交易
+// 提交交易与挖矿打包都通过它校验，保证两条路径的规则一致。
+// 签名是可选的：带有签名或公钥的交易须通过 VerifyTransaction。其余校验地址、金额、手续费、附言长度、余额、
+// 交易是否已被确认，以及启用重放保护窗口时交易是否已过期（按下一个区块的高度判断）。
+func ValidateTransaction(tx Transaction, state *State) error {
+	if tx.Coinbase {
+		return ErrUnexpectedCoinbase
+	}
+	if tx.Sender == "" || tx.Recipient == "" {
+		return ErrEmptyAddress
+	}
+	if tx.IsSigned() {
+		if err := VerifyTransaction(tx); err != nil {
+			return err
+		}
+	}
+	if tx.Amount <= 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, tx.Amount)
+	}
+	if err := validateTxHeight(tx, state.height+1, state.replayWindow); err != nil {
+		return err
+	}
+	if state.IsConfirmed(tx.ID()) {
+		return fmt.Errorf("%w: %s 已被确认", ErrDuplicateTransaction, tx.ID())
+	}
+	if len(tx.Memo) > MaxMemoLength {
+		return fmt.Errorf("%w: %d 字节，最多 %d 字节", ErrMemoTooLong, len(tx.Memo), MaxMemoLength)
+	}
+	if tx.Fee < 0 {
+		return fmt.Errorf("%w: %s", ErrInvalidFee, tx.Fee)
+	}
+	if balance := state.Balance(tx.Sender); !canAfford(balance, tx.Amount, tx.Fee) {
+		return fmt.Errorf("%w: %s 的余额为 %s，需要 %s 加手续费 %s", ErrInsufficientBalance, tx.Sender, balance, tx.Amount, tx.Fee)
+	}
+	return nil
+}
+
+// canAfford 判断balance是否足以支付非负的amount与fee
+// 不计算 amount+fee：二者都接近 Amount 的上限时相加会溢出为负数，使余额检查被绕过
+func canAfford(balance, amount, fee Amount) bool {
+	return amount >= 0 && fee >= 0 && amount <= balance && fee <= balance-amount
+}
+
+// validateTxHeight 检查交易能否被打包进高度为height的区块：启用重放保护窗口（window>0）时，
+// 交易的 RefHeight 须早于该区块，且相差不超过window个区块。
+// 交易ID在确认后至少被记住window个区块，有效期内的重放总能被识别为重复，过期后则因过期被拒绝
+func validateTxHeight(tx Transaction, height, window int) error {
+	if window <= 0 {
+		return nil
+	}
+	if tx.RefHeight >= height {
+		return fmt.Errorf("%w: 参考高度 %d 不早于区块高度 %d", ErrTransactionExpired, tx.RefHeight, height)
+	}
+	if height-tx.RefHeight > window {
+		return fmt.Errorf("%w: 参考高度 %d 距区块高度 %d 超过 %d 个区块", ErrTransactionExpired, tx.RefHeight, height, window)
+	}
+	return nil
+}
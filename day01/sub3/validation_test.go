@@ -0,0 +1,235 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"errors"
+	"math"
+	"net/http"
+	"testing"
+
+	"openspace/day01/signer"
+)
+
+// newReplayChain 创建重放保护窗口为window的区块链，并为alice挖出一个区块的奖励
+func newReplayChain(t *testing.T, window int) *Blockchain {
+	t.Helper()
+	bc := newTestChain(t)
+	if err := bc.SetReplayWindow(window); err != nil {
+		t.Fatal(err)
+	}
+	mustMine(t, bc, "alice")
+	return bc
+}
+
+// transfer 构造以当前链尾为参考高度的转账
+func transfer(bc *Blockchain, sender, recipient string, amount Amount) Transaction {
+	return Transaction{
+		Sender:    sender,
+		Recipient: recipient,
+		Amount:    amount,
+		Timestamp: 1,
+		RefHeight: bc.GetLastBlock().Index,
+	}
+}
+
+func TestReplayWindowRejectsReplayAfterExpiry(t *testing.T) {
+	bc := newReplayChain(t, 3)
+	tx := transfer(bc, "alice", "bob", 1)
+	mustAddTransaction(t, bc, tx)
+	mustMine(t, bc, "miner")
+
+	// 窗口内再次提交：交易ID仍被记住
+	if _, err := bc.AddTransaction(tx); !errors.Is(err, ErrDuplicateTransaction) {
+		t.Fatalf("窗口内重放的错误为 %v，应为 ErrDuplicateTransaction", err)
+	}
+
+	// 窗口之外：交易ID已被遗忘，但交易已过期
+	mustMine(t, bc, "miner")
+	mustMine(t, bc, "miner")
+	if bc.state.IsConfirmed(tx.ID()) {
+		t.Fatal("超出窗口的交易ID不应再被记住")
+	}
+	if _, err := bc.AddTransaction(tx); !errors.Is(err, ErrTransactionExpired) {
+		t.Fatalf("窗口外重放的错误为 %v，应为 ErrTransactionExpired", err)
+	}
+
+	// 直接打包进区块的重放同样被拒绝
+	mineWith(t, bc, "miner", func(b *Block) { b.Transactions = append(b.Transactions, tx) })
+	if index, err := bc.validateChain(bc.Chain); !errors.Is(err, ErrTransactionExpired) || index != 5 {
+		t.Fatalf("校验结果为 区块 %d: %v，应拒绝区块 5 中过期的交易", index, err)
+	}
+}
+
+func TestReplayWindowRejectsFutureRefHeight(t *testing.T) {
+	bc := newReplayChain(t, 3)
+	tx := transfer(bc, "alice", "bob", 1)
+	tx.RefHeight = bc.GetLastBlock().Index + 1
+
+	if _, err := bc.AddTransaction(tx); !errors.Is(err, ErrTransactionExpired) {
+		t.Fatalf("参考高度晚于链尾的错误为 %v，应为 ErrTransactionExpired", err)
+	}
+}
+
+func TestReplayWindowDisabledIgnoresRefHeight(t *testing.T) {
+	bc := newReplayChain(t, 0)
+	tx := transfer(bc, "alice", "bob", 1)
+	tx.RefHeight = 0
+	mustMine(t, bc, "miner")
+	mustMine(t, bc, "miner")
+
+	mustAddTransaction(t, bc, tx)
+	mustMine(t, bc, "miner")
+	if _, err := bc.AddTransaction(tx); !errors.Is(err, ErrDuplicateTransaction) {
+		t.Fatalf("未启用窗口时重放的错误为 %v，应为 ErrDuplicateTransaction", err)
+	}
+}
+
+func TestSetReplayWindowRequiresEmptyChain(t *testing.T) {
+	bc := newReplayChain(t, 3)
+	if err := bc.SetReplayWindow(10); err == nil {
+		t.Fatal("链上已有区块时不应允许修改重放保护窗口")
+	}
+	if bc.ReplayWindow != 3 {
+		t.Fatalf("ReplayWindow 为 %d，应保持 3", bc.ReplayWindow)
+	}
+}
+
+func TestCreateTransactionSetsRefHeight(t *testing.T) {
+	bc := newReplayChain(t, 3)
+	if _, _, err := bc.CreateTransaction("alice", "bob", 1); err != nil {
+		t.Fatal(err)
+	}
+	if got := bc.Transactions[0].RefHeight; got != 1 {
+		t.Fatalf("RefHeight 为 %d，应为链尾高度 1", got)
+	}
+}
+
+func TestValidateChainRejectsOverdraftInBlock(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	// 不经过交易池直接打包：alice 只有一个区块的奖励
+	overdraft := transfer(bc, "alice", "bob", 2*miningReward)
+	mineWith(t, bc, "miner", func(b *Block) { b.Transactions = append(b.Transactions, overdraft) })
+
+	if index, err := bc.validateChain(bc.Chain); !errors.Is(err, ErrInsufficientBalance) || index != 2 {
+		t.Fatalf("校验结果为 区块 %d: %v，应以 ErrInsufficientBalance 拒绝区块 2", index, err)
+	}
+}
+
+func TestValidateTransactionRejectsOverflowingCost(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	tests := []struct {
+		name   string
+		sender string
+		amount Amount
+		fee    Amount
+	}{
+		{"max amount without balance", "mallory", math.MaxInt64, 1},
+		{"max amount with balance", "alice", math.MaxInt64, miningReward},
+		{"max fee", "alice", 1, math.MaxInt64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tx := transfer(bc, tt.sender, "bob", tt.amount)
+			tx.Fee = tt.fee
+			if err := ValidateTransaction(tx, bc.state); !errors.Is(err, ErrInsufficientBalance) {
+				t.Fatalf("ValidateTransaction 返回 %v，应为 ErrInsufficientBalance", err)
+			}
+		})
+	}
+
+	// 不经过交易池直接打包，整条链的校验同样拒绝
+	overflow := transfer(bc, "mallory", "bob", math.MaxInt64)
+	overflow.Fee = 1
+	mineWith(t, bc, "miner", func(b *Block) {
+		b.Transactions[0].Amount += overflow.Fee // 奖励交易须包含手续费
+		b.Transactions = append(b.Transactions, overflow)
+	})
+	if index, err := bc.validateChain(bc.Chain); !errors.Is(err, ErrInsufficientBalance) || index != 2 {
+		t.Fatalf("校验结果为 区块 %d: %v，应以 ErrInsufficientBalance 拒绝区块 2", index, err)
+	}
+}
+
+func TestValidateChainRejectsTamperedSignatureInBlock(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	tx := transfer(bc, "alice", "bob", 1)
+	if err := SignTransaction(&tx, signer.NewKeySigner(key)); err != nil {
+		t.Fatal(err)
+	}
+	// 签名之后修改金额
+	tx.Amount = 2
+	mineWith(t, bc, "miner", func(b *Block) { b.Transactions = append(b.Transactions, tx) })
+
+	if index, err := bc.validateChain(bc.Chain); !errors.Is(err, ErrInvalidSignature) || index != 2 {
+		t.Fatalf("校验结果为 区块 %d: %v，应以 ErrInvalidSignature 拒绝区块 2", index, err)
+	}
+}
+
+func TestValidateChainAppliesTransactionsInBlockOrder(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	// bob 花费同一区块中靠前的交易转入的金额
+	first := transfer(bc, "alice", "bob", 2)
+	second := transfer(bc, "bob", "carol", 1)
+	mineWith(t, bc, "miner", func(b *Block) { b.Transactions = append(b.Transactions, first, second) })
+	if !bc.IsChainValid() {
+		t.Fatal("花费同一区块中靠前交易转入的金额应有效")
+	}
+
+	reversed := newTestChain(t)
+	mustMine(t, reversed, "alice")
+	mineWith(t, reversed, "miner", func(b *Block) { b.Transactions = append(b.Transactions, second, first) })
+	if _, err := reversed.validateChain(reversed.Chain); !errors.Is(err, ErrInsufficientBalance) {
+		t.Fatalf("先花费后转入的区块返回 %v，应为 ErrInsufficientBalance", err)
+	}
+}
+
+func TestValidateChainRejectsDuplicateTransactions(t *testing.T) {
+	t.Run("same block", func(t *testing.T) {
+		bc := newTestChain(t)
+		mustMine(t, bc, "alice")
+		tx := transfer(bc, "alice", "bob", 1)
+		mineWith(t, bc, "miner", func(b *Block) { b.Transactions = append(b.Transactions, tx, tx) })
+
+		if index, err := bc.validateChain(bc.Chain); !errors.Is(err, ErrDuplicateTransaction) || index != 2 {
+			t.Fatalf("校验结果为 区块 %d: %v，应以 ErrDuplicateTransaction 拒绝区块 2", index, err)
+		}
+	})
+
+	t.Run("across blocks", func(t *testing.T) {
+		bc := newTestChain(t)
+		mustMine(t, bc, "alice")
+		tx := transfer(bc, "alice", "bob", 1)
+		mustAddTransaction(t, bc, tx)
+		mustMine(t, bc, "miner")
+		mineWith(t, bc, "miner", func(b *Block) { b.Transactions = append(b.Transactions, tx) })
+
+		if index, err := bc.validateChain(bc.Chain); !errors.Is(err, ErrDuplicateTransaction) || index != 3 {
+			t.Fatalf("校验结果为 区块 %d: %v，应以 ErrDuplicateTransaction 拒绝区块 3", index, err)
+		}
+	})
+}
+
+func TestNewTransactionEndpointRejectsDuplicate(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	n := newTestNetwork(t, bc)
+	tx := transfer(bc, "alice", "bob", 1)
+
+	if status := postJSON(t, n, "/transactions/new", tx, nil); status != http.StatusCreated {
+		t.Fatalf("第一次提交返回 %d，应为 201", status)
+	}
+	var body struct {
+		Code string `json:"code"`
+	}
+	if status := postJSON(t, n, "/transactions/new", tx, &body); status != http.StatusBadRequest || body.Code != "duplicate_transaction" {
+		t.Fatalf("重复提交返回 %d %s，应为 400 duplicate_transaction", status, body.Code)
+	}
+}
@@ -0,0 +1,150 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"time"
+)
+
+// peerFetchTimeout 获取对端区块链的超时时间
+const peerFetchTimeout = 10 * time.Second
+
+// maxPeerChainSize 对端区块链响应的最大字节数，防止恶意节点用超大的响应耗尽内存
+const maxPeerChainSize = 64 << 20
+
+// peerClient 返回访问对端节点的HTTP客户端：请求有超时，且不跟随重定向，只会访问给定的节点地址
+func peerClient(timeout time.Duration) *http.Client {
+	return &http.Client{
+		Timeout: timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+// ChainReport 对一条区块链的详细校验结果
+type ChainReport struct {
+	Valid        bool   `json:"valid"`                   // 是否有效
+	Length       int    `json:"length"`                  // 区块数
+	TotalWork    string `json:"total_work"`              // 累计工作量（各区块期望哈希次数之和）的十六进制表示，与 /tip 相同
+	FirstInvalid *int   `json:"first_invalid,omitempty"` // 第一个无效区块的高度
+	Reason       string `json:"reason,omitempty"`        // 无效的原因
+}
+
+// ValidateChainDetailed 按本链的规则校验给定的区块序列，报告第一个无效区块
+// 累计工作量只统计无效区块之前的部分
+func (bc *Blockchain) ValidateChainDetailed(chain []*Block) ChainReport {
+	report := ChainReport{Valid: true, Length: len(chain)}
+
+	valid := chain
+	if len(chain) == 0 {
+		report.Valid = false
+		report.Reason = "区块链为空"
+	} else if index, err := bc.validateChain(chain); err != nil {
+		report.Valid = false
+		first := index
+		if chain[index] != nil {
+			first = chain[index].Index
+		}
+		report.FirstInvalid = &first
+		report.Reason = err.Error()
+		valid = chain[:index]
+	}
+
+	report.TotalWork = chainWork(valid).Text(16)
+	return report
+}
+
+// ChainWork 返回整条链的累计工作量（期望的哈希次数），用于按工作量选择分叉
+// 由账户状态随出块维护，不重新扫描链；归档区块保留了区块头中的难度，同样计入
+func (bc *Blockchain) ChainWork() *big.Int {
+	return new(big.Int).Set(bc.state.work)
+}
+
+// chainWork 计算区块序列的累计工作量
+func chainWork(chain []*Block) *big.Int {
+	total := new(big.Int)
+	for _, block := range chain {
+		total.Add(total, blockWork(block))
+	}
+	return total
+}
+
+// blockWork 返回单个区块的工作量，难度为d比特的区块期望需要2^d次哈希
+func blockWork(block *Block) *big.Int {
+	return new(big.Int).Lsh(big.NewInt(1), uint(max(block.Difficulty, 0)))
+}
+
+// checkBlocks 检查解码得到的区块序列中没有空区块
+// JSON中的 null 解码为nil，计算工作量或校验之前须先排除，否则会引发panic
+func checkBlocks(chain []*Block) error {
+	for i, block := range chain {
+		if block == nil {
+			return fmt.Errorf("%w: 第 %d 个区块为空", ErrInvalidChain, i)
+		}
+	}
+	return nil
+}
+
+// fetchPeerChain 从对端节点获取区块链，path 为对端的区块链接口路径
+// 响应超过 maxPeerChainSize 字节或包含空区块时返回错误
+func fetchPeerChain(address, path string) ([]*Block, error) {
+	resp, err := peerClient(peerFetchTimeout).Get(fmt.Sprintf("http://%s%s", address, path))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("获取区块链失败: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxPeerChainSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("读取区块链失败: %v", err)
+	}
+	if len(data) > maxPeerChainSize {
+		return nil, fmt.Errorf("区块链响应超过 %d 字节", maxPeerChainSize)
+	}
+
+	var chainResp struct {
+		Chain []*Block `json:"chain"`
+	}
+	if err := json.Unmarshal(data, &chainResp); err != nil {
+		return nil, fmt.Errorf("解析区块链失败: %v", err)
+	}
+	if err := checkBlocks(chainResp.Chain); err != nil {
+		return nil, err
+	}
+	return chainResp.Chain, nil
+}
+
+// chainPath 返回该链在节点上的区块链接口路径
+func (c *namedChain) chainPath() string {
+	return c.peerPath("/chain")
+}
+
+// peerPath 返回该链的接口在对端节点上的路径：默认链不带前缀，其他链带 /chains/{name}
+func (c *namedChain) peerPath(endpoint string) string {
+	if c.name == DefaultChainName {
+		return endpoint
+	}
+	return "/chains/" + c.name + endpoint
+}
+
+// VerifyPeer 获取对端节点上同名的区块链并按本链的规则校验，不修改本地链
+// 调用方须确认address是可信的节点地址（/verify 只接受已注册的节点，见 Network.registeredPeer）
+func (c *namedChain) VerifyPeer(address string) (ChainReport, error) {
+	chain, err := fetchPeerChain(address, c.chainPath())
+	if err != nil {
+		return ChainReport{}, err
+	}
+
+	c.RLock()
+	defer c.RUnlock()
+
+	return c.blockchain.ValidateChainDetailed(chain), nil
+}
@@ -0,0 +1,188 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// getJSON 以GET请求访问网络的接口，返回状态码并把响应解析到v
+func getJSON(t *testing.T, n *Network, target string, v any) int {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	n.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+	if v != nil {
+		if err := json.Unmarshal(rec.Body.Bytes(), v); err != nil {
+			t.Fatalf("解析 %s 的响应失败: %v", target, err)
+		}
+	}
+	return rec.Code
+}
+
+func TestVerifyRegisteredPeer(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	_, addr := startPeer(t, copyChain(t, bc))
+
+	n := newTestNetwork(t, bc)
+	mustRegister(t, n, "peer", addr)
+
+	var report ChainReport
+	if status := getJSON(t, n, "/verify?peer="+addr, &report); status != http.StatusOK {
+		t.Fatalf("校验已注册节点返回 %d，应为 200", status)
+	}
+	if !report.Valid || report.Length != 2 {
+		t.Fatalf("校验结果为 %+v，应为有效的2个区块", report)
+	}
+	if want := bc.Tip().TotalWork; report.TotalWork != want {
+		t.Fatalf("累计工作量为 %s，应与 /tip 一样为十六进制的 %s", report.TotalWork, want)
+	}
+}
+
+func TestVerifyRejectsUnregisteredPeer(t *testing.T) {
+	requested := false
+	target := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requested = true
+	}))
+	defer target.Close()
+
+	n := newTestNetwork(t, newTestChain(t))
+	var body struct {
+		Code string `json:"code"`
+	}
+	if status := getJSON(t, n, "/verify?peer="+target.Listener.Addr().String(), &body); status != http.StatusForbidden {
+		t.Fatalf("校验未注册的地址返回 %d，应为 403", status)
+	}
+	if body.Code != "node_not_found" {
+		t.Fatalf("错误码为 %q，应为 node_not_found", body.Code)
+	}
+	if requested {
+		t.Fatal("不应向未注册的地址发起请求")
+	}
+}
+
+func TestFetchPeerChainDoesNotFollowRedirects(t *testing.T) {
+	internal := false
+	hidden := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		internal = true
+	}))
+	defer hidden.Close()
+	peer := httptest.NewServer(http.RedirectHandler(hidden.URL, http.StatusFound))
+	defer peer.Close()
+
+	if _, err := fetchPeerChain(peer.Listener.Addr().String(), "/chain"); err == nil {
+		t.Fatal("重定向的响应应被视为失败")
+	}
+	if internal {
+		t.Fatal("不应跟随对端的重定向")
+	}
+}
+
+func TestFetchPeerChainLimitsResponseSize(t *testing.T) {
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"chain":[]`)
+		fmt.Fprint(w, strings.Repeat(" ", maxPeerChainSize))
+		fmt.Fprint(w, `}`)
+	}))
+	defer peer.Close()
+
+	_, err := fetchPeerChain(peer.Listener.Addr().String(), "/chain")
+	if err == nil || !strings.Contains(err.Error(), "超过") {
+		t.Fatalf("超大的响应返回 %v，应报告超过大小限制", err)
+	}
+}
+
+// startNullChainPeer 启动一个 /chain 返回 {"chain":[null]} 的对端，返回其 host:port 地址
+func startNullChainPeer(t *testing.T) string {
+	t.Helper()
+	peer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `{"chain":[null]}`)
+	}))
+	t.Cleanup(peer.Close)
+	return peer.Listener.Addr().String()
+}
+
+func TestFetchPeerChainRejectsNullBlocks(t *testing.T) {
+	if _, err := fetchPeerChain(startNullChainPeer(t), "/chain"); !errors.Is(err, ErrInvalidChain) {
+		t.Fatalf("包含空区块的响应返回 %v，应为 ErrInvalidChain", err)
+	}
+}
+
+func TestNullBlocksDoNotPanic(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	n := newTestNetwork(t, bc)
+	addr := startNullChainPeer(t)
+	mustRegister(t, n, "mallory", addr)
+
+	if n.ResolveConflicts() {
+		t.Fatal("包含空区块的链不应被采用")
+	}
+	if status := getJSON(t, n, "/verify?peer="+addr, nil); status != http.StatusBadGateway {
+		t.Fatalf("校验返回空区块的节点返回 %d，应为 502", status)
+	}
+
+	n.DebugEnabled = true
+	if status := postJSON(t, n, "/debug/fork", map[string][]*Block{"chain": {bc.Chain[0], nil}}, nil); status != http.StatusBadRequest {
+		t.Fatalf("提交包含空区块的链返回 %d，应为 400", status)
+	}
+	if err := bc.ReplaceChain([]*Block{bc.Chain[0], nil}); !errors.Is(err, ErrInvalidChain) {
+		t.Fatalf("ReplaceChain 返回 %v，应为 ErrInvalidChain", err)
+	}
+	if report := bc.ValidateChainDetailed([]*Block{bc.Chain[0], nil}); report.Valid || report.FirstInvalid == nil || *report.FirstInvalid != 1 {
+		t.Fatalf("校验结果为 %+v，应指出第 1 个区块无效", report)
+	}
+	if err := (&Blockchain{}).FromJSON([]byte(`{"chain":[null]}`)); !errors.Is(err, ErrInvalidChain) {
+		t.Fatalf("FromJSON 返回 %v，应为 ErrInvalidChain", err)
+	}
+	if len(bc.Chain) != 2 {
+		t.Fatalf("本地链被替换为 %d 个区块", len(bc.Chain))
+	}
+}
+
+func TestChainWorkSumsMixedDifficulties(t *testing.T) {
+	blocks := []*Block{{Difficulty: 0}, {Difficulty: 4}, {Difficulty: 5}, {Difficulty: 100}}
+	want := new(big.Int).Lsh(big.NewInt(1), 100)
+	want.Add(want, big.NewInt(1+16+32))
+	if got := chainWork(blocks); got.Cmp(want) != 0 {
+		t.Fatalf("chainWork = %s，应为 %s", got, want)
+	}
+}
+
+func TestChainWorkTracksMinedBlocks(t *testing.T) {
+	// 出块很快，每个区块难度加1：4、5、6比特
+	bc := newConsensusChain(t)
+	genesis := bc.GetLastBlock().Timestamp
+	for i := 0; i < 3; i++ {
+		mineAt(t, bc, "miner", genesis)
+	}
+	want := big.NewInt(0)
+	for _, block := range bc.Chain {
+		want.Add(want, new(big.Int).Lsh(big.NewInt(1), uint(block.Difficulty)))
+	}
+	if bc.Chain[1].Difficulty == bc.Chain[3].Difficulty {
+		t.Fatal("测试需要难度不同的区块")
+	}
+	if got := bc.ChainWork(); got.Cmp(want) != 0 || got.Cmp(chainWork(bc.Chain)) != 0 {
+		t.Fatalf("ChainWork = %s，应为各区块 2^难度 之和 %s", got, want)
+	}
+
+	// 返回的是副本，修改它不影响链的累计工作量
+	bc.ChainWork().SetInt64(0)
+	if bc.ChainWork().Cmp(want) != 0 {
+		t.Fatal("修改 ChainWork 的返回值不应影响区块链")
+	}
+
+	var status NodeStatus
+	if code := getJSON(t, newTestNetwork(t, bc), "/status", &status); code != http.StatusOK {
+		t.Fatalf("/status 返回 %d", code)
+	}
+	if got := status.Chains[DefaultChainName].ChainWork; got != want.Text(16) {
+		t.Fatalf("/status 的 chain_work 为 %s，应为十六进制的 %s", got, want.Text(16))
+	}
+}
@@ -0,0 +1,126 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// ViewOptions 控制API响应中区块的展示方式，不影响区块的规范序列化与哈希
+type ViewOptions struct {
+	Compact bool // 紧凑模式：省略零值字段与可由相邻区块推导的字段
+	RFC3339 bool // 时间戳以RFC3339字符串（UTC）代替Unix整数输出
+}
+
+// parseViewOptions 从查询参数解析展示选项，如 ?compact=1、?time=rfc3339
+func parseViewOptions(r *http.Request) ViewOptions {
+	compact := r.URL.Query().Get("compact")
+	return ViewOptions{
+		Compact: compact == "1" || compact == "true",
+		RFC3339: r.URL.Query().Get("time") == "rfc3339",
+	}
+}
+
+// blockTime 按展示选项输出区块时间戳（Unix秒）
+func (opts ViewOptions) blockTime(ts int64) interface{} {
+	if !opts.RFC3339 {
+		return ts
+	}
+	return time.Unix(ts, 0).UTC().Format(time.RFC3339)
+}
+
+// transactionTime 按展示选项输出交易时间戳（Unix纳秒），未设置时间戳时返回nil
+func (opts ViewOptions) transactionTime(ts int64) interface{} {
+	if ts == 0 {
+		return nil
+	}
+	if !opts.RFC3339 {
+		return ts
+	}
+	return time.Unix(0, ts).UTC().Format(time.RFC3339Nano)
+}
+
+// transactionView 展示用的交易，时间戳按展示选项输出
+type transactionView struct {
+	Transaction
+	Timestamp interface{} `json:"timestamp,omitempty"`
+}
+
+// newTransactionViews 将交易列表转换为展示表示
+func newTransactionViews(txs []Transaction, opts ViewOptions) []transactionView {
+	views := make([]transactionView, len(txs))
+	for i, tx := range txs {
+		views[i] = transactionView{Transaction: tx, Timestamp: opts.transactionTime(tx.Timestamp)}
+	}
+	return views
+}
+
+// blockView 非紧凑模式下需要转换时间戳时的区块表示，其余字段与Block相同
+type blockView struct {
+	*Block
+	Timestamp    interface{}       `json:"timestamp"`
+	Transactions []transactionView `json:"transactions"`
+}
+
+// compactBlock 紧凑模式下的区块表示
+type compactBlock struct {
+	Version      int               `json:"version,omitempty"`
+	Index        int               `json:"index"`
+	Timestamp    interface{}       `json:"timestamp"`
+	Transactions []transactionView `json:"transactions,omitempty"`
+	Nonce        int64             `json:"nonce,omitempty"`
+	Difficulty   int               `json:"difficulty,omitempty"`
+	PreviousHash string            `json:"previous_hash,omitempty"`
+	Hash         string            `json:"hash"`
+}
+
+// newCompactBlock 将区块转换为紧凑表示
+func newCompactBlock(b *Block, opts ViewOptions) compactBlock {
+	return compactBlock{
+		Version:      b.Version,
+		Index:        b.Index,
+		Timestamp:    opts.blockTime(b.Timestamp),
+		Transactions: newTransactionViews(b.Transactions, opts),
+		Nonce:        b.Nonce,
+		Difficulty:   b.Difficulty,
+		PreviousHash: b.PreviousHash,
+		Hash:         b.Hash,
+	}
+}
+
+// renderBlock 按展示选项输出单个区块
+func renderBlock(b *Block, opts ViewOptions) interface{} {
+	switch {
+	case opts.Compact:
+		return newCompactBlock(b, opts)
+	case opts.RFC3339:
+		return blockView{
+			Block:        b,
+			Timestamp:    opts.blockTime(b.Timestamp),
+			Transactions: newTransactionViews(b.Transactions, opts),
+		}
+	default:
+		return b
+	}
+}
+
+// renderChain 按展示选项输出区块序列
+// 紧凑模式下除第一个区块外省略 previous_hash，它等于列表中前一个区块的 hash
+func renderChain(chain []*Block, opts ViewOptions) interface{} {
+	if !opts.Compact && !opts.RFC3339 {
+		return chain
+	}
+
+	blocks := make([]interface{}, len(chain))
+	for i, b := range chain {
+		if !opts.Compact {
+			blocks[i] = renderBlock(b, opts)
+			continue
+		}
+		compact := newCompactBlock(b, opts)
+		if i > 0 {
+			compact.PreviousHash = ""
+		}
+		blocks[i] = compact
+	}
+	return blocks
+}
@@ -0,0 +1,58 @@
+package main
+
+import (
+	"net/http"
+	"testing"
+	"time"
+)
+
+// timestampsView 区块响应中与时间戳相关的字段
+type timestampsView[T any] struct {
+	Timestamp    T      `json:"timestamp"`
+	Hash         string `json:"hash"`
+	Transactions []struct {
+		Timestamp T `json:"timestamp"`
+	} `json:"transactions"`
+}
+
+func TestRFC3339TimestampsRoundTrip(t *testing.T) {
+	bc := newTestChain(t)
+	mustMine(t, bc, "alice")
+	if _, _, err := bc.CreateTransaction("alice", "bob", 1); err != nil {
+		t.Fatal(err)
+	}
+	block := mustMine(t, bc, "miner")
+	txTime := block.Transactions[len(block.Transactions)-1].Timestamp
+	n := newTestNetwork(t, bc)
+
+	for _, query := range []string{"", "?compact=1"} {
+		var unix timestampsView[int64]
+		var rfc timestampsView[string]
+		if status := getJSON(t, n, "/block/2"+query, &unix); status != http.StatusOK {
+			t.Fatalf("/block/2%s 返回 %d", query, status)
+		}
+		sep := "?"
+		if query != "" {
+			sep = "&"
+		}
+		if status := getJSON(t, n, "/block/2"+query+sep+"time=rfc3339", &rfc); status != http.StatusOK {
+			t.Fatalf("/block/2%s 按RFC3339输出时返回 %d", query, status)
+		}
+
+		if unix.Timestamp != block.Timestamp || unix.Transactions[len(unix.Transactions)-1].Timestamp != txTime {
+			t.Fatalf("默认格式的时间戳为 %d，应为区块中的Unix时间 %d", unix.Timestamp, block.Timestamp)
+		}
+		parsed, err := time.Parse(time.RFC3339, rfc.Timestamp)
+		if err != nil || parsed.Unix() != block.Timestamp {
+			t.Fatalf("区块时间 %q 解析为 %v (%v)，应对应 %d", rfc.Timestamp, parsed, err, block.Timestamp)
+		}
+		parsed, err = time.Parse(time.RFC3339Nano, rfc.Transactions[len(rfc.Transactions)-1].Timestamp)
+		if err != nil || parsed.UnixNano() != txTime {
+			t.Fatalf("交易时间解析为 %v (%v)，应对应 %d 纳秒", parsed, err, txTime)
+		}
+		// 展示格式不影响区块哈希
+		if rfc.Hash != block.Hash || unix.Hash != block.Hash {
+			t.Fatalf("两种格式的哈希为 %s、%s，应都为 %s", unix.Hash, rfc.Hash, block.Hash)
+		}
+	}
+}
@@ -0,0 +1,183 @@
+package main
+
+import (
+	"bufio"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strings"
+	"sync"
+)
+
+// websocketGUID 计算 Sec-WebSocket-Accept 时使用的固定GUID（RFC 6455）
+const websocketGUID = "258EAFA5-E914-47DA-95CA-C5AB0DC85B11"
+
+// maxWebSocketFrame 接收的单个帧允许的最大负载，客户端只需发送控制帧
+const maxWebSocketFrame = 4096
+
+// WebSocket帧的操作码
+const (
+	wsOpText  = 0x1
+	wsOpClose = 0x8
+	wsOpPing  = 0x9
+	wsOpPong  = 0xA
+)
+
+// errWebSocketClosed 对端关闭了WebSocket连接
+var errWebSocketClosed = errors.New("WebSocket连接已关闭")
+
+// wsConn 服务端的WebSocket连接，只实现推送通知所需的部分：
+// 发送文本帧，读取并响应控制帧（ping、close），忽略客户端发来的数据帧
+type wsConn struct {
+	conn net.Conn
+	rw   *bufio.ReadWriter
+	mu   sync.Mutex // 串行化写入，读循环回复pong时与推送并发
+}
+
+// upgradeWebSocket 完成WebSocket握手并接管HTTP连接
+func upgradeWebSocket(w http.ResponseWriter, r *http.Request) (*wsConn, error) {
+	if !headerContains(r.Header, "Connection", "upgrade") || !headerContains(r.Header, "Upgrade", "websocket") {
+		return nil, fmt.Errorf("not a WebSocket handshake")
+	}
+	if r.Header.Get("Sec-WebSocket-Version") != "13" {
+		return nil, fmt.Errorf("unsupported WebSocket version")
+	}
+	key := r.Header.Get("Sec-WebSocket-Key")
+	if key == "" {
+		return nil, fmt.Errorf("missing Sec-WebSocket-Key")
+	}
+
+	hijacker, ok := w.(http.Hijacker)
+	if !ok {
+		return nil, fmt.Errorf("connection cannot be upgraded")
+	}
+	conn, rw, err := hijacker.Hijack()
+	if err != nil {
+		return nil, err
+	}
+
+	sum := sha1.Sum([]byte(key + websocketGUID))
+	fmt.Fprintf(rw, "HTTP/1.1 101 Switching Protocols\r\nUpgrade: websocket\r\nConnection: Upgrade\r\nSec-WebSocket-Accept: %s\r\n\r\n",
+		base64.StdEncoding.EncodeToString(sum[:]))
+	if err := rw.Flush(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &wsConn{conn: conn, rw: rw}, nil
+}
+
+// headerContains 判断以逗号分隔的请求头是否包含指定的值（不区分大小写）
+func headerContains(h http.Header, name, value string) bool {
+	for _, v := range h.Values(name) {
+		for _, part := range strings.Split(v, ",") {
+			if strings.EqualFold(strings.TrimSpace(part), value) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// WriteText 发送一个文本帧
+func (c *wsConn) WriteText(data []byte) error {
+	return c.writeFrame(wsOpText, data)
+}
+
+// Close 发送关闭帧并关闭底层连接
+func (c *wsConn) Close() error {
+	c.writeFrame(wsOpClose, nil)
+	return c.conn.Close()
+}
+
+// writeFrame 发送一个完整（FIN）的帧，服务端发出的帧不加掩码
+func (c *wsConn) writeFrame(opcode byte, payload []byte) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	header := []byte{0x80 | opcode}
+	switch n := len(payload); {
+	case n < 126:
+		header = append(header, byte(n))
+	case n <= 0xFFFF:
+		header = append(header, 126)
+		header = binary.BigEndian.AppendUint16(header, uint16(n))
+	default:
+		header = append(header, 127)
+		header = binary.BigEndian.AppendUint64(header, uint64(n))
+	}
+
+	if _, err := c.rw.Write(header); err != nil {
+		return err
+	}
+	if _, err := c.rw.Write(payload); err != nil {
+		return err
+	}
+	return c.rw.Flush()
+}
+
+// readLoop 读取客户端的帧直到连接关闭：回复ping，收到close或读取出错时返回
+func (c *wsConn) readLoop() error {
+	for {
+		opcode, payload, err := c.readFrame()
+		if err != nil {
+			return err
+		}
+		switch opcode {
+		case wsOpClose:
+			return errWebSocketClosed
+		case wsOpPing:
+			if err := c.writeFrame(wsOpPong, payload); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// readFrame 读取一个客户端帧并去除掩码
+func (c *wsConn) readFrame() (byte, []byte, error) {
+	var head [2]byte
+	if _, err := io.ReadFull(c.rw, head[:]); err != nil {
+		return 0, nil, err
+	}
+	opcode := head[0] & 0x0F
+	if head[1]&0x80 == 0 {
+		return 0, nil, fmt.Errorf("客户端帧缺少掩码")
+	}
+
+	length := uint64(head[1] & 0x7F)
+	switch length {
+	case 126:
+		var ext [2]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = uint64(binary.BigEndian.Uint16(ext[:]))
+	case 127:
+		var ext [8]byte
+		if _, err := io.ReadFull(c.rw, ext[:]); err != nil {
+			return 0, nil, err
+		}
+		length = binary.BigEndian.Uint64(ext[:])
+	}
+	if length > maxWebSocketFrame {
+		return 0, nil, fmt.Errorf("帧过大: %d 字节", length)
+	}
+
+	var mask [4]byte
+	if _, err := io.ReadFull(c.rw, mask[:]); err != nil {
+		return 0, nil, err
+	}
+	payload := make([]byte, length)
+	if _, err := io.ReadFull(c.rw, payload); err != nil {
+		return 0, nil, err
+	}
+	for i := range payload {
+		payload[i] ^= mask[i%4]
+	}
+	return opcode, payload, nil
+}
@@ -3,14 +3,75 @@ package main
 import (
 	"fmt"
 	"math/big"
+	"strings"
 )
 
+// RoundMode 表示格式化金额时的舍入方式
+type RoundMode int
+
+const (
+	RoundFloor RoundMode = iota // 向下取整（向负无穷）
+	RoundHalf                   // 四舍五入（0.5远离零）
+	RoundCeil                   // 向上取整（向正无穷）
+)
+
+// weiPerEth 1 ETH = 10^18 wei
+var weiPerEth = new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)
+
 // weiToEth 将wei转换为ETH
 func weiToEth(wei *big.Int) *big.Float {
 	// 1 ETH = 10^18 wei
 	return new(big.Float).Quo(new(big.Float).SetInt(wei), big.NewFloat(1e18))
 }
 
+// WeiToEthString 将wei精确转换为保留decimals位小数的ETH字符串
+// 内部使用big.Rat计算，避免浮点误差，mode指定舍入方式
+func WeiToEthString(wei *big.Int, decimals int, mode RoundMode) string {
+	if decimals < 0 {
+		decimals = 0
+	}
+
+	// eth * 10^decimals = wei * 10^decimals / 10^18
+	scale := new(big.Int).Exp(big.NewInt(10), big.NewInt(int64(decimals)), nil)
+	scaled := new(big.Rat).SetFrac(new(big.Int).Mul(wei, scale), weiPerEth)
+
+	// 按舍入方式取整
+	quo, rem := new(big.Int).QuoRem(scaled.Num(), scaled.Denom(), new(big.Int))
+	if rem.Sign() != 0 {
+		switch mode {
+		case RoundFloor:
+			if rem.Sign() < 0 {
+				quo.Sub(quo, big.NewInt(1))
+			}
+		case RoundCeil:
+			if rem.Sign() > 0 {
+				quo.Add(quo, big.NewInt(1))
+			}
+		case RoundHalf:
+			// |rem| * 2 >= denom 时远离零进位
+			twice := new(big.Int).Mul(new(big.Int).Abs(rem), big.NewInt(2))
+			if twice.Cmp(scaled.Denom()) >= 0 {
+				quo.Add(quo, big.NewInt(int64(rem.Sign())))
+			}
+		}
+	}
+
+	// 格式化整数部分与小数部分
+	sign := ""
+	if quo.Sign() < 0 {
+		sign = "-"
+		quo.Abs(quo)
+	}
+	digits := quo.String()
+	if decimals == 0 {
+		return sign + digits
+	}
+	if len(digits) <= decimals {
+		digits = strings.Repeat("0", decimals-len(digits)+1) + digits
+	}
+	return sign + digits[:len(digits)-decimals] + "." + digits[len(digits)-decimals:]
+}
+
 // gweiToWei 将gwei转换为wei
 func gweiToWei(gwei float64) *big.Int {
 	// 1 gwei = 10^9 wei
@@ -44,4 +105,6 @@ func main() {
 	fmt.Printf("Gas限制: %d\n", gasLimit)
 	fmt.Printf("总Gas费用: %s wei\n", totalGasWei.String())
 	fmt.Printf("总Gas费用: %.19f ETH\n", totalGasEth)
+	fmt.Printf("总Gas费用: %s ETH (精确到18位)\n", WeiToEthString(totalGasWei, 18, RoundFloor))
+	fmt.Printf("总Gas费用: %s ETH (四舍五入到6位)\n", WeiToEthString(totalGasWei, 6, RoundHalf))
 }
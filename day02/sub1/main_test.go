@@ -0,0 +1,56 @@
+package main
+
+import (
+	"math/big"
+	"testing"
+)
+
+// wei 把十进制字符串解析为wei
+func wei(t *testing.T, s string) *big.Int {
+	t.Helper()
+	v, ok := new(big.Int).SetString(s, 10)
+	if !ok {
+		t.Fatalf("无效的wei: %s", s)
+	}
+	return v
+}
+
+func TestWeiToEthStringRounding(t *testing.T) {
+	tests := []struct {
+		name              string
+		wei               string
+		decimals          int
+		floor, half, ceil string
+	}{
+		{"exact", "1000000000000000000", 2, "1.00", "1.00", "1.00"},
+		{"zero", "0", 2, "0.00", "0.00", "0.00"},
+		{"exactly half", "1005000000000000000", 2, "1.00", "1.01", "1.01"},
+		{"just below half", "1004999999999999999", 2, "1.00", "1.00", "1.01"},
+		{"just above half", "1005000000000000001", 2, "1.00", "1.01", "1.01"},
+		{"carry into integer part", "1995000000000000000", 2, "1.99", "2.00", "2.00"},
+		{"negative half", "-1005000000000000000", 2, "-1.01", "-1.01", "-1.00"},
+		{"negative below one unit", "-1", 2, "-0.01", "0.00", "0.00"},
+		{"half to integer", "500000000000000000", 0, "0", "1", "1"},
+		{"below half to integer", "499999999999999999", 0, "0", "0", "1"},
+		{"one wei at full precision", "1", 18, "0.000000000000000001", "0.000000000000000001", "0.000000000000000001"},
+		{"more decimals than wei", "1", 20, "0.00000000000000000100", "0.00000000000000000100", "0.00000000000000000100"},
+		{"negative decimals", "1500000000000000000", -1, "1", "2", "2"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for mode, want := range map[RoundMode]string{RoundFloor: tt.floor, RoundHalf: tt.half, RoundCeil: tt.ceil} {
+				if got := WeiToEthString(wei(t, tt.wei), tt.decimals, mode); got != want {
+					t.Errorf("WeiToEthString(%s, %d, %d) = %s，应为 %s", tt.wei, tt.decimals, mode, got, want)
+				}
+			}
+		})
+	}
+}
+
+func TestWeiToEthStringDoesNotModifyInput(t *testing.T) {
+	v := wei(t, "1005000000000000000")
+	WeiToEthString(v, 2, RoundHalf)
+	if v.String() != "1005000000000000000" {
+		t.Fatalf("输入被修改为 %s", v)
+	}
+}